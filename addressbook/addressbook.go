@@ -0,0 +1,88 @@
+package addressbook
+
+import (
+	"context"
+
+	"github.com/google/wire"
+)
+
+// A Role defines what a member can do in an address book
+type Role string
+
+// Available roles, from the least to the most privileged
+const (
+	RoleViewer Role = "viewer"
+	RoleEditor Role = "editor"
+	RoleOwner  Role = "owner"
+)
+
+// A Permission is an action that requires a minimum role
+type Permission int
+
+// Available permissions
+const (
+	// PermissionRead allows listing the contacts of the book
+	PermissionRead Permission = iota
+	// PermissionWrite allows creating, changing and deleting contacts of the book
+	PermissionWrite
+	// PermissionManage allows managing the book itself and its members
+	PermissionManage
+)
+
+var rank = map[Role]int{RoleViewer: 1, RoleEditor: 2, RoleOwner: 3}
+
+// Valid reports if r is one of the defined roles
+func (r Role) Valid() bool {
+	_, ok := rank[r]
+	return ok
+}
+
+// Allows reports if a member with this role has the provided permission
+func (r Role) Allows(p Permission) bool {
+	switch p {
+	case PermissionRead:
+		return rank[r] >= rank[RoleViewer]
+	case PermissionWrite:
+		return rank[r] >= rank[RoleEditor]
+	case PermissionManage:
+		return rank[r] >= rank[RoleOwner]
+	}
+
+	return false
+}
+
+// An AddressBook is a named collection of contacts shared between its members
+type AddressBook struct {
+	ID   int    `json:"id,omitempty"`
+	Name string `json:"name"`
+	// Role is the role of the current principal in the book, when listing the principal's books
+	Role Role `json:"role,omitempty"`
+}
+
+// A Member is a subject with access to an address book
+type Member struct {
+	AddressBookID int    `json:"address_book_id"`
+	Subject       string `json:"subject"`
+	Role          Role   `json:"role"`
+}
+
+type contextKey struct{}
+
+// WithBookID returns a copy of ctx targeting the address book with the provided ID.
+// Contacts operations without a targeted book work on the principal's private contacts
+func WithBookID(ctx context.Context, id int) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// BookIDFromContext returns the ID of the address book targeted by ctx, if any
+func BookIDFromContext(ctx context.Context) (int, bool) {
+	id, ok := ctx.Value(contextKey{}).(int)
+	return id, ok
+}
+
+// Set is a wire set that contains all the providers of this package
+var Set = wire.NewSet(
+	ControllerSet,
+	ServiceSet,
+	RepositorySet,
+)
@@ -0,0 +1,174 @@
+package addressbook
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/google/wire"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// A Controller provides the HTTP handlers to manage address books and who they are shared with
+type Controller struct {
+	service *Service
+	logger  *zap.Logger
+	echo    *echo.Echo
+}
+
+// ProvideController is responsible by building a Controller object. Designed especially for the use of
+// wire, to provide the dependencies via DI
+func ProvideController(s *Service, logger *zap.Logger, echo *echo.Echo) *Controller {
+	return &Controller{service: s, logger: logger.Named("AddressBookController"), echo: echo}
+}
+
+// writeError maps the service errors to their HTTP responses
+func (ct *Controller) writeError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, ErrAddressBookNotFound), errors.Is(err, ErrNotMember):
+		return c.JSON(http.StatusNotFound, map[string]interface{}{"error": err.Error()})
+	case errors.Is(err, ErrForbidden):
+		return c.JSON(http.StatusForbidden, map[string]interface{}{"error": err.Error()})
+	case errors.Is(err, ErrLastOwner), errors.Is(err, ErrInvalidRole), errors.Is(err, ErrAddressBookNotEmpty):
+		return c.JSON(http.StatusConflict, map[string]interface{}{"error": err.Error()})
+	}
+
+	ct.logger.Error(fmt.Sprintf("%s %s internal server error: %v", c.Request().Method, c.Path(), err))
+	return c.NoContent(http.StatusInternalServerError)
+}
+
+func bookID(c echo.Context) (int, error) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]interface{}{"error": "malformed ID"})
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// FindAll lists the address books shared with the current principal
+func (ct *Controller) FindAll(c echo.Context) error {
+	books, err := ct.service.FindAll(c.Request().Context())
+	if err != nil {
+		return ct.writeError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"address_books": books})
+}
+
+// Create creates a new address book owned by the current principal
+func (ct *Controller) Create(c echo.Context) (err error) {
+	type RequestBody struct {
+		Name string `json:"name" validate:"required,max=100"`
+	}
+
+	body := new(RequestBody)
+	if err = c.Bind(body); err != nil {
+		return
+	}
+
+	if err = c.Validate(body); err != nil {
+		c.JSON(http.StatusBadRequest, map[string]interface{}{"message": err.Error()})
+		return
+	}
+
+	book, err := ct.service.Create(c.Request().Context(), body.Name)
+	if err != nil {
+		return ct.writeError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, book)
+}
+
+// Delete deletes an address book without contacts, answering 409 while it still has some
+func (ct *Controller) Delete(c echo.Context) error {
+	id, err := bookID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := ct.service.Delete(c.Request().Context(), id); err != nil {
+		return ct.writeError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// FindMembers lists who the address book is shared with
+func (ct *Controller) FindMembers(c echo.Context) error {
+	id, err := bookID(c)
+	if err != nil {
+		return err
+	}
+
+	members, err := ct.service.FindMembers(c.Request().Context(), id)
+	if err != nil {
+		return ct.writeError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"members": members})
+}
+
+// SaveMember shares the address book with the subject in the path, with the role provided in the body
+func (ct *Controller) SaveMember(c echo.Context) (err error) {
+	type RequestBody struct {
+		Role string `json:"role" validate:"required,oneof=viewer editor owner"`
+	}
+
+	id, err := bookID(c)
+	if err != nil {
+		return
+	}
+
+	body := new(RequestBody)
+	if err = c.Bind(body); err != nil {
+		return
+	}
+
+	if err = c.Validate(body); err != nil {
+		c.JSON(http.StatusBadRequest, map[string]interface{}{"message": err.Error()})
+		return
+	}
+
+	member := Member{AddressBookID: id, Subject: c.Param("subject"), Role: Role(body.Role)}
+	if err := ct.service.SaveMember(c.Request().Context(), member); err != nil {
+		return ct.writeError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, member)
+}
+
+// RemoveMember stops sharing the address book with the subject in the path
+func (ct *Controller) RemoveMember(c echo.Context) error {
+	id, err := bookID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := ct.service.RemoveMember(c.Request().Context(), id, c.Param("subject")); err != nil {
+		return ct.writeError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// EchoGroup is responsible for building an echo group with all routes for this controller
+func (ct *Controller) EchoGroup() *echo.Group {
+	ct.logger.Debug("Building the AddressBookController routing group...")
+
+	gp := ct.echo.Group("/address-books")
+	gp.GET("/", ct.FindAll)
+	gp.POST("/", ct.Create)
+	gp.DELETE("/:id", ct.Delete)
+	gp.GET("/:id/members", ct.FindMembers)
+	gp.PUT("/:id/members/:subject", ct.SaveMember)
+	gp.DELETE("/:id/members/:subject", ct.RemoveMember)
+
+	return gp
+}
+
+// ControllerSet is a wire set which contains all the bindings needed for building the controller
+var ControllerSet = wire.NewSet(ProvideController)
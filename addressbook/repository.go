@@ -0,0 +1,225 @@
+package addressbook
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/LucasFrezarini/go-contacts/db"
+	"github.com/google/wire"
+	"go.uber.org/zap"
+)
+
+// ErrNotMember is returned when a subject isn't a member of an address book
+var ErrNotMember = errors.New("not a member of the address book")
+
+// GenericRepository defines the structure of an address book repository
+// created to facilitate the mocking in unit testing
+type GenericRepository interface {
+	Create(ctx context.Context, name string, owner string) (*AddressBook, error)
+	FindBySubject(ctx context.Context, subject string) ([]*AddressBook, error)
+	DeleteByID(ctx context.Context, id int) error
+	FindRole(ctx context.Context, id int, subject string) (Role, error)
+	FindMembers(ctx context.Context, id int) ([]Member, error)
+	SaveMember(ctx context.Context, m Member) error
+	DeleteMember(ctx context.Context, id int, subject string) error
+	CountOwners(ctx context.Context, id int) (int, error)
+	CountContacts(ctx context.Context, id int) (int, error)
+	LockMembers(ctx context.Context, id int) error
+}
+
+// A Repository persists the address books and their memberships
+type Repository struct {
+	DB     *sql.DB
+	Logger *zap.Logger
+}
+
+// ProvideRepository creates a new Repository with the dependencies provided.
+// Created especially for the use of Wire, which will inject the dependencies via DI
+func ProvideRepository(db *sql.DB, logger *zap.Logger) *Repository {
+	return &Repository{db, logger.Named("AddressBookRepository")}
+}
+
+// Create creates a new address book, making the provided subject its owner. Both inserts run in the
+// transaction carried by ctx, or in a transaction of their own otherwise
+func (r *Repository) Create(ctx context.Context, name string, owner string) (*AddressBook, error) {
+	var id int64
+
+	err := db.ProvideTxManager(r.DB).InTx(ctx, func(ctx context.Context) error {
+		conn := db.Conn(ctx, r.DB)
+
+		result, err := conn.ExecContext(ctx, "INSERT INTO address_book (name) VALUES (?)", name)
+		if err != nil {
+			return fmt.Errorf("Create(%s, %s): error while inserting address book: %w", name, owner, err)
+		}
+
+		if id, err = result.LastInsertId(); err != nil {
+			return fmt.Errorf("Create(%s, %s): error while fetching the last inserted ID: %w", name, owner, err)
+		}
+
+		raw := "INSERT INTO address_book_member (address_book_id, subject, role) VALUES (?, ?, ?)"
+		if _, err := conn.ExecContext(ctx, raw, id, owner, RoleOwner); err != nil {
+			return fmt.Errorf("Create(%s, %s): error while inserting the owner membership: %w", name, owner, err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &AddressBook{ID: int(id), Name: name, Role: RoleOwner}, nil
+}
+
+// FindBySubject returns all the address books the subject is a member of, with its role in each
+func (r *Repository) FindBySubject(ctx context.Context, subject string) ([]*AddressBook, error) {
+	raw := `SELECT b.id, b.name, m.role FROM address_book b
+		INNER JOIN address_book_member m ON m.address_book_id = b.id
+		WHERE m.subject = ? ORDER BY b.id`
+
+	rows, err := db.Conn(ctx, r.DB).QueryContext(ctx, raw, subject)
+	if err != nil {
+		return nil, fmt.Errorf("FindBySubject(%s): error while executing query: %w", subject, err)
+	}
+
+	defer rows.Close()
+	books := make([]*AddressBook, 0)
+
+	for rows.Next() {
+		var book AddressBook
+
+		if err := rows.Scan(&book.ID, &book.Name, &book.Role); err != nil {
+			return nil, fmt.Errorf("FindBySubject(%s): error while scanning rows: %w", subject, err)
+		}
+
+		books = append(books, &book)
+	}
+
+	return books, nil
+}
+
+// DeleteByID deletes the address book, its memberships and its contacts
+func (r *Repository) DeleteByID(ctx context.Context, id int) error {
+	if _, err := db.Conn(ctx, r.DB).ExecContext(ctx, "DELETE FROM address_book WHERE id = ?", id); err != nil {
+		return fmt.Errorf("DeleteByID(%d): error while executing the delete query: %w", id, err)
+	}
+
+	return nil
+}
+
+// FindRole returns the role of the subject in the address book, or ErrNotMember
+func (r *Repository) FindRole(ctx context.Context, id int, subject string) (Role, error) {
+	raw := "SELECT role FROM address_book_member WHERE address_book_id = ? AND subject = ?"
+
+	var role Role
+	err := db.Conn(ctx, r.DB).QueryRowContext(ctx, raw, id, subject).Scan(&role)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotMember
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("FindRole(%d, %s): error while executing query: %w", id, subject, err)
+	}
+
+	return role, nil
+}
+
+// FindMembers returns all the members of the address book
+func (r *Repository) FindMembers(ctx context.Context, id int) ([]Member, error) {
+	raw := "SELECT address_book_id, subject, role FROM address_book_member WHERE address_book_id = ? ORDER BY subject"
+
+	rows, err := db.Conn(ctx, r.DB).QueryContext(ctx, raw, id)
+	if err != nil {
+		return nil, fmt.Errorf("FindMembers(%d): error while executing query: %w", id, err)
+	}
+
+	defer rows.Close()
+	members := make([]Member, 0)
+
+	for rows.Next() {
+		var m Member
+
+		if err := rows.Scan(&m.AddressBookID, &m.Subject, &m.Role); err != nil {
+			return nil, fmt.Errorf("FindMembers(%d): error while scanning rows: %w", id, err)
+		}
+
+		members = append(members, m)
+	}
+
+	return members, nil
+}
+
+// SaveMember adds the member to the address book, or changes its role if it's already a member
+func (r *Repository) SaveMember(ctx context.Context, m Member) error {
+	raw := `INSERT INTO address_book_member (address_book_id, subject, role) VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE role = VALUES(role)`
+
+	if _, err := db.Conn(ctx, r.DB).ExecContext(ctx, raw, m.AddressBookID, m.Subject, m.Role); err != nil {
+		return fmt.Errorf("SaveMember(%d, %s): error while executing query: %w", m.AddressBookID, m.Subject, err)
+	}
+
+	return nil
+}
+
+// DeleteMember removes the subject from the address book
+func (r *Repository) DeleteMember(ctx context.Context, id int, subject string) error {
+	raw := "DELETE FROM address_book_member WHERE address_book_id = ? AND subject = ?"
+
+	result, err := db.Conn(ctx, r.DB).ExecContext(ctx, raw, id, subject)
+	if err != nil {
+		return fmt.Errorf("DeleteMember(%d, %s): error while executing query: %w", id, subject, err)
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrNotMember
+	}
+
+	return nil
+}
+
+// CountOwners returns how many owners the address book has
+func (r *Repository) CountOwners(ctx context.Context, id int) (int, error) {
+	raw := "SELECT COUNT(*) FROM address_book_member WHERE address_book_id = ? AND role = ?"
+
+	var count int
+	if err := db.Conn(ctx, r.DB).QueryRowContext(ctx, raw, id, RoleOwner).Scan(&count); err != nil {
+		return 0, fmt.Errorf("CountOwners(%d): error while executing query: %w", id, err)
+	}
+
+	return count, nil
+}
+
+// CountContacts returns how many contacts of the address book aren't in the trash. All the contacts of the book
+// stay locked until the end of the transaction carried by ctx, so none can be added or restored meanwhile
+func (r *Repository) CountContacts(ctx context.Context, id int) (int, error) {
+	raw := "SELECT COUNT(*) FROM contact WHERE address_book_id = ? AND deleted_at IS NULL FOR UPDATE"
+
+	var count int
+	if err := db.Conn(ctx, r.DB).QueryRowContext(ctx, raw, id).Scan(&count); err != nil {
+		return 0, fmt.Errorf("CountContacts(%d): error while executing query: %w", id, err)
+	}
+
+	return count, nil
+}
+
+// LockMembers locks the memberships of the address book until the end of the transaction carried by ctx,
+// so the owners can't change between checking and updating them
+func (r *Repository) LockMembers(ctx context.Context, id int) error {
+	raw := "SELECT COUNT(*) FROM address_book_member WHERE address_book_id = ? FOR UPDATE"
+
+	var count int
+	if err := db.Conn(ctx, r.DB).QueryRowContext(ctx, raw, id).Scan(&count); err != nil {
+		return fmt.Errorf("LockMembers(%d): error while executing query: %w", id, err)
+	}
+
+	return nil
+}
+
+// RepositorySet is the wire set that contains all the providers for this repository
+var RepositorySet = wire.NewSet(
+	ProvideRepository,
+	wire.Bind(new(GenericRepository), new(*Repository)),
+)
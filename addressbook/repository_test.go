@@ -0,0 +1,168 @@
+package addressbook
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/LucasFrezarini/go-contacts/db"
+	"go.uber.org/zap"
+)
+
+func TestRepositoryCreate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO address_book").WithArgs("Demon Slayer Corps").WillReturnResult(sqlmock.NewResult(4, 1))
+	mock.ExpectExec("INSERT INTO address_book_member").WithArgs(4, "tanjiro", RoleOwner).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	repository := ProvideRepository(db, zap.NewNop())
+	book, err := repository.Create(context.Background(), "Demon Slayer Corps", "tanjiro")
+
+	if err != nil {
+		t.Fatalf("Create() returned an error %v, want nil", err)
+	}
+
+	if expected := (AddressBook{ID: 4, Name: "Demon Slayer Corps", Role: RoleOwner}); *book != expected {
+		t.Errorf("Create() = %v, want %v", *book, expected)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled mock expectations: %v", err)
+	}
+}
+
+func TestRepositoryCreateRollback(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO address_book").WillReturnResult(sqlmock.NewResult(4, 1))
+	mock.ExpectExec("INSERT INTO address_book_member").WillReturnError(errors.New("connection lost"))
+	mock.ExpectRollback()
+
+	repository := ProvideRepository(db, zap.NewNop())
+
+	if _, err := repository.Create(context.Background(), "Demon Slayer Corps", "tanjiro"); err == nil {
+		t.Error("Create() returned a nil error, want the membership insert error")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled mock expectations: %v", err)
+	}
+}
+
+func TestRepositoryCreateInTx(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer conn.Close()
+
+	// a single transaction: Create joins the one of the caller instead of committing on its own
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO address_book").WithArgs("Demon Slayer Corps").WillReturnResult(sqlmock.NewResult(4, 1))
+	mock.ExpectExec("INSERT INTO address_book_member").WithArgs(4, "tanjiro", RoleOwner).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT address_book_id, subject, role FROM address_book_member").WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"address_book_id", "subject", "role"}).AddRow(4, "tanjiro", "owner"))
+	mock.ExpectQuery("SELECT b.id, b.name, m.role FROM address_book b").WithArgs("tanjiro").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "role"}).AddRow(4, "Demon Slayer Corps", "owner"))
+	mock.ExpectCommit()
+
+	repository := ProvideRepository(conn, zap.NewNop())
+
+	err = db.ProvideTxManager(conn).InTx(context.Background(), func(ctx context.Context) error {
+		book, err := repository.Create(ctx, "Demon Slayer Corps", "tanjiro")
+		if err != nil {
+			return err
+		}
+
+		if _, err := repository.FindMembers(ctx, book.ID); err != nil {
+			return err
+		}
+
+		_, err = repository.FindBySubject(ctx, "tanjiro")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Create(), FindMembers() and FindBySubject() in a transaction returned %v, want nil", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled mock expectations: %v", err)
+	}
+}
+
+func TestRepositoryFindRole(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT role FROM address_book_member").WithArgs(1, "nezuko").
+		WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow("editor"))
+	mock.ExpectQuery("SELECT role FROM address_book_member").WithArgs(1, "inosuke").
+		WillReturnRows(sqlmock.NewRows([]string{"role"}))
+
+	repository := ProvideRepository(db, zap.NewNop())
+
+	if role, err := repository.FindRole(context.Background(), 1, "nezuko"); err != nil || role != RoleEditor {
+		t.Errorf("FindRole() = (%q, %v), want (%q, nil)", role, err, RoleEditor)
+	}
+
+	if _, err := repository.FindRole(context.Background(), 1, "inosuke"); !errors.Is(err, ErrNotMember) {
+		t.Errorf("FindRole() of a non member returned %v, want ErrNotMember", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled mock expectations: %v", err)
+	}
+}
+
+func TestRepositoryLockMembers(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer conn.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM address_book_member WHERE address_book_id = \\? FOR UPDATE").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM address_book_member").WithArgs(1, RoleOwner).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectCommit()
+
+	repository := ProvideRepository(conn, zap.NewNop())
+
+	err = db.ProvideTxManager(conn).InTx(context.Background(), func(ctx context.Context) error {
+		if err := repository.LockMembers(ctx, 1); err != nil {
+			return err
+		}
+
+		_, err := repository.CountOwners(ctx, 1)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("LockMembers() and CountOwners() in a transaction returned %v, want nil", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled mock expectations: %v", err)
+	}
+}
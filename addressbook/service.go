@@ -0,0 +1,246 @@
+package addressbook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/LucasFrezarini/go-contacts/auth"
+	"github.com/LucasFrezarini/go-contacts/db"
+	"github.com/google/wire"
+	"go.uber.org/zap"
+)
+
+// Errors returned by the Service
+var (
+	ErrAddressBookNotFound = errors.New("address book not found")
+	ErrForbidden           = errors.New("forbidden")
+	ErrLastOwner           = errors.New("an address book must keep at least one owner")
+	ErrInvalidRole         = errors.New("invalid role")
+	ErrAddressBookNotEmpty = errors.New("the address book still has contacts, delete them first")
+)
+
+// An Authorizer checks if the current principal has a permission in the address book targeted by
+// the context. Private contacts, without a targeted book, are always fully accessible by their owner
+type Authorizer interface {
+	Authorize(ctx context.Context, p Permission) error
+}
+
+// A Service contains all the business logic related to address books and their sharing
+type Service struct {
+	Logger     *zap.Logger
+	Repository GenericRepository
+	Transactor db.Transactor
+}
+
+// ProvideService creates a new Service with the provided dependencies.
+// Created especially for the use of Wire, who will inject the dependencies via DI
+func ProvideService(logger *zap.Logger, r GenericRepository, tx db.Transactor) *Service {
+	return &Service{logger.Named("AddressBookService"), r, tx}
+}
+
+func subjectFromContext(ctx context.Context) (string, error) {
+	p, ok := auth.FromContext(ctx)
+	if !ok || p.Subject == "" {
+		return "", ErrForbidden
+	}
+
+	return p.Subject, nil
+}
+
+// Authorize implements Authorizer
+func (s *Service) Authorize(ctx context.Context, p Permission) error {
+	subject, err := subjectFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	id, ok := BookIDFromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	return s.authorizeBook(ctx, id, subject, p)
+}
+
+// authorizeBook hides the existence of the books the subject isn't a member of,
+// and forbids the actions its role doesn't allow
+func (s *Service) authorizeBook(ctx context.Context, id int, subject string, p Permission) error {
+	role, err := s.Repository.FindRole(ctx, id, subject)
+
+	if errors.Is(err, ErrNotMember) {
+		return ErrAddressBookNotFound
+	}
+
+	if err != nil {
+		s.Logger.Error(fmt.Sprintf("error while fetching the role of %s in address book %d: %v", subject, id, err))
+		return err
+	}
+
+	if !role.Allows(p) {
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+// Create creates a new address book owned by the current principal
+func (s *Service) Create(ctx context.Context, name string) (*AddressBook, error) {
+	subject, err := subjectFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	book, err := s.Repository.Create(ctx, name, subject)
+	if err != nil {
+		s.Logger.Error(fmt.Sprintf("error while creating address book: %v", err))
+		return nil, err
+	}
+
+	return book, nil
+}
+
+// FindAll returns all the address books shared with the current principal
+func (s *Service) FindAll(ctx context.Context) ([]*AddressBook, error) {
+	subject, err := subjectFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Repository.FindBySubject(ctx, subject)
+}
+
+// Delete deletes the address book. Only owners can do it, and only once all its contacts were deleted,
+// so they're recorded in the audit log and can still be restored. The contacts in the trash are purged with it
+func (s *Service) Delete(ctx context.Context, id int) error {
+	subject, err := subjectFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := s.authorizeBook(ctx, id, subject, PermissionManage); err != nil {
+		return err
+	}
+
+	return s.Transactor.InTx(ctx, func(ctx context.Context) error {
+		count, err := s.Repository.CountContacts(ctx, id)
+		if err != nil {
+			s.Logger.Error(fmt.Sprintf("error while counting the contacts of address book %d: %v", id, err))
+			return err
+		}
+
+		if count > 0 {
+			return ErrAddressBookNotEmpty
+		}
+
+		return s.Repository.DeleteByID(ctx, id)
+	})
+}
+
+// FindMembers returns the members of the address book. Any member can see them
+func (s *Service) FindMembers(ctx context.Context, id int) ([]Member, error) {
+	subject, err := subjectFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.authorizeBook(ctx, id, subject, PermissionRead); err != nil {
+		return nil, err
+	}
+
+	return s.Repository.FindMembers(ctx, id)
+}
+
+// SaveMember shares the address book with a subject, or changes its role. Only owners can do it,
+// and the last owner can't be demoted. The memberships stay locked while they're checked and updated
+func (s *Service) SaveMember(ctx context.Context, m Member) error {
+	if !m.Role.Valid() {
+		return ErrInvalidRole
+	}
+
+	subject, err := subjectFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return s.Transactor.InTx(ctx, func(ctx context.Context) error {
+		if err := s.Repository.LockMembers(ctx, m.AddressBookID); err != nil {
+			return err
+		}
+
+		if err := s.authorizeBook(ctx, m.AddressBookID, subject, PermissionManage); err != nil {
+			return err
+		}
+
+		if m.Role != RoleOwner {
+			if err := s.ensureNotLastOwner(ctx, m.AddressBookID, m.Subject); err != nil {
+				return err
+			}
+		}
+
+		return s.Repository.SaveMember(ctx, m)
+	})
+}
+
+// RemoveMember stops sharing the address book with a subject. Owners can remove anyone,
+// and any member can leave by removing itself, as long as a book owner remains.
+// The memberships stay locked while they're checked and updated
+func (s *Service) RemoveMember(ctx context.Context, id int, member string) error {
+	subject, err := subjectFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	p := PermissionManage
+	if member == subject {
+		p = PermissionRead
+	}
+
+	return s.Transactor.InTx(ctx, func(ctx context.Context) error {
+		if err := s.Repository.LockMembers(ctx, id); err != nil {
+			return err
+		}
+
+		if err := s.authorizeBook(ctx, id, subject, p); err != nil {
+			return err
+		}
+
+		if err := s.ensureNotLastOwner(ctx, id, member); err != nil {
+			return err
+		}
+
+		return s.Repository.DeleteMember(ctx, id, member)
+	})
+}
+
+func (s *Service) ensureNotLastOwner(ctx context.Context, id int, subject string) error {
+	role, err := s.Repository.FindRole(ctx, id, subject)
+	if errors.Is(err, ErrNotMember) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if role != RoleOwner {
+		return nil
+	}
+
+	owners, err := s.Repository.CountOwners(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if owners <= 1 {
+		return ErrLastOwner
+	}
+
+	return nil
+}
+
+// ServiceSet is a wire set which contains all the bindings needed for creating a new service
+var ServiceSet = wire.NewSet(
+	ProvideService,
+	wire.Bind(new(Authorizer), new(*Service)),
+)
@@ -0,0 +1,259 @@
+package addressbook
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/LucasFrezarini/go-contacts/auth"
+	"go.uber.org/zap"
+)
+
+// fakeRepository keeps the memberships of the address books in memory, recording the changes to them
+// and whether they were made inside a transaction of fakeTransactor
+type fakeRepository struct {
+	roles    map[int]map[string]Role
+	contacts map[int]int
+	calls    []string
+}
+
+type txKey struct{}
+
+// fakeTransactor marks the context of the functions it runs as carrying a transaction
+type fakeTransactor struct{}
+
+func (fakeTransactor) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(context.WithValue(ctx, txKey{}, true))
+}
+
+func (f *fakeRepository) record(ctx context.Context, call string) {
+	if ctx.Value(txKey{}) == nil {
+		call += " outside transaction"
+	}
+
+	f.calls = append(f.calls, call)
+}
+
+func newFakeRepository() *fakeRepository {
+	return &fakeRepository{roles: map[int]map[string]Role{
+		1: {"tanjiro": RoleOwner, "nezuko": RoleEditor, "zenitsu": RoleViewer},
+	}}
+}
+
+func (f *fakeRepository) Create(ctx context.Context, name string, owner string) (*AddressBook, error) {
+	id := len(f.roles) + 1
+	f.roles[id] = map[string]Role{owner: RoleOwner}
+
+	return &AddressBook{ID: id, Name: name, Role: RoleOwner}, nil
+}
+
+func (f *fakeRepository) FindBySubject(ctx context.Context, subject string) ([]*AddressBook, error) {
+	books := make([]*AddressBook, 0)
+	for id, members := range f.roles {
+		if role, ok := members[subject]; ok {
+			books = append(books, &AddressBook{ID: id, Role: role})
+		}
+	}
+
+	return books, nil
+}
+
+func (f *fakeRepository) DeleteByID(ctx context.Context, id int) error {
+	f.record(ctx, "DeleteByID")
+	delete(f.roles, id)
+	return nil
+}
+
+func (f *fakeRepository) FindRole(ctx context.Context, id int, subject string) (Role, error) {
+	role, ok := f.roles[id][subject]
+	if !ok {
+		return "", ErrNotMember
+	}
+
+	return role, nil
+}
+
+func (f *fakeRepository) FindMembers(ctx context.Context, id int) ([]Member, error) {
+	members := make([]Member, 0)
+	for subject, role := range f.roles[id] {
+		members = append(members, Member{AddressBookID: id, Subject: subject, Role: role})
+	}
+
+	return members, nil
+}
+
+func (f *fakeRepository) SaveMember(ctx context.Context, m Member) error {
+	f.record(ctx, "SaveMember")
+	f.roles[m.AddressBookID][m.Subject] = m.Role
+	return nil
+}
+
+func (f *fakeRepository) DeleteMember(ctx context.Context, id int, subject string) error {
+	f.record(ctx, "DeleteMember")
+	if _, ok := f.roles[id][subject]; !ok {
+		return ErrNotMember
+	}
+
+	delete(f.roles[id], subject)
+	return nil
+}
+
+func (f *fakeRepository) CountOwners(ctx context.Context, id int) (int, error) {
+	f.record(ctx, "CountOwners")
+	count := 0
+	for _, role := range f.roles[id] {
+		if role == RoleOwner {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (f *fakeRepository) CountContacts(ctx context.Context, id int) (int, error) {
+	f.record(ctx, "CountContacts")
+	return f.contacts[id], nil
+}
+
+func (f *fakeRepository) LockMembers(ctx context.Context, id int) error {
+	f.record(ctx, "LockMembers")
+	return nil
+}
+
+func subjectContext(subject string) context.Context {
+	return auth.WithPrincipal(context.Background(), &auth.Principal{Subject: subject, Method: auth.MethodJWT})
+}
+
+func TestServiceAuthorize(t *testing.T) {
+	testCases := []struct {
+		subject    string
+		permission Permission
+		want       error
+	}{
+		{"zenitsu", PermissionRead, nil},
+		{"zenitsu", PermissionWrite, ErrForbidden},
+		{"nezuko", PermissionWrite, nil},
+		{"nezuko", PermissionManage, ErrForbidden},
+		{"tanjiro", PermissionManage, nil},
+		{"inosuke", PermissionRead, ErrAddressBookNotFound},
+	}
+
+	service := ProvideService(zap.NewNop(), newFakeRepository(), fakeTransactor{})
+
+	for _, tc := range testCases {
+		ctx := WithBookID(subjectContext(tc.subject), 1)
+
+		if err := service.Authorize(ctx, tc.permission); !errors.Is(err, tc.want) {
+			t.Errorf("Authorize() for %s with permission %d returned %v, want %v", tc.subject, tc.permission, err, tc.want)
+		}
+	}
+}
+
+func TestServiceAuthorizePrivateContacts(t *testing.T) {
+	service := ProvideService(zap.NewNop(), newFakeRepository(), fakeTransactor{})
+
+	if err := service.Authorize(subjectContext("inosuke"), PermissionWrite); err != nil {
+		t.Errorf("Authorize() without address book returned %v, want nil", err)
+	}
+
+	if err := service.Authorize(context.Background(), PermissionRead); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Authorize() without principal returned %v, want ErrForbidden", err)
+	}
+}
+
+func TestServiceSaveMember(t *testing.T) {
+	repository := newFakeRepository()
+	service := ProvideService(zap.NewNop(), repository, fakeTransactor{})
+
+	if err := service.SaveMember(subjectContext("nezuko"), Member{AddressBookID: 1, Subject: "inosuke", Role: RoleViewer}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("SaveMember() by an editor returned %v, want ErrForbidden", err)
+	}
+
+	if err := service.SaveMember(subjectContext("tanjiro"), Member{AddressBookID: 1, Subject: "inosuke", Role: "admin"}); !errors.Is(err, ErrInvalidRole) {
+		t.Errorf("SaveMember() with an unknown role returned %v, want ErrInvalidRole", err)
+	}
+
+	if err := service.SaveMember(subjectContext("tanjiro"), Member{AddressBookID: 1, Subject: "tanjiro", Role: RoleEditor}); !errors.Is(err, ErrLastOwner) {
+		t.Errorf("SaveMember() demoting the last owner returned %v, want ErrLastOwner", err)
+	}
+
+	if err := service.SaveMember(subjectContext("tanjiro"), Member{AddressBookID: 1, Subject: "inosuke", Role: RoleViewer}); err != nil {
+		t.Fatalf("SaveMember() by the owner returned %v, want nil", err)
+	}
+
+	if role := repository.roles[1]["inosuke"]; role != RoleViewer {
+		t.Errorf("SaveMember() saved role %q, want %q", role, RoleViewer)
+	}
+}
+
+func TestServiceRemoveMember(t *testing.T) {
+	service := ProvideService(zap.NewNop(), newFakeRepository(), fakeTransactor{})
+
+	if err := service.RemoveMember(subjectContext("zenitsu"), 1, "nezuko"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("RemoveMember() of another member by a viewer returned %v, want ErrForbidden", err)
+	}
+
+	if err := service.RemoveMember(subjectContext("zenitsu"), 1, "zenitsu"); err != nil {
+		t.Errorf("RemoveMember() of itself by a viewer returned %v, want nil", err)
+	}
+
+	if err := service.RemoveMember(subjectContext("tanjiro"), 1, "tanjiro"); !errors.Is(err, ErrLastOwner) {
+		t.Errorf("RemoveMember() of the last owner returned %v, want ErrLastOwner", err)
+	}
+}
+
+func TestServiceMembersLocked(t *testing.T) {
+	repository := newFakeRepository()
+	repository.roles[1]["inosuke"] = RoleOwner
+	service := ProvideService(zap.NewNop(), repository, fakeTransactor{})
+
+	if err := service.SaveMember(subjectContext("tanjiro"), Member{AddressBookID: 1, Subject: "inosuke", Role: RoleEditor}); err != nil {
+		t.Fatalf("SaveMember() demoting an owner returned %v, want nil", err)
+	}
+
+	if err := service.RemoveMember(subjectContext("tanjiro"), 1, "nezuko"); err != nil {
+		t.Fatalf("RemoveMember() by the owner returned %v, want nil", err)
+	}
+
+	// the owners are counted after locking the memberships, in the same transaction as the change
+	expected := []string{"LockMembers", "CountOwners", "SaveMember", "LockMembers", "DeleteMember"}
+	if !reflect.DeepEqual(repository.calls, expected) {
+		t.Errorf("SaveMember() and RemoveMember() made the calls %v, want %v", repository.calls, expected)
+	}
+}
+
+func TestServiceDelete(t *testing.T) {
+	repository := newFakeRepository()
+	repository.contacts = map[int]int{1: 2}
+	service := ProvideService(zap.NewNop(), repository, fakeTransactor{})
+
+	if err := service.Delete(subjectContext("nezuko"), 1); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Delete() by an editor returned %v, want ErrForbidden", err)
+	}
+
+	if err := service.Delete(subjectContext("tanjiro"), 1); !errors.Is(err, ErrAddressBookNotEmpty) {
+		t.Errorf("Delete() of a book with contacts returned %v, want ErrAddressBookNotEmpty", err)
+	}
+
+	if _, ok := repository.roles[1]; !ok {
+		t.Fatal("Delete() of a book with contacts deleted it")
+	}
+
+	repository.contacts[1] = 0
+	repository.calls = nil
+
+	if err := service.Delete(subjectContext("tanjiro"), 1); err != nil {
+		t.Fatalf("Delete() of an empty book returned %v, want nil", err)
+	}
+
+	if _, ok := repository.roles[1]; ok {
+		t.Error("Delete() of an empty book didn't delete it")
+	}
+
+	// the contacts are counted in the same transaction as the deletion
+	expected := []string{"CountContacts", "DeleteByID"}
+	if !reflect.DeepEqual(repository.calls, expected) {
+		t.Errorf("Delete() made the calls %v, want %v", repository.calls, expected)
+	}
+}
@@ -0,0 +1,61 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/LucasFrezarini/go-contacts/auth"
+	"github.com/google/wire"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// A Controller provides the HTTP handlers used to operate the application at runtime
+type Controller struct {
+	level  zap.AtomicLevel
+	logger *zap.Logger
+	echo   *echo.Echo
+}
+
+// ProvideController is responsible by building a Controller object. Designed especially for the use of
+// wire, to provide the dependencies via DI
+func ProvideController(level zap.AtomicLevel, logger *zap.Logger, echo *echo.Echo) *Controller {
+	return &Controller{level: level, logger: logger.Named("AdminController"), echo: echo}
+}
+
+// LogLevel reports the current log level on GET requests and changes it on PUT requests,
+// with a body like {"level": "debug"}
+func (ct *Controller) LogLevel(c echo.Context) error {
+	before := ct.level.String()
+	ct.level.ServeHTTP(c.Response(), c.Request())
+
+	if after := ct.level.String(); after != before {
+		ct.logger.Warn("log level changed", zap.String("from", before), zap.String("to", after))
+	}
+
+	return nil
+}
+
+// requireAdmin forbids the routes of this controller to the principals that aren't admins
+func (ct *Controller) requireAdmin(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if p, ok := auth.FromContext(c.Request().Context()); !ok || !p.Admin {
+			return c.JSON(http.StatusForbidden, map[string]interface{}{"error": "only admins can operate the application"})
+		}
+
+		return next(c)
+	}
+}
+
+// EchoGroup is responsible for building an echo group with all routes for this controller
+func (ct *Controller) EchoGroup() *echo.Group {
+	ct.logger.Debug("Building the AdminController routing group...")
+
+	gp := ct.echo.Group("/admin", ct.requireAdmin)
+	gp.GET("/log/level", ct.LogLevel)
+	gp.PUT("/log/level", ct.LogLevel)
+
+	return gp
+}
+
+// Set is a wire set which contains all the providers of this package
+var Set = wire.NewSet(ProvideController)
@@ -0,0 +1,82 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/LucasFrezarini/go-contacts/auth"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// newAdminEcho builds the admin routes, with every request made by the provided principal
+func newAdminEcho(level zap.AtomicLevel, principal *auth.Principal) *echo.Echo {
+	e := echo.New()
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.SetRequest(c.Request().WithContext(auth.WithPrincipal(c.Request().Context(), principal)))
+			return next(c)
+		}
+	})
+
+	ProvideController(level, zap.NewNop(), e).EchoGroup()
+
+	return e
+}
+
+func TestLogLevel(t *testing.T) {
+	level := zap.NewAtomicLevelAt(zap.InfoLevel)
+	e := newAdminEcho(level, &auth.Principal{Subject: "urokodaki", Method: auth.MethodJWT, Admin: true})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/log/level", nil))
+
+	if expected := http.StatusOK; rec.Code != expected {
+		t.Errorf("GET /admin/log/level wrote status %d, want %d", rec.Code, expected)
+	}
+
+	if body := rec.Body.String(); !strings.Contains(body, `"info"`) {
+		t.Errorf("GET /admin/log/level body == %s, want the current level", body)
+	}
+
+	req := httptest.NewRequest(http.MethodPut, "/admin/log/level", strings.NewReader(`{"level":"debug"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if expected := http.StatusOK; rec.Code != expected {
+		t.Errorf("PUT /admin/log/level wrote status %d, want %d", rec.Code, expected)
+	}
+
+	if expected, got := zapcore.DebugLevel, level.Level(); expected != got {
+		t.Errorf("PUT /admin/log/level changed the level to %v, want %v", got, expected)
+	}
+}
+
+func TestLogLevelForbidden(t *testing.T) {
+	level := zap.NewAtomicLevelAt(zap.InfoLevel)
+	e := newAdminEcho(level, &auth.Principal{Subject: "tanjiro", Method: auth.MethodJWT})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/log/level", nil))
+
+	if expected := http.StatusForbidden; rec.Code != expected {
+		t.Errorf("GET /admin/log/level by a user wrote status %d, want %d", rec.Code, expected)
+	}
+
+	req := httptest.NewRequest(http.MethodPut, "/admin/log/level", strings.NewReader(`{"level":"debug"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if expected := http.StatusForbidden; rec.Code != expected {
+		t.Errorf("PUT /admin/log/level by a user wrote status %d, want %d", rec.Code, expected)
+	}
+
+	if expected, got := zapcore.InfoLevel, level.Level(); expected != got {
+		t.Errorf("PUT /admin/log/level by a user changed the level to %v, want it unchanged", got)
+	}
+}
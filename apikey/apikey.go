@@ -0,0 +1,89 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/google/wire"
+)
+
+// Every API key looks like gck_<prefix>_<secret>. The prefix is stored in clear, so the owner
+// can recognize the key and the server can find it, while the whole key is only stored hashed
+const (
+	keyMarker    = "gck_"
+	prefixLength = 8
+	secretLength = 32
+)
+
+// An APIKey lets a machine client authenticate on behalf of the subject that created it
+type APIKey struct {
+	ID         int        `json:"id"`
+	Subject    string     `json:"-"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Hash       string     `json:"-"`
+	Scope      string     `json:"scope"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Revoked checks if the key was revoked, so it can't authenticate anymore
+func (k *APIKey) Revoked() bool {
+	return k.RevokedAt != nil
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// generate creates a new random key, returning it along with its visible prefix
+func generate() (key string, prefix string, err error) {
+	prefix, err = randomHex(prefixLength / 2)
+	if err != nil {
+		return "", "", err
+	}
+
+	secret, err := randomHex(secretLength)
+	if err != nil {
+		return "", "", err
+	}
+
+	return keyMarker + prefix + "_" + secret, prefix, nil
+}
+
+// parsePrefix extracts the visible prefix of a key
+func parsePrefix(key string) (string, bool) {
+	if !strings.HasPrefix(key, keyMarker) {
+		return "", false
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(key, keyMarker), "_", 2)
+	if len(parts) != 2 || len(parts[0]) != prefixLength || parts[1] == "" {
+		return "", false
+	}
+
+	return parts[0], true
+}
+
+// hash returns the hex encoded SHA-256 of the key. Keys are long random strings,
+// so a fast hash is enough to make the stored values useless if they leak
+func hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Set is a wire set that contains all the providers of this package
+var Set = wire.NewSet(
+	ControllerSet,
+	ServiceSet,
+	RepositorySet,
+)
@@ -0,0 +1,105 @@
+package apikey
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/google/wire"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// A Controller provides the HTTP handlers to manage the API keys of the current user
+type Controller struct {
+	service *Service
+	logger  *zap.Logger
+	echo    *echo.Echo
+}
+
+// ProvideController is responsible by building a Controller object. Designed especially for the use of
+// wire, to provide the dependencies via DI
+func ProvideController(s *Service, logger *zap.Logger, echo *echo.Echo) *Controller {
+	return &Controller{service: s, logger: logger.Named("APIKeyController"), echo: echo}
+}
+
+// writeError maps the service errors to their HTTP responses
+func (ct *Controller) writeError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, ErrKeyNotFound):
+		return c.JSON(http.StatusNotFound, map[string]interface{}{"error": err.Error()})
+	case errors.Is(err, ErrForbidden):
+		return c.JSON(http.StatusForbidden, map[string]interface{}{"error": err.Error()})
+	}
+
+	ct.logger.Error(fmt.Sprintf("%s %s internal server error: %v", c.Request().Method, c.Path(), err))
+	return c.NoContent(http.StatusInternalServerError)
+}
+
+// FindAll lists the API keys of the current user. The keys themselves are never returned
+func (ct *Controller) FindAll(c echo.Context) error {
+	keys, err := ct.service.FindAll(c.Request().Context())
+	if err != nil {
+		return ct.writeError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"api_keys": keys})
+}
+
+// Create creates a new API key. The response is the only time the key is shown
+func (ct *Controller) Create(c echo.Context) (err error) {
+	type RequestBody struct {
+		Name  string `json:"name" validate:"required,max=100"`
+		Scope string `json:"scope" validate:"required,oneof=read read-write"`
+	}
+
+	body := new(RequestBody)
+	if err = c.Bind(body); err != nil {
+		return
+	}
+
+	if err = c.Validate(body); err != nil {
+		c.JSON(http.StatusBadRequest, map[string]interface{}{"message": err.Error()})
+		return
+	}
+
+	created, key, err := ct.service.Create(c.Request().Context(), body.Name, body.Scope)
+	if err != nil {
+		return ct.writeError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, struct {
+		*APIKey
+		Key string `json:"key"`
+	}{created, key})
+}
+
+// Revoke revokes the API key with the ID in the path
+func (ct *Controller) Revoke(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": "malformed ID"})
+	}
+
+	if err := ct.service.Revoke(c.Request().Context(), int(id)); err != nil {
+		return ct.writeError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// EchoGroup is responsible for building an echo group with all routes for this controller
+func (ct *Controller) EchoGroup() *echo.Group {
+	ct.logger.Debug("Building the APIKeyController routing group...")
+
+	gp := ct.echo.Group("/api-keys")
+	gp.GET("/", ct.FindAll)
+	gp.POST("/", ct.Create)
+	gp.DELETE("/:id", ct.Revoke)
+
+	return gp
+}
+
+// ControllerSet is a wire set which contains all the bindings needed for building the controller
+var ControllerSet = wire.NewSet(ProvideController)
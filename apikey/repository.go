@@ -0,0 +1,163 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/google/wire"
+	"go.uber.org/zap"
+)
+
+// ErrKeyNotFound is returned when the key doesn't exist, or doesn't belong to the subject
+var ErrKeyNotFound = errors.New("API key not found")
+
+// ErrDuplicatePrefix is returned when creating a key whose prefix is already used by another one
+var ErrDuplicatePrefix = errors.New("API key prefix already used")
+
+// errDuplicateEntry is the number of the MySQL error raised when an unique index is violated
+const errDuplicateEntry = 1062
+
+// GenericRepository defines the structure of an API key repository
+// created to facilitate the mocking in unit testing
+type GenericRepository interface {
+	Create(ctx context.Context, k APIKey) (*APIKey, error)
+	FindBySubject(ctx context.Context, subject string) ([]*APIKey, error)
+	FindByPrefix(ctx context.Context, prefix string) (*APIKey, error)
+	Revoke(ctx context.Context, id int, subject string, at time.Time) error
+	Touch(ctx context.Context, id int, at time.Time) error
+}
+
+// A Repository persists the API keys
+type Repository struct {
+	DB     *sql.DB
+	Logger *zap.Logger
+}
+
+// ProvideRepository creates a new Repository with the dependencies provided.
+// Created especially for the use of Wire, which will inject the dependencies via DI
+func ProvideRepository(db *sql.DB, logger *zap.Logger) *Repository {
+	return &Repository{db, logger.Named("APIKeyRepository")}
+}
+
+const columns = "id, subject, name, prefix, hash, scope, created_at, last_used_at, revoked_at"
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scan(s scanner) (*APIKey, error) {
+	var k APIKey
+	var lastUsed, revoked sql.NullTime
+
+	if err := s.Scan(&k.ID, &k.Subject, &k.Name, &k.Prefix, &k.Hash, &k.Scope, &k.CreatedAt, &lastUsed, &revoked); err != nil {
+		return nil, err
+	}
+
+	if lastUsed.Valid {
+		k.LastUsedAt = &lastUsed.Time
+	}
+
+	if revoked.Valid {
+		k.RevokedAt = &revoked.Time
+	}
+
+	return &k, nil
+}
+
+// Create stores a new API key, returning it with its ID. When its prefix is already used, ErrDuplicatePrefix
+// is returned
+func (r *Repository) Create(ctx context.Context, k APIKey) (*APIKey, error) {
+	raw := "INSERT INTO api_key (subject, name, prefix, hash, scope, created_at) VALUES (?, ?, ?, ?, ?, ?)"
+
+	result, err := r.DB.ExecContext(ctx, raw, k.Subject, k.Name, k.Prefix, k.Hash, k.Scope, k.CreatedAt)
+	if err != nil {
+		// the prefix is the only unique column filled by the insert
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == errDuplicateEntry {
+			return nil, fmt.Errorf("create: %w", ErrDuplicatePrefix)
+		}
+
+		return nil, fmt.Errorf("create: error while executing insert query: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("create: error while fetching the last inserted ID: %w", err)
+	}
+
+	k.ID = int(id)
+	return &k, nil
+}
+
+// FindBySubject returns all the keys created by the subject, including the revoked ones
+func (r *Repository) FindBySubject(ctx context.Context, subject string) ([]*APIKey, error) {
+	rows, err := r.DB.QueryContext(ctx, "SELECT "+columns+" FROM api_key WHERE subject = ? ORDER BY id", subject)
+	if err != nil {
+		return nil, fmt.Errorf("FindBySubject(%s): error while executing query: %w", subject, err)
+	}
+
+	defer rows.Close()
+	keys := make([]*APIKey, 0)
+
+	for rows.Next() {
+		k, err := scan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("FindBySubject(%s): error while scanning rows: %w", subject, err)
+		}
+
+		keys = append(keys, k)
+	}
+
+	return keys, nil
+}
+
+// FindByPrefix returns the key with the provided visible prefix, or ErrKeyNotFound
+func (r *Repository) FindByPrefix(ctx context.Context, prefix string) (*APIKey, error) {
+	row := r.DB.QueryRowContext(ctx, "SELECT "+columns+" FROM api_key WHERE prefix = ?", prefix)
+
+	k, err := scan(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrKeyNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("FindByPrefix(%s): error while executing query: %w", prefix, err)
+	}
+
+	return k, nil
+}
+
+// Revoke revokes an active key of the subject, or returns ErrKeyNotFound
+func (r *Repository) Revoke(ctx context.Context, id int, subject string, at time.Time) error {
+	raw := "UPDATE api_key SET revoked_at = ? WHERE id = ? AND subject = ? AND revoked_at IS NULL"
+
+	result, err := r.DB.ExecContext(ctx, raw, at, id, subject)
+	if err != nil {
+		return fmt.Errorf("Revoke(%d): error while executing query: %w", id, err)
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrKeyNotFound
+	}
+
+	return nil
+}
+
+// Touch records that the key was used at the provided time
+func (r *Repository) Touch(ctx context.Context, id int, at time.Time) error {
+	if _, err := r.DB.ExecContext(ctx, "UPDATE api_key SET last_used_at = ? WHERE id = ?", at, id); err != nil {
+		return fmt.Errorf("Touch(%d): error while executing query: %w", id, err)
+	}
+
+	return nil
+}
+
+// RepositorySet is the wire set that contains all the providers for this repository
+var RepositorySet = wire.NewSet(
+	ProvideRepository,
+	wire.Bind(new(GenericRepository), new(*Repository)),
+)
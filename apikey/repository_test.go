@@ -0,0 +1,128 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"go.uber.org/zap"
+)
+
+func TestRepositoryCreate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	k := APIKey{Subject: "tanjiro", Name: "sync job", Prefix: "a1b2c3d4", Hash: "hash", Scope: "read", CreatedAt: time.Now()}
+
+	mock.ExpectExec("INSERT INTO api_key").WithArgs(k.Subject, k.Name, k.Prefix, k.Hash, k.Scope, k.CreatedAt).WillReturnResult(sqlmock.NewResult(3, 1))
+
+	repository := ProvideRepository(db, zap.NewNop())
+	created, err := repository.Create(context.Background(), k)
+
+	if err != nil {
+		t.Fatalf("Create() returned an error %v, want nil", err)
+	}
+
+	if expected := 3; created.ID != expected {
+		t.Errorf("Create() key.ID == %d, want %d", created.ID, expected)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled mock expectations: %v", err)
+	}
+}
+
+func TestRepositoryCreateDuplicatePrefix(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	k := APIKey{Subject: "tanjiro", Name: "sync job", Prefix: "a1b2c3d4", Hash: "hash", Scope: "read", CreatedAt: time.Now()}
+
+	mock.ExpectExec("INSERT INTO api_key").
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'a1b2c3d4' for key 'idx_api_key_prefix'"})
+	mock.ExpectExec("INSERT INTO api_key").WillReturnError(&mysql.MySQLError{Number: 1406, Message: "Data too long for column 'name'"})
+
+	repository := ProvideRepository(db, zap.NewNop())
+
+	if _, err := repository.Create(context.Background(), k); !errors.Is(err, ErrDuplicatePrefix) {
+		t.Errorf("Create() with a used prefix returned %v, want ErrDuplicatePrefix", err)
+	}
+
+	if _, err := repository.Create(context.Background(), k); err == nil || errors.Is(err, ErrDuplicatePrefix) {
+		t.Errorf("Create() with a too long name returned %v, want another error", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled mock expectations: %v", err)
+	}
+}
+
+func TestRepositoryFindByPrefix(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	created := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	cols := []string{"id", "subject", "name", "prefix", "hash", "scope", "created_at", "last_used_at", "revoked_at"}
+
+	mock.ExpectQuery("SELECT (.+) FROM api_key WHERE prefix = (.+)").WithArgs("a1b2c3d4").
+		WillReturnRows(sqlmock.NewRows(cols).AddRow(3, "tanjiro", "sync job", "a1b2c3d4", "hash", "read", created, nil, created))
+	mock.ExpectQuery("SELECT (.+) FROM api_key WHERE prefix = (.+)").WithArgs("ffffffff").
+		WillReturnRows(sqlmock.NewRows(cols))
+
+	repository := ProvideRepository(db, zap.NewNop())
+
+	k, err := repository.FindByPrefix(context.Background(), "a1b2c3d4")
+	if err != nil {
+		t.Fatalf("FindByPrefix() returned an error %v, want nil", err)
+	}
+
+	if k.ID != 3 || k.Subject != "tanjiro" || k.LastUsedAt != nil || !k.Revoked() {
+		t.Errorf("FindByPrefix() = %+v, want the revoked, never used key 3 of tanjiro", k)
+	}
+
+	if _, err := repository.FindByPrefix(context.Background(), "ffffffff"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("FindByPrefix() of an unknown prefix returned %v, want ErrKeyNotFound", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled mock expectations: %v", err)
+	}
+}
+
+func TestRepositoryRevoke(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	// revoked keys, or keys of other subjects, don't match the update
+	mock.ExpectExec("UPDATE api_key SET revoked_at = (.+) WHERE id = (.+) AND subject = (.+) AND revoked_at IS NULL").
+		WithArgs(sqlmock.AnyArg(), 3, "zenitsu").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repository := ProvideRepository(db, zap.NewNop())
+
+	if err := repository.Revoke(context.Background(), 3, "zenitsu", time.Now()); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Revoke() returned %v, want ErrKeyNotFound", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled mock expectations: %v", err)
+	}
+}
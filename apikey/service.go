@@ -0,0 +1,142 @@
+package apikey
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/LucasFrezarini/go-contacts/auth"
+	"github.com/google/wire"
+	"go.uber.org/zap"
+)
+
+// Errors returned by the Service
+var (
+	ErrForbidden    = errors.New("API keys can only be managed by users")
+	ErrInvalidScope = errors.New("invalid scope")
+)
+
+// createAttempts is the number of keys generated by Create until one has a prefix not used yet. The prefixes are
+// short, so they may collide once there are many keys
+const createAttempts = 3
+
+// A Service contains all the business logic related to API keys
+type Service struct {
+	Logger     *zap.Logger
+	Repository GenericRepository
+	now        func() time.Time
+}
+
+// ProvideService creates a new Service with the provided dependencies.
+// Created especially for the use of Wire, who will inject the dependencies via DI
+func ProvideService(logger *zap.Logger, r GenericRepository) *Service {
+	return &Service{logger.Named("APIKeyService"), r, time.Now}
+}
+
+// userFromContext returns the subject of the current principal. Keys can't be managed with
+// another key, so a leaked key can't be used to mint new ones
+func userFromContext(ctx context.Context) (string, error) {
+	p, ok := auth.FromContext(ctx)
+	if !ok || p.Subject == "" || p.Method == auth.MethodAPIKey {
+		return "", ErrForbidden
+	}
+
+	return p.Subject, nil
+}
+
+// Create creates a new key for the current user. The clear key is returned only here,
+// as only its hash is stored. A new key is generated when the prefix of the previous one is already used
+func (s *Service) Create(ctx context.Context, name string, scope string) (*APIKey, string, error) {
+	if scope != auth.ScopeRead && scope != auth.ScopeReadWrite {
+		return nil, "", ErrInvalidScope
+	}
+
+	subject, err := userFromContext(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	for attempt := 1; ; attempt++ {
+		key, prefix, err := generate()
+		if err != nil {
+			return nil, "", fmt.Errorf("error while generating the API key: %w", err)
+		}
+
+		created, err := s.Repository.Create(ctx, APIKey{
+			Subject:   subject,
+			Name:      name,
+			Prefix:    prefix,
+			Hash:      hash(key),
+			Scope:     scope,
+			CreatedAt: s.now().UTC(),
+		})
+
+		if errors.Is(err, ErrDuplicatePrefix) && attempt < createAttempts {
+			s.Logger.Warn(fmt.Sprintf("the prefix %s of the new API key is already used, generating another key", prefix))
+			continue
+		}
+
+		if err != nil {
+			s.Logger.Error(fmt.Sprintf("error while creating API key: %v", err))
+			return nil, "", err
+		}
+
+		return created, key, nil
+	}
+}
+
+// FindAll returns all the keys of the current user
+func (s *Service) FindAll(ctx context.Context) ([]*APIKey, error) {
+	subject, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Repository.FindBySubject(ctx, subject)
+}
+
+// Revoke revokes a key of the current user, so it can't authenticate anymore
+func (s *Service) Revoke(ctx context.Context, id int) error {
+	subject, err := userFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return s.Repository.Revoke(ctx, id, subject, s.now().UTC())
+}
+
+// AuthenticateKey implements auth.KeyAuthenticator
+func (s *Service) AuthenticateKey(ctx context.Context, key string) (*auth.Principal, error) {
+	prefix, ok := parsePrefix(key)
+	if !ok {
+		return nil, auth.ErrInvalidAPIKey
+	}
+
+	k, err := s.Repository.FindByPrefix(ctx, prefix)
+	if errors.Is(err, ErrKeyNotFound) {
+		return nil, auth.ErrInvalidAPIKey
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if k.Revoked() || subtle.ConstantTimeCompare([]byte(k.Hash), []byte(hash(key))) != 1 {
+		return nil, auth.ErrInvalidAPIKey
+	}
+
+	// failing to record the usage must not lock the client out
+	if err := s.Repository.Touch(ctx, k.ID, s.now().UTC()); err != nil {
+		s.Logger.Warn(fmt.Sprintf("error while recording the usage of API key %d: %v", k.ID, err))
+	}
+
+	return &auth.Principal{Subject: k.Subject, Method: auth.MethodAPIKey, Scope: k.Scope, KeyID: k.ID}, nil
+}
+
+// ServiceSet is a wire set which contains all the bindings needed for creating a new service
+var ServiceSet = wire.NewSet(
+	ProvideService,
+	wire.Bind(new(auth.KeyAuthenticator), new(*Service)),
+)
@@ -0,0 +1,206 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LucasFrezarini/go-contacts/auth"
+	"go.uber.org/zap"
+)
+
+// fakeRepository keeps the API keys in memory. The first collisions keys created have a prefix already used
+type fakeRepository struct {
+	keys       []*APIKey
+	touched    map[int]time.Time
+	collisions int
+	prefixes   []string
+}
+
+func (f *fakeRepository) Create(ctx context.Context, k APIKey) (*APIKey, error) {
+	f.prefixes = append(f.prefixes, k.Prefix)
+	if f.collisions > 0 {
+		f.collisions--
+		return nil, ErrDuplicatePrefix
+	}
+
+	k.ID = len(f.keys) + 1
+	f.keys = append(f.keys, &k)
+
+	return &k, nil
+}
+
+func (f *fakeRepository) FindBySubject(ctx context.Context, subject string) ([]*APIKey, error) {
+	keys := make([]*APIKey, 0)
+	for _, k := range f.keys {
+		if k.Subject == subject {
+			keys = append(keys, k)
+		}
+	}
+
+	return keys, nil
+}
+
+func (f *fakeRepository) FindByPrefix(ctx context.Context, prefix string) (*APIKey, error) {
+	for _, k := range f.keys {
+		if k.Prefix == prefix {
+			return k, nil
+		}
+	}
+
+	return nil, ErrKeyNotFound
+}
+
+func (f *fakeRepository) Revoke(ctx context.Context, id int, subject string, at time.Time) error {
+	for _, k := range f.keys {
+		if k.ID == id && k.Subject == subject && !k.Revoked() {
+			k.RevokedAt = &at
+			return nil
+		}
+	}
+
+	return ErrKeyNotFound
+}
+
+func (f *fakeRepository) Touch(ctx context.Context, id int, at time.Time) error {
+	f.touched[id] = at
+	return nil
+}
+
+func newTestService() (*Service, *fakeRepository) {
+	repository := &fakeRepository{touched: map[int]time.Time{}}
+	service := ProvideService(zap.NewNop(), repository)
+	service.now = func() time.Time { return time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC) }
+
+	return service, repository
+}
+
+func userContext(subject string) context.Context {
+	return auth.WithPrincipal(context.Background(), &auth.Principal{Subject: subject, Method: auth.MethodJWT})
+}
+
+func TestServiceCreate(t *testing.T) {
+	service, repository := newTestService()
+
+	created, key, err := service.Create(userContext("tanjiro"), "sync job", auth.ScopeRead)
+	if err != nil {
+		t.Fatalf("Create() returned an error %v, want nil", err)
+	}
+
+	if !strings.HasPrefix(key, keyMarker+created.Prefix+"_") {
+		t.Errorf("Create() returned key %q, want it to start with its prefix %q", key, created.Prefix)
+	}
+
+	stored := repository.keys[0]
+	if stored.Hash == key || stored.Hash != hash(key) {
+		t.Errorf("Create() stored hash %q, want the SHA-256 of the key", stored.Hash)
+	}
+
+	if stored.Subject != "tanjiro" || stored.Scope != auth.ScopeRead {
+		t.Errorf("Create() stored subject %q with scope %q, want tanjiro with %q", stored.Subject, stored.Scope, auth.ScopeRead)
+	}
+
+	if _, _, err := service.Create(userContext("tanjiro"), "admin", "admin"); !errors.Is(err, ErrInvalidScope) {
+		t.Errorf("Create() with an unknown scope returned %v, want ErrInvalidScope", err)
+	}
+}
+
+func TestServiceCreateDuplicatePrefix(t *testing.T) {
+	var testCases = []struct {
+		testName   string
+		collisions int
+		tries      int
+		err        error
+	}{
+		{"new_prefix", 0, 1, nil},
+		{"retried", createAttempts - 1, createAttempts, nil},
+		{"exhausted", createAttempts, createAttempts, ErrDuplicatePrefix},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			service, repository := newTestService()
+			repository.collisions = tc.collisions
+
+			created, key, err := service.Create(userContext("tanjiro"), "sync job", auth.ScopeRead)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("Create() returned %v, want %v", err, tc.err)
+			}
+
+			if got := len(repository.prefixes); got != tc.tries {
+				t.Errorf("Create() tried %d keys, want %d", got, tc.tries)
+			}
+
+			if tc.err == nil && !strings.HasPrefix(key, keyMarker+repository.prefixes[tc.tries-1]+"_") {
+				t.Errorf("Create() returned key %q, want the last one tried with the prefix %q", key, repository.prefixes[tc.tries-1])
+			}
+
+			if tc.err == nil && created.Prefix != repository.prefixes[tc.tries-1] {
+				t.Errorf("Create() returned the prefix %q, want %q", created.Prefix, repository.prefixes[tc.tries-1])
+			}
+		})
+	}
+}
+
+func TestServiceCreateWithAPIKey(t *testing.T) {
+	service, _ := newTestService()
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "tanjiro", Method: auth.MethodAPIKey, Scope: auth.ScopeReadWrite})
+
+	if _, _, err := service.Create(ctx, "another key", auth.ScopeReadWrite); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Create() authenticated by an API key returned %v, want ErrForbidden", err)
+	}
+}
+
+func TestServiceAuthenticateKey(t *testing.T) {
+	service, repository := newTestService()
+	ctx := userContext("tanjiro")
+
+	created, key, err := service.Create(ctx, "sync job", auth.ScopeReadWrite)
+	if err != nil {
+		t.Fatalf("Create() returned an error %v, want nil", err)
+	}
+
+	principal, err := service.AuthenticateKey(context.Background(), key)
+	if err != nil {
+		t.Fatalf("AuthenticateKey() returned an error %v, want nil", err)
+	}
+
+	expected := auth.Principal{Subject: "tanjiro", Method: auth.MethodAPIKey, Scope: auth.ScopeReadWrite, KeyID: created.ID}
+	if *principal != expected {
+		t.Errorf("AuthenticateKey() = %+v, want %+v", *principal, expected)
+	}
+
+	if _, ok := repository.touched[created.ID]; !ok {
+		t.Error("AuthenticateKey() didn't record the key usage")
+	}
+
+	tampered := keyMarker + created.Prefix + "_" + strings.Repeat("0", secretLength*2)
+	for _, invalid := range []string{"", "gck_", "not-a-key", tampered} {
+		if _, err := service.AuthenticateKey(context.Background(), invalid); !errors.Is(err, auth.ErrInvalidAPIKey) {
+			t.Errorf("AuthenticateKey(%q) returned %v, want ErrInvalidAPIKey", invalid, err)
+		}
+	}
+
+	if err := service.Revoke(ctx, created.ID); err != nil {
+		t.Fatalf("Revoke() returned an error %v, want nil", err)
+	}
+
+	if _, err := service.AuthenticateKey(context.Background(), key); !errors.Is(err, auth.ErrInvalidAPIKey) {
+		t.Errorf("AuthenticateKey() of a revoked key returned %v, want ErrInvalidAPIKey", err)
+	}
+}
+
+func TestServiceRevokeOtherSubject(t *testing.T) {
+	service, _ := newTestService()
+
+	created, _, err := service.Create(userContext("tanjiro"), "sync job", auth.ScopeRead)
+	if err != nil {
+		t.Fatalf("Create() returned an error %v, want nil", err)
+	}
+
+	if err := service.Revoke(userContext("zenitsu"), created.ID); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Revoke() of another subject's key returned %v, want ErrKeyNotFound", err)
+	}
+}
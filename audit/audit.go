@@ -0,0 +1,122 @@
+package audit
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/google/wire"
+)
+
+// An Action is a kind of change recorded in the audit log
+type Action string
+
+// Recorded actions
+const (
+	ActionCreate  Action = "create"
+	ActionUpdate  Action = "update"
+	ActionDelete  Action = "delete"
+	ActionRestore Action = "restore"
+)
+
+// An Entity is a kind of resource whose changes are recorded in the audit log
+type Entity string
+
+// Audited entities
+const (
+	EntityContact Entity = "contact"
+	EntityEmail   Entity = "email"
+	EntityPhone   Entity = "phone"
+	EntityAddress Entity = "address"
+)
+
+// Entities are all the audited entities, each of them must be one of the values of the audit_entry.entity column
+var Entities = []Entity{EntityContact, EntityEmail, EntityPhone, EntityAddress}
+
+// A Change is the value of a field before and after it was changed
+type Change struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// An Entry records a change made to a contact, or to one of its emails, phones or addresses
+type Entry struct {
+	ID int64 `json:"id"`
+	// Actor is the subject of the principal that made the change
+	Actor     string `json:"actor"`
+	RequestID string `json:"request_id,omitempty"`
+	Action    Action `json:"action"`
+	Entity    Entity `json:"entity"`
+	EntityID  int    `json:"entity_id"`
+	// ContactID is the contact that was changed, or that owns the changed email, phone or address
+	ContactID     int  `json:"contact_id"`
+	AddressBookID *int `json:"address_book_id,omitempty"`
+	// Owner is the subject owning the contact, when it isn't in an address book
+	Owner     string            `json:"-"`
+	Before    json.RawMessage   `json:"before,omitempty"`
+	After     json.RawMessage   `json:"after,omitempty"`
+	Changes   map[string]Change `json:"changes,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// A Filter selects the entries of the audit log. Zero values don't filter anything
+type Filter struct {
+	Actor     string
+	Action    Action
+	Entity    Entity
+	ContactID int
+	RequestID string
+	From      time.Time
+	To        time.Time
+	Limit     int
+	Offset    int
+}
+
+// snapshot returns the JSON representation of a resource, or nil if there's no resource
+func snapshot(v interface{}) (json.RawMessage, error) {
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+		return nil, nil
+	}
+
+	return json.Marshal(v)
+}
+
+// diff compares the top level fields of two snapshots, returning the ones that changed
+func diff(before, after json.RawMessage) (map[string]Change, error) {
+	var b, a map[string]interface{}
+
+	if before != nil {
+		if err := json.Unmarshal(before, &b); err != nil {
+			return nil, err
+		}
+	}
+
+	if after != nil {
+		if err := json.Unmarshal(after, &a); err != nil {
+			return nil, err
+		}
+	}
+
+	changes := make(map[string]Change)
+
+	for field, value := range b {
+		if other, ok := a[field]; !ok || !reflect.DeepEqual(value, other) {
+			changes[field] = Change{Before: value, After: a[field]}
+		}
+	}
+
+	for field, value := range a {
+		if _, ok := b[field]; !ok {
+			changes[field] = Change{After: value}
+		}
+	}
+
+	return changes, nil
+}
+
+// Set is a wire set that contains all the providers of this package
+var Set = wire.NewSet(
+	ControllerSet,
+	ServiceSet,
+	RepositorySet,
+)
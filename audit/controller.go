@@ -0,0 +1,92 @@
+package audit
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/wire"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// A Controller provides the HTTP handlers to browse the audit log
+type Controller struct {
+	service *Service
+	logger  *zap.Logger
+	echo    *echo.Echo
+}
+
+// ProvideController is responsible by building a Controller object. Designed especially for the use of
+// wire, to provide the dependencies via DI
+func ProvideController(s *Service, logger *zap.Logger, echo *echo.Echo) *Controller {
+	return &Controller{service: s, logger: logger.Named("AuditController"), echo: echo}
+}
+
+// parseFilter reads the filter from the query params
+func parseFilter(c echo.Context) (Filter, error) {
+	f := Filter{
+		Actor:     c.QueryParam("actor"),
+		Action:    Action(c.QueryParam("action")),
+		Entity:    Entity(c.QueryParam("entity")),
+		RequestID: c.QueryParam("request_id"),
+	}
+
+	ints := map[string]*int{"contact_id": &f.ContactID, "limit": &f.Limit, "offset": &f.Offset}
+	for param, dest := range ints {
+		if raw := c.QueryParam(param); raw != "" {
+			i, err := strconv.Atoi(raw)
+			if err != nil || i < 0 {
+				return f, fmt.Errorf("%s must be a positive integer", param)
+			}
+
+			*dest = i
+		}
+	}
+
+	times := map[string]*time.Time{"from": &f.From, "to": &f.To}
+	for param, dest := range times {
+		if raw := c.QueryParam(param); raw != "" {
+			t, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return f, fmt.Errorf("%s must be a RFC 3339 timestamp", param)
+			}
+
+			*dest = t.UTC()
+		}
+	}
+
+	return f, nil
+}
+
+// Find lists the audit entries about the contacts visible by the current principal, filtered by the
+// actor, action, entity, contact_id, request_id, from and to query params, and paginated with limit and offset
+func (ct *Controller) Find(c echo.Context) error {
+	f, err := parseFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+
+	entries, err := ct.service.Find(c.Request().Context(), f)
+	if err != nil {
+		ct.logger.Error(fmt.Sprintf("GET /audit internal server error: %v", err))
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"entries": entries})
+}
+
+// EchoGroup is responsible for building an echo group with all routes for this controller
+func (ct *Controller) EchoGroup() *echo.Group {
+	ct.logger.Debug("Building the AuditController routing group...")
+
+	gp := ct.echo.Group("/audit")
+	gp.GET("", ct.Find)
+	gp.GET("/", ct.Find)
+
+	return gp
+}
+
+// ControllerSet is a wire set which contains all the bindings needed for building the controller
+var ControllerSet = wire.NewSet(ProvideController)
@@ -0,0 +1,45 @@
+package audit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+func TestControllerFind(t *testing.T) {
+	var testCases = []struct {
+		testName string
+		query    string
+		status   int
+	}{
+		{"no_filter", "", http.StatusOK},
+		{"filtered", "?actor=zenitsu&entity=phone&from=2020-05-01T00:00:00Z&limit=10", http.StatusOK},
+		{"malformed_from", "?from=yesterday", http.StatusBadRequest},
+		{"malformed_contact_id", "?contact_id=abc", http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/audit"+tc.query, nil)
+			req = req.WithContext(principalContext("tanjiro"))
+			rec := httptest.NewRecorder()
+
+			repository := &fakeRepository{}
+			controller := ProvideController(ProvideService(zap.NewNop(), repository), zap.NewNop(), e)
+
+			_ = controller.Find(e.NewContext(req, rec))
+
+			if rec.Code != tc.status {
+				t.Errorf("Find wrote respose status %d, want %d", rec.Code, tc.status)
+			}
+
+			if tc.testName == "filtered" && (repository.filter.Actor != "zenitsu" || repository.filter.Entity != EntityPhone || repository.filter.Limit != 10) {
+				t.Errorf("Find used filter %+v, want the query params", repository.filter)
+			}
+		})
+	}
+}
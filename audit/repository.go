@@ -0,0 +1,177 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/LucasFrezarini/go-contacts/db"
+	"github.com/google/wire"
+	"go.uber.org/zap"
+)
+
+// GenericRepository defines the structure of an audit log repository
+// created to facilitate the mocking in unit testing
+type GenericRepository interface {
+	Create(ctx context.Context, e Entry) (*Entry, error)
+	FindByContactID(ctx context.Context, contactID int) ([]*Entry, error)
+	Find(ctx context.Context, subject string, f Filter) ([]*Entry, error)
+}
+
+// A Repository persists the audit log
+type Repository struct {
+	DB     *sql.DB
+	Logger *zap.Logger
+}
+
+// ProvideRepository creates a new Repository with the dependencies provided.
+// Created especially for the use of Wire, which will inject the dependencies via DI
+func ProvideRepository(db *sql.DB, logger *zap.Logger) *Repository {
+	return &Repository{db, logger.Named("AuditRepository")}
+}
+
+const columns = "id, actor, request_id, action, entity, entity_id, contact_id, address_book_id, owner, before_state, after_state, changes, created_at"
+
+// nullJSON stores empty snapshots as NULL
+func nullJSON(raw []byte) interface{} {
+	if raw == nil {
+		return nil
+	}
+
+	return raw
+}
+
+// Create appends the entry to the audit log, within the transaction of the change carried by ctx, if any
+func (r *Repository) Create(ctx context.Context, e Entry) (*Entry, error) {
+	changes, err := json.Marshal(e.Changes)
+	if err != nil {
+		return nil, fmt.Errorf("create: error while encoding the changes: %w", err)
+	}
+
+	var bookID sql.NullInt64
+	if e.AddressBookID != nil {
+		bookID = sql.NullInt64{Int64: int64(*e.AddressBookID), Valid: true}
+	}
+
+	raw := "INSERT INTO audit_entry (" + strings.TrimPrefix(columns, "id, ") + ") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+
+	result, err := db.Conn(ctx, r.DB).ExecContext(ctx, raw, e.Actor, e.RequestID, e.Action, e.Entity, e.EntityID, e.ContactID,
+		bookID, e.Owner, nullJSON(e.Before), nullJSON(e.After), changes, e.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("create: error while executing insert query: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("create: error while fetching the last inserted ID: %w", err)
+	}
+
+	e.ID = id
+	return &e, nil
+}
+
+// FindByContactID returns the history of the contact, from the oldest to the newest change
+func (r *Repository) FindByContactID(ctx context.Context, contactID int) ([]*Entry, error) {
+	entries, err := r.query(ctx, "SELECT "+columns+" FROM audit_entry WHERE contact_id = ? ORDER BY id", contactID)
+	if err != nil {
+		return nil, fmt.Errorf("FindByContactID(%d): %w", contactID, err)
+	}
+
+	return entries, nil
+}
+
+// Find returns the entries matching the filter about the contacts the subject can see: its private
+// contacts and the contacts of the address books it's a member of. The newest entries come first
+func (r *Repository) Find(ctx context.Context, subject string, f Filter) ([]*Entry, error) {
+	conds := []string{"((address_book_id IS NULL AND owner = ?) OR address_book_id IN (SELECT m.address_book_id FROM address_book_member m WHERE m.subject = ?))"}
+	args := []interface{}{subject, subject}
+
+	add := func(cond string, arg interface{}) {
+		conds = append(conds, cond)
+		args = append(args, arg)
+	}
+
+	if f.Actor != "" {
+		add("actor = ?", f.Actor)
+	}
+
+	if f.Action != "" {
+		add("action = ?", f.Action)
+	}
+
+	if f.Entity != "" {
+		add("entity = ?", f.Entity)
+	}
+
+	if f.ContactID != 0 {
+		add("contact_id = ?", f.ContactID)
+	}
+
+	if f.RequestID != "" {
+		add("request_id = ?", f.RequestID)
+	}
+
+	if !f.From.IsZero() {
+		add("created_at >= ?", f.From)
+	}
+
+	if !f.To.IsZero() {
+		add("created_at < ?", f.To)
+	}
+
+	raw := "SELECT " + columns + " FROM audit_entry WHERE " + strings.Join(conds, " AND ") + " ORDER BY id DESC LIMIT ? OFFSET ?"
+
+	entries, err := r.query(ctx, raw, append(args, f.Limit, f.Offset)...)
+	if err != nil {
+		return nil, fmt.Errorf("Find(): %w", err)
+	}
+
+	return entries, nil
+}
+
+func (r *Repository) query(ctx context.Context, raw string, args ...interface{}) ([]*Entry, error) {
+	rows, err := r.DB.QueryContext(ctx, raw, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error while executing query: %w", err)
+	}
+
+	defer rows.Close()
+	entries := make([]*Entry, 0)
+
+	for rows.Next() {
+		var e Entry
+		var bookID sql.NullInt64
+		var before, after, changes []byte
+
+		err := rows.Scan(&e.ID, &e.Actor, &e.RequestID, &e.Action, &e.Entity, &e.EntityID, &e.ContactID,
+			&bookID, &e.Owner, &before, &after, &changes, &e.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error while scanning rows: %w", err)
+		}
+
+		if bookID.Valid {
+			id := int(bookID.Int64)
+			e.AddressBookID = &id
+		}
+
+		e.Before, e.After = before, after
+
+		if len(changes) != 0 {
+			if err := json.Unmarshal(changes, &e.Changes); err != nil {
+				return nil, fmt.Errorf("error while decoding the changes of entry %d: %w", e.ID, err)
+			}
+		}
+
+		entries = append(entries, &e)
+	}
+
+	return entries, nil
+}
+
+// RepositorySet is the wire set that contains all the providers for this repository
+var RepositorySet = wire.NewSet(
+	ProvideRepository,
+	wire.Bind(new(GenericRepository), new(*Repository)),
+)
@@ -0,0 +1,197 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/LucasFrezarini/go-contacts/db"
+	"go.uber.org/zap"
+)
+
+var cols = []string{"id", "actor", "request_id", "action", "entity", "entity_id", "contact_id", "address_book_id",
+	"owner", "before_state", "after_state", "changes", "created_at"}
+
+func TestRepositoryCreate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	e := Entry{Actor: "tanjiro", Action: ActionCreate, Entity: EntityEmail, EntityID: 9, ContactID: 3, Owner: "tanjiro",
+		After: json.RawMessage(`{"address":"nezuko@gmail.com"}`), Changes: map[string]Change{"address": {After: "nezuko@gmail.com"}}, CreatedAt: now}
+
+	mock.ExpectExec("INSERT INTO audit_entry").
+		WithArgs("tanjiro", "", ActionCreate, EntityEmail, 9, 3, nil, "tanjiro", nil, []byte(e.After), []byte(`{"address":{"before":null,"after":"nezuko@gmail.com"}}`), now).
+		WillReturnResult(sqlmock.NewResult(5, 1))
+
+	repository := ProvideRepository(db, zap.NewNop())
+	created, err := repository.Create(context.Background(), e)
+
+	if err != nil {
+		t.Fatalf("Create() returned an error %v, want nil", err)
+	}
+
+	if expected := int64(5); created.ID != expected {
+		t.Errorf("Create() entry.ID == %d, want %d", created.ID, expected)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled mock expectations: %v", err)
+	}
+}
+
+func TestRepositoryCreateInTx(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer conn.Close()
+
+	// the entry is undone along with the change it records
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO audit_entry").WillReturnResult(sqlmock.NewResult(5, 1))
+	mock.ExpectRollback()
+
+	repository := ProvideRepository(conn, zap.NewNop())
+	failure := errors.New("change failed")
+
+	err = db.ProvideTxManager(conn).InTx(context.Background(), func(ctx context.Context) error {
+		if _, err := repository.Create(ctx, Entry{Actor: "tanjiro", Action: ActionDelete, Entity: EntityContact}); err != nil {
+			return err
+		}
+
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("InTx() returned %v, want %v", err, failure)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled mock expectations: %v", err)
+	}
+}
+
+func TestRepositoryFind(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows(cols).
+		AddRow(5, "zenitsu", "req-1", "delete", "contact", 3, 3, 7, "", `{"first_name":"Nezuko"}`, nil, `{"first_name":{"before":"Nezuko","after":null}}`, now)
+
+	mock.ExpectQuery(`SELECT (.+) FROM audit_entry WHERE (.+) AND actor = \? AND contact_id = \? AND created_at >= \? ORDER BY id DESC LIMIT \? OFFSET \?`).
+		WithArgs("tanjiro", "tanjiro", "zenitsu", 3, now, 50, 0).
+		WillReturnRows(rows).RowsWillBeClosed()
+
+	repository := ProvideRepository(db, zap.NewNop())
+	entries, err := repository.Find(context.Background(), "tanjiro", Filter{Actor: "zenitsu", ContactID: 3, From: now, Limit: 50})
+
+	if err != nil {
+		t.Fatalf("Find() returned an error %v, want nil", err)
+	}
+
+	book := 7
+	expected := []*Entry{{
+		ID: 5, Actor: "zenitsu", RequestID: "req-1", Action: ActionDelete, Entity: EntityContact, EntityID: 3, ContactID: 3,
+		AddressBookID: &book, Before: json.RawMessage(`{"first_name":"Nezuko"}`),
+		Changes: map[string]Change{"first_name": {Before: "Nezuko"}}, CreatedAt: now,
+	}}
+
+	if !reflect.DeepEqual(entries, expected) {
+		t.Errorf("Find() = %+v, want %+v", entries[0], expected[0])
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled mock expectations: %v", err)
+	}
+}
+
+func TestRepositoryAddressEntry(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	e := Entry{Actor: "tanjiro", Action: ActionCreate, Entity: EntityAddress, EntityID: 4, ContactID: 3, Owner: "tanjiro",
+		After: json.RawMessage(`{"locality":"Tokyo"}`), Changes: map[string]Change{"locality": {After: "Tokyo"}}, CreatedAt: now}
+
+	mock.ExpectExec("INSERT INTO audit_entry").
+		WithArgs("tanjiro", "", ActionCreate, EntityAddress, 4, 3, nil, "tanjiro", nil, []byte(e.After), []byte(`{"locality":{"before":null,"after":"Tokyo"}}`), now).
+		WillReturnResult(sqlmock.NewResult(6, 1))
+	mock.ExpectQuery(`SELECT (.+) FROM audit_entry WHERE (.+) AND entity = \? ORDER BY id DESC`).
+		WithArgs("tanjiro", "tanjiro", EntityAddress, 50, 0).
+		WillReturnRows(sqlmock.NewRows(cols).
+			AddRow(6, "tanjiro", "", "create", "address", 4, 3, nil, "tanjiro", nil, `{"locality":"Tokyo"}`, `{"locality":{"before":null,"after":"Tokyo"}}`, now)).
+		RowsWillBeClosed()
+
+	repository := ProvideRepository(db, zap.NewNop())
+
+	if _, err := repository.Create(context.Background(), e); err != nil {
+		t.Fatalf("Create() of an address entry returned an error %v, want nil", err)
+	}
+
+	entries, err := repository.Find(context.Background(), "tanjiro", Filter{Entity: EntityAddress, Limit: 50})
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Find() = %v, %v, want the address entry", entries, err)
+	}
+
+	if got := entries[0]; got.Entity != EntityAddress || got.EntityID != 4 || !reflect.DeepEqual(got.Changes, e.Changes) {
+		t.Errorf("Find() = %+v, want the created address entry", got)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled mock expectations: %v", err)
+	}
+}
+
+// TestEntitiesMigrated checks that the migrations allow every entity in the audit_entry.entity column, otherwise
+// the entries of the missing ones fail to be recorded
+func TestEntitiesMigrated(t *testing.T) {
+	files, err := filepath.Glob("../db/migrations/*.sql")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("error while listing the migrations: %v", err)
+	}
+
+	sort.Strings(files)
+	definition := regexp.MustCompile("`entity` ENUM\\(([^)]*)\\)")
+
+	var values string
+	for _, f := range files {
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			t.Fatalf("error while reading the migration %s: %v", f, err)
+		}
+
+		// the last migration defining the column wins
+		if m := definition.FindSubmatch(b); m != nil {
+			values = string(m[1])
+		}
+	}
+
+	for _, e := range Entities {
+		if !strings.Contains(values, "'"+string(e)+"'") {
+			t.Errorf("audit_entry.entity is ENUM(%s), want it to allow %q", values, e)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/LucasFrezarini/go-contacts/addressbook"
+	"github.com/LucasFrezarini/go-contacts/auth"
+	"github.com/LucasFrezarini/go-contacts/requestid"
+	"github.com/google/wire"
+	"go.uber.org/zap"
+)
+
+// Limits of the number of entries returned by a single Find
+const (
+	DefaultLimit = 50
+	MaxLimit     = 500
+)
+
+// ErrNoPrincipal is returned when the audit log is used without an authenticated principal in the context
+var ErrNoPrincipal = errors.New("no authenticated principal in context")
+
+// An Auditor records the changes made to the contacts and their details, and retrieves their history
+type Auditor interface {
+	// Record records a change made in the request of ctx. Before and after are the states of the
+	// entity around the change, nil when it didn't exist yet or doesn't exist anymore
+	Record(ctx context.Context, action Action, entity Entity, entityID int, contactID int, before, after interface{}) error
+	// History returns all the changes made to a contact. The caller is responsible for checking
+	// that the contact is visible by the principal
+	History(ctx context.Context, contactID int) ([]*Entry, error)
+}
+
+// A Service contains all the business logic related to the audit log
+type Service struct {
+	Logger     *zap.Logger
+	Repository GenericRepository
+	now        func() time.Time
+}
+
+// ProvideService creates a new Service with the provided dependencies.
+// Created especially for the use of Wire, who will inject the dependencies via DI
+func ProvideService(logger *zap.Logger, r GenericRepository) *Service {
+	return &Service{logger.Named("AuditService"), r, time.Now}
+}
+
+func subjectFromContext(ctx context.Context) (string, error) {
+	p, ok := auth.FromContext(ctx)
+	if !ok || p.Subject == "" {
+		return "", ErrNoPrincipal
+	}
+
+	return p.Subject, nil
+}
+
+// Record implements Auditor
+func (s *Service) Record(ctx context.Context, action Action, entity Entity, entityID int, contactID int, before, after interface{}) error {
+	actor, err := subjectFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	e := Entry{
+		Actor:     actor,
+		RequestID: requestid.FromContext(ctx),
+		Action:    action,
+		Entity:    entity,
+		EntityID:  entityID,
+		ContactID: contactID,
+		Owner:     actor,
+		CreatedAt: s.now().UTC(),
+	}
+
+	if id, ok := addressbook.BookIDFromContext(ctx); ok {
+		e.AddressBookID = &id
+		e.Owner = ""
+	}
+
+	if e.Before, err = snapshot(before); err != nil {
+		return fmt.Errorf("error while encoding the state before the change: %w", err)
+	}
+
+	if e.After, err = snapshot(after); err != nil {
+		return fmt.Errorf("error while encoding the state after the change: %w", err)
+	}
+
+	if e.Changes, err = diff(e.Before, e.After); err != nil {
+		return fmt.Errorf("error while comparing the states around the change: %w", err)
+	}
+
+	if _, err := s.Repository.Create(ctx, e); err != nil {
+		s.Logger.Error(fmt.Sprintf("error while recording the %s of %s %d: %v", action, entity, entityID, err))
+		return err
+	}
+
+	return nil
+}
+
+// History implements Auditor
+func (s *Service) History(ctx context.Context, contactID int) ([]*Entry, error) {
+	return s.Repository.FindByContactID(ctx, contactID)
+}
+
+// Find returns the entries matching the filter about the contacts visible by the current principal
+func (s *Service) Find(ctx context.Context, f Filter) ([]*Entry, error) {
+	subject, err := subjectFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.Limit <= 0 {
+		f.Limit = DefaultLimit
+	}
+
+	if f.Limit > MaxLimit {
+		f.Limit = MaxLimit
+	}
+
+	return s.Repository.Find(ctx, subject, f)
+}
+
+// ServiceSet is a wire set which contains all the bindings needed for creating a new service
+var ServiceSet = wire.NewSet(
+	ProvideService,
+	wire.Bind(new(Auditor), new(*Service)),
+)
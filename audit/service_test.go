@@ -0,0 +1,133 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/LucasFrezarini/go-contacts/addressbook"
+	"github.com/LucasFrezarini/go-contacts/auth"
+	"github.com/LucasFrezarini/go-contacts/requestid"
+	"go.uber.org/zap"
+)
+
+// fakeRepository keeps the audit log in memory
+type fakeRepository struct {
+	entries []*Entry
+	filter  Filter
+}
+
+func (f *fakeRepository) Create(ctx context.Context, e Entry) (*Entry, error) {
+	e.ID = int64(len(f.entries) + 1)
+	f.entries = append(f.entries, &e)
+
+	return &e, nil
+}
+
+func (f *fakeRepository) FindByContactID(ctx context.Context, contactID int) ([]*Entry, error) {
+	entries := make([]*Entry, 0)
+	for _, e := range f.entries {
+		if e.ContactID == contactID {
+			entries = append(entries, e)
+		}
+	}
+
+	return entries, nil
+}
+
+func (f *fakeRepository) Find(ctx context.Context, subject string, filter Filter) ([]*Entry, error) {
+	f.filter = filter
+	return f.entries, nil
+}
+
+type contact struct {
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+}
+
+func principalContext(subject string) context.Context {
+	return auth.WithPrincipal(context.Background(), &auth.Principal{Subject: subject, Method: auth.MethodJWT})
+}
+
+func TestRecord(t *testing.T) {
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	repository := &fakeRepository{}
+	service := ProvideService(zap.NewNop(), repository)
+	service.now = func() time.Time { return now }
+
+	ctx := requestid.WithID(principalContext("tanjiro"), "req-1")
+	before, after := &contact{"Nezuko", "Kamado"}, &contact{"Nezuko", "Kamado-Agatsuma"}
+
+	if err := service.Record(ctx, ActionUpdate, EntityContact, 3, 3, before, after); err != nil {
+		t.Fatalf("Record() returned an error %v, want nil", err)
+	}
+
+	e := repository.entries[0]
+	if e.Actor != "tanjiro" || e.Owner != "tanjiro" || e.RequestID != "req-1" || !e.CreatedAt.Equal(now) {
+		t.Errorf("Record() created entry %+v, want actor, owner and request ID from the context", e)
+	}
+
+	expected := map[string]Change{"last_name": {Before: "Kamado", After: "Kamado-Agatsuma"}}
+	if !reflect.DeepEqual(e.Changes, expected) {
+		t.Errorf("Record() entry.Changes == %v, want %v", e.Changes, expected)
+	}
+}
+
+func TestRecordInAddressBook(t *testing.T) {
+	repository := &fakeRepository{}
+	service := ProvideService(zap.NewNop(), repository)
+
+	ctx := addressbook.WithBookID(principalContext("tanjiro"), 7)
+
+	if err := service.Record(ctx, ActionDelete, EntityContact, 3, 3, &contact{"Nezuko", "Kamado"}, nil); err != nil {
+		t.Fatalf("Record() returned an error %v, want nil", err)
+	}
+
+	e := repository.entries[0]
+	if e.AddressBookID == nil || *e.AddressBookID != 7 || e.Owner != "" {
+		t.Errorf("Record() created entry %+v, want it to belong to address book 7", e)
+	}
+
+	if e.After != nil {
+		t.Errorf("Record() entry.After == %s, want nil", e.After)
+	}
+
+	if expected := (Change{Before: "Nezuko"}); !reflect.DeepEqual(e.Changes["first_name"], expected) {
+		t.Errorf("Record() entry.Changes[first_name] == %v, want %v", e.Changes["first_name"], expected)
+	}
+}
+
+func TestRecordWithoutPrincipal(t *testing.T) {
+	service := ProvideService(zap.NewNop(), &fakeRepository{})
+
+	err := service.Record(context.Background(), ActionCreate, EntityContact, 1, 1, nil, &contact{})
+	if !errors.Is(err, ErrNoPrincipal) {
+		t.Errorf("Record() returned error %v, want %v", err, ErrNoPrincipal)
+	}
+}
+
+func TestFindLimit(t *testing.T) {
+	var testCases = []struct {
+		limit    int
+		expected int
+	}{
+		{0, DefaultLimit},
+		{10, 10},
+		{MaxLimit + 1, MaxLimit},
+	}
+
+	for _, tc := range testCases {
+		repository := &fakeRepository{}
+		service := ProvideService(zap.NewNop(), repository)
+
+		if _, err := service.Find(principalContext("tanjiro"), Filter{Limit: tc.limit}); err != nil {
+			t.Fatalf("Find() returned an error %v, want nil", err)
+		}
+
+		if repository.filter.Limit != tc.expected {
+			t.Errorf("Find() with limit %d used limit %d, want %d", tc.limit, repository.filter.Limit, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package auth
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrInvalidAPIKey is returned when an API key is malformed, unknown or revoked
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+// A KeyAuthenticator resolves the principal that owns an API key
+type KeyAuthenticator interface {
+	AuthenticateKey(ctx context.Context, key string) (*Principal, error)
+}
@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+)
+
+// jwks is the JSON Web Key Set document format, as defined by RFC 7517
+type jwks struct {
+	Keys []struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Use string `json:"use"`
+		Alg string `json:"alg"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	} `json:"keys"`
+}
+
+// loadJWKS reads the RSA signing keys of a local JWKS file, indexed by their key ID.
+// Keys of other types or meant for encryption are ignored
+func loadJWKS(path string) (map[string]*rsa.PublicKey, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading JWKS file: %w", err)
+	}
+
+	var set jwks
+	if err := json.Unmarshal(b, &set); err != nil {
+		return nil, fmt.Errorf("error while decoding JWKS file %s: %w", path, err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+
+	for i, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") || (k.Alg != "" && k.Alg != "RS256") {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("JWKS key %d: invalid modulus: %w", i, err)
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("JWKS key %d: invalid exponent: %w", i, err)
+		}
+
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("JWKS file %s doesn't contain any RS256 signing key", path)
+	}
+
+	return keys, nil
+}
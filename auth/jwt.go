@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/LucasFrezarini/go-contacts/config"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/google/wire"
+)
+
+// ErrInvalidToken is returned when a token can't be trusted, for whatever reason
+var ErrInvalidToken = errors.New("invalid token")
+
+// A JWTAuthenticator validates HS256 and RS256 signed bearer tokens
+type JWTAuthenticator struct {
+	secret   []byte
+	keys     map[string]*rsa.PublicKey
+	issuer   string
+	audience string
+	admins   map[string]bool
+}
+
+// ProvideJWTAuthenticator loads the signing keys from the configuration.
+// Designed especially for the use of wire, to provide the dependencies via DI
+func ProvideJWTAuthenticator(cfg *config.Config) (*JWTAuthenticator, error) {
+	a := cfg.Auth
+	authenticator := &JWTAuthenticator{
+		keys:     make(map[string]*rsa.PublicKey),
+		issuer:   a.Issuer,
+		audience: a.Audience,
+		admins:   make(map[string]bool, len(a.Admins)),
+	}
+
+	for _, subject := range a.Admins {
+		authenticator.admins[subject] = true
+	}
+
+	if a.HS256Secret != "" {
+		authenticator.secret = []byte(a.HS256Secret)
+	}
+
+	if a.RS256PublicKeyFile != "" {
+		b, err := ioutil.ReadFile(a.RS256PublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("ProvideJWTAuthenticator: error while reading RS256 public key: %w", err)
+		}
+
+		key, err := jwt.ParseRSAPublicKeyFromPEM(b)
+		if err != nil {
+			return nil, fmt.Errorf("ProvideJWTAuthenticator: error while parsing RS256 public key: %w", err)
+		}
+
+		authenticator.keys[""] = key
+	}
+
+	if a.JWKSFile != "" {
+		keys, err := loadJWKS(a.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("ProvideJWTAuthenticator: %w", err)
+		}
+
+		for kid, key := range keys {
+			authenticator.keys[kid] = key
+		}
+	}
+
+	return authenticator, nil
+}
+
+// Authenticate validates the signature and the claims of the provided token and returns the
+// principal it identifies. The subject claim is mandatory, and the configured admin subjects are admins
+func (a *JWTAuthenticator) Authenticate(token string) (*Principal, error) {
+	claims := &jwt.StandardClaims{}
+	parser := &jwt.Parser{ValidMethods: []string{"HS256", "RS256"}}
+
+	if _, err := parser.ParseWithClaims(token, claims, a.key); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	if a.issuer != "" && !claims.VerifyIssuer(a.issuer, true) {
+		return nil, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidToken, claims.Issuer)
+	}
+
+	if a.audience != "" && !claims.VerifyAudience(a.audience, true) {
+		return nil, fmt.Errorf("%w: unexpected audience %q", ErrInvalidToken, claims.Audience)
+	}
+
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidToken)
+	}
+
+	return &Principal{Subject: claims.Subject, Method: MethodJWT, Admin: a.admins[claims.Subject]}, nil
+}
+
+// key picks the verification key of a token based on its algorithm, making sure an RS256 key
+// is never used as an HMAC secret and vice versa
+func (a *JWTAuthenticator) key(token *jwt.Token) (interface{}, error) {
+	switch token.Method.Alg() {
+	case "HS256":
+		if a.secret == nil {
+			return nil, errors.New("HS256 tokens aren't accepted")
+		}
+
+		return a.secret, nil
+	case "RS256":
+		kid, _ := token.Header["kid"].(string)
+		if key, ok := a.keys[kid]; ok {
+			return key, nil
+		}
+
+		if kid == "" && len(a.keys) == 1 {
+			for _, key := range a.keys {
+				return key, nil
+			}
+		}
+
+		return nil, fmt.Errorf("unknown RS256 key %q", kid)
+	}
+
+	return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+}
+
+// Set is the wire.ProviderSet of the auth package
+var Set = wire.NewSet(ProvideJWTAuthenticator)
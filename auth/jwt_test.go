@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/LucasFrezarini/go-contacts/config"
+	"github.com/dgrijalva/jwt-go"
+)
+
+func sign(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, claims jwt.StandardClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	s, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("error while signing token: %v", err)
+	}
+
+	return s
+}
+
+func writeJWKS(t *testing.T, kid string, key *rsa.PublicKey) string {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "jwks")
+	if err != nil {
+		t.Fatalf("error while creating temp dir: %v", err)
+	}
+
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	b, _ := json.Marshal(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": kid,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	})
+
+	path := filepath.Join(dir, "jwks.json")
+	if err := ioutil.WriteFile(path, b, 0600); err != nil {
+		t.Fatalf("error while writing JWKS file: %v", err)
+	}
+
+	return path
+}
+
+func TestJWTAuthenticate(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("error while generating RSA key: %v", err)
+	}
+
+	secret := []byte("testing-secret")
+	authenticator, err := ProvideJWTAuthenticator(&config.Config{Auth: config.Auth{
+		HS256Secret: string(secret),
+		JWKSFile:    writeJWKS(t, "key-1", &rsaKey.PublicKey),
+		Issuer:      "go-contacts-tests",
+	}})
+	if err != nil {
+		t.Fatalf("ProvideJWTAuthenticator() returned an error: %v", err)
+	}
+
+	valid := jwt.StandardClaims{Subject: "tanjiro", Issuer: "go-contacts-tests", ExpiresAt: time.Now().Add(time.Hour).Unix()}
+	expired := valid
+	expired.ExpiresAt = time.Now().Add(-time.Hour).Unix()
+	otherIssuer := valid
+	otherIssuer.Issuer = "someone-else"
+	noSubject := valid
+	noSubject.Subject = ""
+
+	var testCases = []struct {
+		testName string
+		token    string
+		valid    bool
+	}{
+		{"hs256", sign(t, jwt.SigningMethodHS256, secret, "", valid), true},
+		{"rs256_jwks", sign(t, jwt.SigningMethodRS256, rsaKey, "key-1", valid), true},
+		{"rs256_unknown_kid", sign(t, jwt.SigningMethodRS256, rsaKey, "key-2", valid), false},
+		{"hs256_wrong_secret", sign(t, jwt.SigningMethodHS256, []byte("wrong"), "", valid), false},
+		{"hs512_not_accepted", sign(t, jwt.SigningMethodHS512, secret, "", valid), false},
+		{"expired", sign(t, jwt.SigningMethodHS256, secret, "", expired), false},
+		{"other_issuer", sign(t, jwt.SigningMethodHS256, secret, "", otherIssuer), false},
+		{"missing_subject", sign(t, jwt.SigningMethodHS256, secret, "", noSubject), false},
+		{"garbage", "not.a.token", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			principal, err := authenticator.Authenticate(tc.token)
+
+			if !tc.valid {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Errorf("Authenticate() error == %v, want ErrInvalidToken", err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Authenticate() returned an error: %v", err)
+			}
+
+			if expected, got := "tanjiro", principal.Subject; expected != got {
+				t.Errorf("Authenticate() principal.Subject == %q, want %q", got, expected)
+			}
+		})
+	}
+}
+
+func TestJWTAuthenticateAdmins(t *testing.T) {
+	secret := []byte("testing-secret")
+	authenticator, err := ProvideJWTAuthenticator(&config.Config{Auth: config.Auth{
+		HS256Secret: string(secret),
+		Admins:      []string{"urokodaki"},
+	}})
+	if err != nil {
+		t.Fatalf("ProvideJWTAuthenticator() returned an error: %v", err)
+	}
+
+	for subject, admin := range map[string]bool{"urokodaki": true, "tanjiro": false} {
+		claims := jwt.StandardClaims{Subject: subject, ExpiresAt: time.Now().Add(time.Hour).Unix()}
+
+		principal, err := authenticator.Authenticate(sign(t, jwt.SigningMethodHS256, secret, "", claims))
+		if err != nil {
+			t.Fatalf("Authenticate() returned an error: %v", err)
+		}
+
+		if principal.Admin != admin {
+			t.Errorf("Authenticate() principal.Admin of %q == %t, want %t", subject, principal.Admin, admin)
+		}
+	}
+}
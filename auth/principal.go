@@ -0,0 +1,52 @@
+package auth
+
+import "context"
+
+// Available authentication methods
+const (
+	MethodJWT    = "jwt"
+	MethodAPIKey = "api_key"
+	MethodNone   = "none"
+)
+
+// Scopes limiting what a principal can do. Users authenticated by other methods have no scope
+// and can do everything their roles allow
+const (
+	ScopeRead      = "read"
+	ScopeReadWrite = "read-write"
+)
+
+// AnonymousSubject is the subject of every request when authentication is disabled
+const AnonymousSubject = "anonymous"
+
+// A Principal is the authenticated identity behind a request
+type Principal struct {
+	// Subject uniquely identifies the user
+	Subject string
+	// Method is the authentication method that produced this principal
+	Method string
+	// Scope limits the actions of the principal, when it's authenticated by an API key
+	Scope string
+	// KeyID is the ID of the API key used to authenticate, if any
+	KeyID int
+	// Admin allows the principal to operate the application through the /admin routes
+	Admin bool
+}
+
+// CanWrite checks if the principal's scope allows it to change data
+func (p *Principal) CanWrite() bool {
+	return p.Scope != ScopeRead
+}
+
+type contextKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the provided principal
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext returns the principal stored in ctx, if any
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(contextKey{}).(*Principal)
+	return p, ok && p != nil
+}
@@ -0,0 +1,268 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/google/wire"
+	"github.com/labstack/gommon/bytes"
+	"github.com/ttacon/libphonenumber"
+)
+
+// CORS defines which browser applications, served from other origins, can call the API.
+// CORS is disabled while no origin is allowed
+type CORS struct {
+	AllowOrigins     []string `yaml:"allow_origins" env:"CORS_ALLOW_ORIGINS" flag:"cors-allow-origins" usage:"comma separated list of allowed origins, * allows any origin"`
+	AllowMethods     []string `yaml:"allow_methods" env:"CORS_ALLOW_METHODS" flag:"cors-allow-methods" default:"GET,HEAD,POST,PUT,PATCH,DELETE" usage:"comma separated list of allowed methods"`
+	AllowHeaders     []string `yaml:"allow_headers" env:"CORS_ALLOW_HEADERS" flag:"cors-allow-headers" default:"Authorization,Content-Type,If-Match,If-None-Match,X-Request-ID" usage:"comma separated list of allowed request headers"`
+	ExposeHeaders    []string `yaml:"expose_headers" env:"CORS_EXPOSE_HEADERS" flag:"cors-expose-headers" default:"ETag,X-Request-ID,RateLimit-Limit,RateLimit-Remaining,RateLimit-Reset,Retry-After" usage:"comma separated list of response headers readable by the browser app"`
+	AllowCredentials bool     `yaml:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS" flag:"cors-allow-credentials" default:"false" usage:"allow requests with cookies or HTTP authentication"`
+	MaxAge           int      `yaml:"max_age" env:"CORS_MAX_AGE" flag:"cors-max-age" default:"600" usage:"how long, in seconds, browsers may cache a preflight response"`
+}
+
+// Security defines the security headers sent on every response
+type Security struct {
+	HSTSMaxAge            int    `yaml:"hsts_max_age" env:"SECURITY_HSTS_MAX_AGE" flag:"security-hsts-max-age" default:"31536000" usage:"Strict-Transport-Security max-age, in seconds, sent on HTTPS requests only, 0 disables it"`
+	ContentSecurityPolicy string `yaml:"content_security_policy" env:"SECURITY_CONTENT_SECURITY_POLICY" flag:"security-content-security-policy" default:"default-src 'none'; frame-ancestors 'none'" usage:"Content-Security-Policy header"`
+	ReferrerPolicy        string `yaml:"referrer_policy" env:"SECURITY_REFERRER_POLICY" flag:"security-referrer-policy" default:"no-referrer" usage:"Referrer-Policy header"`
+}
+
+// Server defines the HTTP server configuration
+type Server struct {
+	Address string `yaml:"address" env:"SERVER_ADDRESS" flag:"address" default:":8080" required:"true" usage:"address the HTTP server listens on"`
+	// MaxBodySize limits the size of the request bodies, except for the import endpoints
+	MaxBodySize string `yaml:"max_body_size" env:"SERVER_MAX_BODY_SIZE" flag:"server-max-body-size" default:"1M" usage:"maximum size of the request bodies, e.g. 512K or 1M"`
+	// MaxImportBodySize limits the size of the request bodies of the import endpoints, ending with /import
+	MaxImportBodySize string   `yaml:"max_import_body_size" env:"SERVER_MAX_IMPORT_BODY_SIZE" flag:"server-max-import-body-size" default:"10M" usage:"maximum size of the request bodies of the import endpoints"`
+	CORS              CORS     `yaml:"cors"`
+	Security          Security `yaml:"security"`
+	// TrustedProxies are the only peers whose X-Forwarded-For header is trusted to identify the client
+	TrustedProxies []string `yaml:"trusted_proxies" env:"SERVER_TRUSTED_PROXIES" flag:"server-trusted-proxies" usage:"comma separated list of CIDRs of the proxies setting X-Forwarded-For, which is ignored when empty"`
+}
+
+// MySQLPool defines the limits of the MySQL connection pool
+type MySQLPool struct {
+	MaxOpenConns    int           `yaml:"max_open_conns" env:"MYSQL_MAX_OPEN_CONNS" flag:"mysql-max-open-conns" default:"25" usage:"maximum number of open connections, 0 means unlimited"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env:"MYSQL_MAX_IDLE_CONNS" flag:"mysql-max-idle-conns" default:"25" usage:"maximum number of idle connections"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"MYSQL_CONN_MAX_LIFETIME" flag:"mysql-conn-max-lifetime" default:"5m" usage:"maximum time a connection may be reused, 0 means forever"`
+}
+
+// MySQLRetry defines how the initial connection to MySQL is retried while the database isn't reachable
+type MySQLRetry struct {
+	MaxAttempts     int           `yaml:"max_attempts" env:"MYSQL_CONNECT_MAX_ATTEMPTS" flag:"mysql-connect-max-attempts" default:"10" usage:"maximum number of connection attempts on startup"`
+	InitialInterval time.Duration `yaml:"initial_interval" env:"MYSQL_CONNECT_INITIAL_INTERVAL" flag:"mysql-connect-initial-interval" default:"500ms" usage:"wait before the first retry, doubled on every attempt"`
+	MaxInterval     time.Duration `yaml:"max_interval" env:"MYSQL_CONNECT_MAX_INTERVAL" flag:"mysql-connect-max-interval" default:"10s" usage:"maximum wait between two attempts"`
+}
+
+// MySQL defines the MySQL connection configuration
+type MySQL struct {
+	Host         string        `yaml:"host" env:"MYSQL_HOST" flag:"mysql-host" default:"localhost" required:"true" usage:"MySQL host"`
+	Port         string        `yaml:"port" env:"MYSQL_PORT" flag:"mysql-port" default:"3306" required:"true" usage:"MySQL port"`
+	User         string        `yaml:"user" env:"MYSQL_USER" flag:"mysql-user" required:"true" usage:"MySQL user"`
+	Password     string        `yaml:"password" env:"MYSQL_PASSWORD" flag:"mysql-password" secret:"true" usage:"MySQL password"`
+	Database     string        `yaml:"database" env:"MYSQL_DATABASE" flag:"mysql-database" required:"true" usage:"MySQL database name"`
+	ParseTime    bool          `yaml:"parse_time" env:"MYSQL_PARSE_TIME" flag:"mysql-parse-time" default:"true" usage:"parse DATE and DATETIME values into time.Time"`
+	Timeout      time.Duration `yaml:"timeout" env:"MYSQL_TIMEOUT" flag:"mysql-timeout" default:"5s" usage:"dial timeout, 0 for none"`
+	ReadTimeout  time.Duration `yaml:"read_timeout" env:"MYSQL_READ_TIMEOUT" flag:"mysql-read-timeout" default:"30s" usage:"I/O read timeout"`
+	WriteTimeout time.Duration `yaml:"write_timeout" env:"MYSQL_WRITE_TIMEOUT" flag:"mysql-write-timeout" default:"30s" usage:"I/O write timeout"`
+	TLS          string        `yaml:"tls" env:"MYSQL_TLS" flag:"mysql-tls" default:"false" usage:"TLS mode: true, false, skip-verify or preferred"`
+	Pool         MySQLPool     `yaml:"pool"`
+	Retry        MySQLRetry    `yaml:"retry"`
+	// Migrate applies the migrations of MigrationsDir not applied yet at startup
+	Migrate       bool   `yaml:"migrate" env:"MYSQL_MIGRATE" flag:"mysql-migrate" default:"true" usage:"apply the pending migrations at startup"`
+	MigrationsDir string `yaml:"migrations_dir" env:"MYSQL_MIGRATIONS_DIR" flag:"mysql-migrations-dir" default:"db/migrations" usage:"directory of the SQL migrations"`
+}
+
+// HTTPLog defines the HTTP access log configuration
+type HTTPLog struct {
+	// LogBody enables logging of the (redacted) request body
+	LogBody bool `yaml:"log_body" env:"HTTP_LOG_BODY" flag:"http-log-body" default:"false" usage:"log redacted request bodies"`
+	// LogHeaders enables logging of the (redacted) request headers
+	LogHeaders bool `yaml:"log_headers" env:"HTTP_LOG_HEADERS" flag:"http-log-headers" default:"false" usage:"log redacted request headers"`
+	// MaxBodySize is the maximum number of body bytes that will be logged
+	MaxBodySize int `yaml:"max_body_size" env:"HTTP_LOG_MAX_BODY_SIZE" flag:"http-log-max-body-size" default:"4096" usage:"maximum logged body size, in bytes"`
+	// SampleRates maps a route, in the "METHOD /path" format, to the fraction of its
+	// successful requests that will be logged
+	SampleRates map[string]float64 `yaml:"sample_rates" env:"HTTP_LOG_SAMPLE_RATES" flag:"http-log-sample-rates" usage:"comma separated list of \"METHOD /path=rate\" entries"`
+}
+
+// LogHTTPSink defines the HTTP batch log sink configuration
+type LogHTTPSink struct {
+	URL           string        `yaml:"url" env:"LOG_HTTP_SINK_URL" flag:"log-http-sink-url" usage:"intake URL that receives the log batches"`
+	APIKey        string        `yaml:"api_key" env:"LOG_HTTP_SINK_API_KEY" flag:"log-http-sink-api-key" secret:"true" usage:"API key sent to the log intake"`
+	Service       string        `yaml:"service" env:"LOG_HTTP_SINK_SERVICE" flag:"log-http-sink-service" default:"go-contacts" usage:"service name attached to the shipped logs"`
+	BatchSize     int           `yaml:"batch_size" env:"LOG_HTTP_SINK_BATCH_SIZE" flag:"log-http-sink-batch-size" default:"100" usage:"number of entries per batch"`
+	FlushInterval time.Duration `yaml:"flush_interval" env:"LOG_HTTP_SINK_FLUSH_INTERVAL" flag:"log-http-sink-flush-interval" default:"5s" usage:"maximum time between two batches"`
+}
+
+// Log defines the application logger configuration
+type Log struct {
+	Mode     string      `yaml:"mode" env:"LOG_MODE" flag:"log-mode" default:"production" required:"true" usage:"logger mode: production or development"`
+	Level    string      `yaml:"level" env:"LOG_LEVEL" flag:"log-level" default:"info" required:"true" usage:"minimum log level"`
+	Encoding string      `yaml:"encoding" env:"LOG_ENCODING" flag:"log-encoding" usage:"log encoding: json or console. Defaults to the mode's encoding"`
+	Outputs  []string    `yaml:"outputs" env:"LOG_OUTPUTS" flag:"log-outputs" usage:"comma separated list of log outputs (stdout, stderr or file paths)"`
+	HTTPSink LogHTTPSink `yaml:"http_sink"`
+}
+
+// Auth defines how the API authenticates its clients
+type Auth struct {
+	Enabled            bool     `yaml:"enabled" env:"AUTH_ENABLED" flag:"auth-enabled" default:"true" usage:"require authentication on all non-public routes"`
+	HS256Secret        string   `yaml:"hs256_secret" env:"AUTH_HS256_SECRET" flag:"auth-hs256-secret" secret:"true" usage:"secret used to verify HS256 tokens"`
+	RS256PublicKeyFile string   `yaml:"rs256_public_key_file" env:"AUTH_RS256_PUBLIC_KEY_FILE" flag:"auth-rs256-public-key-file" usage:"PEM file with the public key used to verify RS256 tokens"`
+	JWKSFile           string   `yaml:"jwks_file" env:"AUTH_JWKS_FILE" flag:"auth-jwks-file" usage:"local JWKS file with the keys used to verify RS256 tokens"`
+	Issuer             string   `yaml:"issuer" env:"AUTH_ISSUER" flag:"auth-issuer" usage:"expected iss claim, not checked when empty"`
+	Audience           string   `yaml:"audience" env:"AUTH_AUDIENCE" flag:"auth-audience" usage:"expected aud claim, not checked when empty"`
+	PublicRoutes       []string `yaml:"public_routes" env:"AUTH_PUBLIC_ROUTES" flag:"auth-public-routes" usage:"comma separated list of paths that don't require authentication, a trailing * matches any suffix"`
+	Admins             []string `yaml:"admins" env:"AUTH_ADMINS" flag:"auth-admins" usage:"comma separated list of the token subjects allowed to use the /admin routes"`
+}
+
+// RateLimit defines how many requests each client can make. Limits are written as "requests/period",
+// e.g. "100/1m", and clients are identified by their API key, their user or their IP address. Each IP address
+// also has its own limit before authentication, so clients sending bad credentials are limited too
+type RateLimit struct {
+	Enabled bool              `yaml:"enabled" env:"RATE_LIMIT_ENABLED" flag:"rate-limit-enabled" default:"true" usage:"limit the requests rate of each client"`
+	Default string            `yaml:"default" env:"RATE_LIMIT_DEFAULT" flag:"rate-limit-default" default:"300/1m" usage:"limit applied to the routes without their own limit, e.g. 300/1m"`
+	Routes  map[string]string `yaml:"routes" env:"RATE_LIMIT_ROUTES" flag:"rate-limit-routes" usage:"comma separated list of \"METHOD /path=requests/period\" entries"`
+	PerIP   string            `yaml:"per_ip" env:"RATE_LIMIT_PER_IP" flag:"rate-limit-per-ip" default:"600/1m" usage:"limit of each IP address before authentication, empty for none"`
+}
+
+// Trash defines how long deleted contacts can be restored before being permanently removed
+type Trash struct {
+	Retention     time.Duration `yaml:"retention" env:"TRASH_RETENTION" flag:"trash-retention" default:"720h" usage:"how long deleted contacts stay in the trash, 0 keeps them forever"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env:"TRASH_PURGE_INTERVAL" flag:"trash-purge-interval" default:"1h" usage:"how often the expired contacts are removed from the trash"`
+}
+
+// Dedupe defines how similar two contacts must be to be reported as duplicates. Each signal raises the
+// score of a pair of contacts by its weight, the name one being also scaled by the similarity of the names
+type Dedupe struct {
+	Threshold     float64 `yaml:"threshold" env:"DEDUPE_THRESHOLD" flag:"dedupe-threshold" default:"0.7" usage:"minimum score, from 0 to 1, of two contacts to be reported as duplicates"`
+	NameThreshold float64 `yaml:"name_threshold" env:"DEDUPE_NAME_THRESHOLD" flag:"dedupe-name-threshold" default:"0.88" usage:"minimum similarity, from 0 to 1, of two names to count as the same name"`
+	NameWeight    float64 `yaml:"name_weight" env:"DEDUPE_NAME_WEIGHT" flag:"dedupe-name-weight" default:"0.75" usage:"weight of similar names in the score"`
+	EmailWeight   float64 `yaml:"email_weight" env:"DEDUPE_EMAIL_WEIGHT" flag:"dedupe-email-weight" default:"0.9" usage:"weight of a shared email address in the score"`
+	PhoneWeight   float64 `yaml:"phone_weight" env:"DEDUPE_PHONE_WEIGHT" flag:"dedupe-phone-weight" default:"0.8" usage:"weight of a shared phone number in the score"`
+}
+
+// Phone defines how the phone numbers are parsed
+type Phone struct {
+	DefaultRegion string `yaml:"default_region" env:"PHONE_DEFAULT_REGION" flag:"phone-default-region" default:"US" usage:"ISO 3166-1 alpha-2 region of the phone numbers provided without a country code"`
+}
+
+// Config is the whole application configuration. Each value is resolved from, in order of precedence:
+// CLI flags, environment variables, the configuration file and the defaults
+type Config struct {
+	Server    Server    `yaml:"server"`
+	MySQL     MySQL     `yaml:"mysql"`
+	HTTPLog   HTTPLog   `yaml:"http_log"`
+	Log       Log       `yaml:"log"`
+	Auth      Auth      `yaml:"auth"`
+	RateLimit RateLimit `yaml:"rate_limit"`
+	Trash     Trash     `yaml:"trash"`
+	Dedupe    Dedupe    `yaml:"dedupe"`
+	Phone     Phone     `yaml:"phone"`
+}
+
+// Args are the command line arguments the configuration is loaded from, without the program name
+type Args []string
+
+// ProvideConfig loads and validates the application configuration.
+// Designed especially for the use of wire, to provide the dependencies via DI
+func ProvideConfig(args Args) (*Config, error) {
+	cfg, err := Load(args)
+	if err != nil {
+		return nil, fmt.Errorf("ProvideConfig: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// Validate checks the values that can't be validated by the struct tags alone
+func (c *Config) Validate() error {
+	var problems []string
+
+	if m := c.Log.Mode; m != "production" && m != "development" {
+		problems = append(problems, fmt.Sprintf("log.mode must be production or development, got %q", m))
+	}
+
+	switch c.MySQL.TLS {
+	case "true", "false", "skip-verify", "preferred":
+	default:
+		problems = append(problems, fmt.Sprintf("mysql.tls must be true, false, skip-verify or preferred, got %q", c.MySQL.TLS))
+	}
+
+	for name, size := range map[string]string{"max_body_size": c.Server.MaxBodySize, "max_import_body_size": c.Server.MaxImportBodySize} {
+		if _, err := bytes.Parse(size); err != nil {
+			problems = append(problems, fmt.Sprintf("server.%s must be a size like 512K or 1M, got %q", name, size))
+		}
+	}
+
+	for _, cidr := range c.Server.TrustedProxies {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			problems = append(problems, fmt.Sprintf("server.trusted_proxies must be CIDRs like 10.0.0.0/8, got %q", cidr))
+		}
+	}
+
+	if c.Trash.Retention > 0 && c.Trash.PurgeInterval <= 0 {
+		problems = append(problems, fmt.Sprintf("trash.purge_interval must be positive, got %v", c.Trash.PurgeInterval))
+	}
+
+	d := c.Dedupe
+	for name, value := range map[string]float64{"threshold": d.Threshold, "name_threshold": d.NameThreshold,
+		"name_weight": d.NameWeight, "email_weight": d.EmailWeight, "phone_weight": d.PhoneWeight} {
+		if value < 0 || value > 1 {
+			problems = append(problems, fmt.Sprintf("dedupe.%s must be between 0 and 1, got %v", name, value))
+		}
+	}
+
+	if _, ok := libphonenumber.GetSupportedRegions()[c.Phone.DefaultRegion]; !ok {
+		problems = append(problems, fmt.Sprintf("phone.default_region must be a supported region like US or BR, got %q", c.Phone.DefaultRegion))
+	}
+
+	if c.MySQL.Timeout < 0 {
+		problems = append(problems, fmt.Sprintf("mysql.timeout can't be negative, got %v", c.MySQL.Timeout))
+	}
+
+	if c.MySQL.Retry.MaxAttempts < 1 {
+		problems = append(problems, fmt.Sprintf("mysql.retry.max_attempts must be at least 1, got %d", c.MySQL.Retry.MaxAttempts))
+	}
+
+	if a := c.Auth; a.Enabled && a.HS256Secret == "" && a.RS256PublicKeyFile == "" && a.JWKSFile == "" {
+		problems = append(problems, "auth.hs256_secret, auth.rs256_public_key_file or auth.jwks_file is required when auth.enabled is true")
+	}
+
+	if e := c.Log.Encoding; e != "" && e != "json" && e != "console" {
+		problems = append(problems, fmt.Sprintf("log.encoding must be json or console, got %q", e))
+	}
+
+	for route, rate := range c.HTTPLog.SampleRates {
+		if rate < 0 || rate > 1 {
+			problems = append(problems, fmt.Sprintf("http_log.sample_rates[%q] must be between 0 and 1, got %v", route, rate))
+		}
+	}
+
+	if len(problems) != 0 {
+		return fmt.Errorf("invalid configuration:\n\t%s", strings.Join(problems, "\n\t"))
+	}
+
+	return nil
+}
+
+// String prints the configuration with all the secret values redacted, so it's safe to log
+func (c Config) String() string {
+	var b strings.Builder
+
+	for _, f := range fieldsOf(&c) {
+		value := fmt.Sprint(f.value.Interface())
+		if f.tag.Get("secret") == "true" && !f.value.IsZero() {
+			value = "[REDACTED]"
+		}
+
+		fmt.Fprintf(&b, "%s: %s\n", f.path, value)
+	}
+
+	return b.String()
+}
+
+// Set is the wire.ProviderSet of the config package
+var Set = wire.NewSet(ProvideConfig)
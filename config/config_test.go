@@ -0,0 +1,188 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setenv sets an environment variable for the duration of the test
+func setenv(t *testing.T, key, value string) {
+	t.Helper()
+
+	old, existed := os.LookupEnv(key)
+	os.Setenv(key, value)
+
+	t.Cleanup(func() {
+		if existed {
+			os.Setenv(key, old)
+		} else {
+			os.Unsetenv(key)
+		}
+	})
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatalf("error while creating temp dir: %v", err)
+	}
+
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("error while writing %s: %v", path, err)
+	}
+
+	return path
+}
+
+func setRequired(t *testing.T) {
+	setenv(t, "MYSQL_USER", "root")
+	setenv(t, "MYSQL_DATABASE", "go_contacts")
+	setenv(t, "AUTH_HS256_SECRET", "testing")
+}
+
+func TestLoadDefaults(t *testing.T) {
+	setRequired(t)
+
+	cfg, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Load() returned an error: %v", err)
+	}
+
+	if expected, got := ":8080", cfg.Server.Address; expected != got {
+		t.Errorf("Load() Server.Address == %q, want %q", got, expected)
+	}
+
+	if expected, got := "localhost", cfg.MySQL.Host; expected != got {
+		t.Errorf("Load() MySQL.Host == %q, want %q", got, expected)
+	}
+
+	if expected, got := 5*time.Second, cfg.Log.HTTPSink.FlushInterval; expected != got {
+		t.Errorf("Load() Log.HTTPSink.FlushInterval == %v, want %v", got, expected)
+	}
+
+	// every route requires authentication unless configured otherwise
+	if len(cfg.Auth.PublicRoutes) != 0 {
+		t.Errorf("Load() Auth.PublicRoutes == %v, want none", cfg.Auth.PublicRoutes)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	var testCases = []struct {
+		testName string
+		file     string
+		content  string
+	}{
+		{
+			"yaml",
+			"config.yaml",
+			"mysql:\n  host: file-host\n  port: \"3307\"\nlog:\n  level: warn\nhttp_log:\n  sample_rates:\n    \"GET /contacts/\": 0.25\n",
+		},
+		{
+			"toml",
+			"config.toml",
+			"[mysql]\nhost = \"file-host\"\nport = \"3307\"\n\n[log]\nlevel = \"warn\"\n\n[http_log.sample_rates]\n\"GET /contacts/\" = 0.25\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			setRequired(t)
+			setenv(t, "CONFIG_FILE", writeFile(t, tc.file, tc.content))
+			setenv(t, "MYSQL_HOST", "env-host")
+
+			cfg, err := Load([]string{"--log-level", "debug"})
+			if err != nil {
+				t.Fatalf("Load() returned an error: %v", err)
+			}
+
+			if expected, got := "3307", cfg.MySQL.Port; expected != got {
+				t.Errorf("Load() MySQL.Port == %q, want the file value %q", got, expected)
+			}
+
+			if expected, got := "env-host", cfg.MySQL.Host; expected != got {
+				t.Errorf("Load() MySQL.Host == %q, want the env value %q", got, expected)
+			}
+
+			if expected, got := "debug", cfg.Log.Level; expected != got {
+				t.Errorf("Load() Log.Level == %q, want the flag value %q", got, expected)
+			}
+
+			if expected, got := 0.25, cfg.HTTPLog.SampleRates["GET /contacts/"]; expected != got {
+				t.Errorf("Load() HTTPLog.SampleRates == %v, want %v for GET /contacts/", cfg.HTTPLog.SampleRates, expected)
+			}
+		})
+	}
+}
+
+func TestLoadSecretFile(t *testing.T) {
+	setRequired(t)
+	setenv(t, "MYSQL_PASSWORD_FILE", writeFile(t, "password", "s3cr3t\n"))
+
+	cfg, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Load() returned an error: %v", err)
+	}
+
+	if expected, got := "s3cr3t", cfg.MySQL.Password; expected != got {
+		t.Errorf("Load() MySQL.Password == %q, want %q", got, expected)
+	}
+
+	if printed := cfg.String(); strings.Contains(printed, "s3cr3t") {
+		t.Errorf("Config.String() leaks the MySQL password:\n%s", printed)
+	}
+
+	setenv(t, "MYSQL_PASSWORD", "other")
+	if _, err := Load(nil); err == nil {
+		t.Error("Load() with both MYSQL_PASSWORD and MYSQL_PASSWORD_FILE error == nil, want non-nil")
+	}
+}
+
+func TestLoadValidation(t *testing.T) {
+	var testCases = []struct {
+		testName string
+		args     []string
+		contains []string
+	}{
+		{"missing_required", nil, []string{"mysql.user", "MYSQL_USER", "mysql.database"}},
+		{"invalid_value", []string{"--mysql-user", "root", "--mysql-database", "db", "--http-log-max-body-size", "big"}, []string{"--http-log-max-body-size"}},
+		{"invalid_mode", []string{"--mysql-user", "root", "--mysql-database", "db", "--log-mode", "verbose"}, []string{"log.mode"}},
+		{"invalid_body_size", []string{"--mysql-user", "root", "--mysql-database", "db", "--server-max-body-size", "huge"}, []string{"server.max_body_size"}},
+		{"invalid_dedupe_threshold", []string{"--mysql-user", "root", "--mysql-database", "db", "--dedupe-threshold", "1.5"}, []string{"dedupe.threshold"}},
+		{"negative_mysql_timeout", []string{"--mysql-user", "root", "--mysql-database", "db", "--mysql-timeout", "-1s"}, []string{"mysql.timeout"}},
+		{"invalid_phone_region", []string{"--mysql-user", "root", "--mysql-database", "db", "--phone-default-region", "XX"}, []string{"phone.default_region"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			_, err := Load(tc.args)
+			if err == nil {
+				t.Fatal("Load() error == nil, want non-nil")
+			}
+
+			for _, s := range tc.contains {
+				if !strings.Contains(err.Error(), s) {
+					t.Errorf("Load() error %q doesn't mention %q", err, s)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadUnknownFileKey(t *testing.T) {
+	setRequired(t)
+	setenv(t, "CONFIG_FILE", writeFile(t, "config.yaml", "mysql:\n  hots: typo\n"))
+
+	_, err := Load(nil)
+	if err == nil || !strings.Contains(err.Error(), "mysql.hots") {
+		t.Errorf("Load() error == %v, want an unknown key error for mysql.hots", err)
+	}
+}
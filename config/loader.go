@@ -0,0 +1,380 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+// field is a single configurable value of the Config struct, identified by its dotted path
+type field struct {
+	path  string
+	value reflect.Value
+	tag   reflect.StructTag
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// fieldsOf flattens all the leaf fields of the struct pointed by v
+func fieldsOf(v interface{}) []field {
+	return collectFields(reflect.ValueOf(v).Elem(), "")
+}
+
+func collectFields(v reflect.Value, prefix string) []field {
+	var fields []field
+
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+		path := prefix + sf.Tag.Get("yaml")
+
+		if sf.Type.Kind() == reflect.Struct {
+			fields = append(fields, collectFields(v.Field(i), path+".")...)
+			continue
+		}
+
+		fields = append(fields, field{path: path, value: v.Field(i), tag: sf.Tag})
+	}
+
+	return fields
+}
+
+// Load resolves the configuration by layering, from the lowest to the highest precedence: the defaults,
+// the configuration file, the environment variables and the command line flags. The configuration file
+// is taken from the --config flag or the CONFIG_FILE variable and may be written in YAML or TOML.
+// Every environment variable can also be read from a file, by setting <NAME>_FILE to its path
+func Load(args []string) (*Config, error) {
+	cfg := &Config{}
+	fields := fieldsOf(cfg)
+
+	for _, f := range fields {
+		if d, ok := f.tag.Lookup("default"); ok {
+			if err := setString(f.value, d); err != nil {
+				return nil, fmt.Errorf("invalid default for %s: %w", f.path, err)
+			}
+		}
+	}
+
+	fs := flag.NewFlagSet("go-contacts", flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or TOML configuration file")
+
+	byFlag := make(map[string]field)
+	for _, f := range fields {
+		if name := f.tag.Get("flag"); name != "" {
+			byFlag[name] = f
+			fs.Var(&rawValue{isBool: f.value.Kind() == reflect.Bool}, name, f.tag.Get("usage"))
+		}
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, fmt.Errorf("error while parsing flags: %w", err)
+	}
+
+	if *configFile != "" {
+		if err := loadFile(*configFile, fields); err != nil {
+			return nil, err
+		}
+	}
+
+	var problems []string
+
+	for _, f := range fields {
+		if err := loadEnv(f); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	fs.Visit(func(fl *flag.Flag) {
+		f, ok := byFlag[fl.Name]
+		if !ok {
+			return
+		}
+
+		if err := setString(f.value, fl.Value.String()); err != nil {
+			problems = append(problems, fmt.Sprintf("--%s: %v", fl.Name, err))
+		}
+	})
+
+	for _, f := range fields {
+		if f.tag.Get("required") == "true" && f.value.IsZero() {
+			problems = append(problems, fmt.Sprintf("%s is required (env %s, flag --%s)", f.path, f.tag.Get("env"), f.tag.Get("flag")))
+		}
+	}
+
+	if len(problems) != 0 {
+		return nil, fmt.Errorf("invalid configuration:\n\t%s", strings.Join(problems, "\n\t"))
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// loadEnv sets the field from its environment variable, or from the file pointed by <NAME>_FILE
+func loadEnv(f field) error {
+	key := f.tag.Get("env")
+	if key == "" {
+		return nil
+	}
+
+	value, filePath := os.Getenv(key), os.Getenv(key+"_FILE")
+
+	switch {
+	case value != "" && filePath != "":
+		return fmt.Errorf("%s and %s_FILE are both set, only one is allowed", key, key)
+	case filePath != "":
+		b, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			return fmt.Errorf("%s_FILE: %v", key, err)
+		}
+
+		value = strings.TrimRight(string(b), "\r\n")
+	case value == "":
+		return nil
+	}
+
+	if err := setString(f.value, value); err != nil {
+		return fmt.Errorf("%s: %v", key, err)
+	}
+
+	return nil
+}
+
+// loadFile decodes the configuration file and sets every field present in it.
+// Unknown keys are rejected, so typos don't go unnoticed
+func loadFile(path string, fields []field) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error while reading config file: %w", err)
+	}
+
+	tree := make(map[string]interface{})
+
+	switch ext := filepath.Ext(path); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &tree)
+	case ".toml":
+		_, err = toml.Decode(string(b), &tree)
+	default:
+		return fmt.Errorf("unsupported config file extension %q, want .yaml, .yml or .toml", ext)
+	}
+
+	if err != nil {
+		return fmt.Errorf("error while decoding config file %s: %w", path, err)
+	}
+
+	byPath := make(map[string]field, len(fields))
+	for _, f := range fields {
+		byPath[f.path] = f
+	}
+
+	var problems []string
+	walkTree(tree, "", func(path string, value interface{}) bool {
+		f, ok := byPath[path]
+		if !ok {
+			if _, isMap := asMap(value); isMap && isPrefix(path, fields) {
+				return true
+			}
+
+			problems = append(problems, fmt.Sprintf("unknown key %s", path))
+			return false
+		}
+
+		if err := setValue(f.value, value); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", path, err))
+		}
+
+		return false
+	})
+
+	if len(problems) != 0 {
+		return fmt.Errorf("invalid config file %s:\n\t%s", path, strings.Join(problems, "\n\t"))
+	}
+
+	return nil
+}
+
+// walkTree calls fn for every key of the decoded file, descending into nested maps when fn returns true
+func walkTree(tree map[string]interface{}, prefix string, fn func(path string, value interface{}) bool) {
+	for k, v := range tree {
+		path := prefix + k
+		if fn(path, v) {
+			nested, _ := asMap(v)
+			walkTree(nested, path+".", fn)
+		}
+	}
+}
+
+func isPrefix(path string, fields []field) bool {
+	for _, f := range fields {
+		if strings.HasPrefix(f.path, path+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// asMap normalizes the maps produced by the YAML and TOML decoders
+func asMap(v interface{}) (map[string]interface{}, bool) {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m, true
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(m))
+		for k, item := range m {
+			converted[fmt.Sprint(k)] = item
+		}
+
+		return converted, true
+	}
+
+	return nil, false
+}
+
+// setValue sets a field from a value decoded from the configuration file
+func setValue(v reflect.Value, in interface{}) error {
+	switch v.Kind() {
+	case reflect.Slice:
+		items, ok := in.([]interface{})
+		if !ok {
+			return setString(v, fmt.Sprint(in))
+		}
+
+		slice := reflect.MakeSlice(v.Type(), 0, len(items))
+		for _, item := range items {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := setString(elem, fmt.Sprint(item)); err != nil {
+				return err
+			}
+
+			slice = reflect.Append(slice, elem)
+		}
+
+		v.Set(slice)
+		return nil
+	case reflect.Map:
+		m, ok := asMap(in)
+		if !ok {
+			return fmt.Errorf("expected a map, got %T", in)
+		}
+
+		converted := reflect.MakeMapWithSize(v.Type(), len(m))
+		for k, item := range m {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := setString(elem, fmt.Sprint(item)); err != nil {
+				return fmt.Errorf("key %q: %w", k, err)
+			}
+
+			converted.SetMapIndex(reflect.ValueOf(k), elem)
+		}
+
+		v.Set(converted)
+		return nil
+	}
+
+	return setString(v, fmt.Sprint(in))
+}
+
+// setString sets a field from its string representation, as found in defaults, env variables and flags.
+// Lists are comma separated and maps are written as comma separated key=value pairs
+func setString(v reflect.Value, s string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+
+		v.SetBool(b)
+	case reflect.Int:
+		i, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+
+		v.SetInt(int64(i))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+
+		v.SetFloat(f)
+	case reflect.Slice:
+		slice := reflect.MakeSlice(v.Type(), 0, 0)
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := setString(elem, item); err != nil {
+				return err
+			}
+
+			slice = reflect.Append(slice, elem)
+		}
+
+		v.Set(slice)
+	case reflect.Map:
+		m := reflect.MakeMap(v.Type())
+		for _, entry := range strings.Split(s, ",") {
+			if strings.TrimSpace(entry) == "" {
+				continue
+			}
+
+			i := strings.LastIndex(entry, "=")
+			if i == -1 {
+				return fmt.Errorf("malformed entry %q, want key=value", entry)
+			}
+
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := setString(elem, strings.TrimSpace(entry[i+1:])); err != nil {
+				return err
+			}
+
+			m.SetMapIndex(reflect.ValueOf(strings.TrimSpace(entry[:i])), elem)
+		}
+
+		v.Set(m)
+	default:
+		return errors.New("unsupported field type " + v.Type().String())
+	}
+
+	return nil
+}
+
+// rawValue is a flag.Value that only keeps the raw string, so flags go through the same parsing
+// as every other configuration source
+type rawValue struct {
+	value  string
+	isBool bool
+}
+
+func (r *rawValue) String() string     { return r.value }
+func (r *rawValue) Set(s string) error { r.value = s; return nil }
+func (r *rawValue) IsBoolFlag() bool   { return r.isBool }
@@ -0,0 +1,33 @@
+package address
+
+import "github.com/google/wire"
+
+// Well-known address labels. Any other label up to 50 characters is a custom one
+const (
+	LabelHome  = "home"
+	LabelWork  = "work"
+	LabelOther = "other"
+)
+
+// DefaultLabel is the label of the addresses created without one
+const DefaultLabel = LabelOther
+
+// Labels are the well-known address labels
+var Labels = []string{LabelHome, LabelWork, LabelOther}
+
+// Address represents a contact's postal address
+type Address struct {
+	ID         int    `json:"id,omitempty"`
+	ContactID  int    `json:"contact_id"`
+	Street     string `json:"street"`
+	Locality   string `json:"locality"`
+	Region     string `json:"region"`
+	PostalCode string `json:"postal_code"`
+	// Country is the ISO 3166-1 alpha-2 code of the country, empty when it's unknown
+	Country string `json:"country"`
+	// Label is one of Labels or a custom label given by the user
+	Label string `json:"label"`
+}
+
+// Set is a Wire set that contains all the providers for this package
+var Set = wire.NewSet(RepositorySet)
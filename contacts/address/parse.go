@@ -0,0 +1,126 @@
+package address
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/language"
+)
+
+// ErrInvalidAddress is returned when an address is empty or one of its fields is too long, wrapped with the reason
+var ErrInvalidAddress = errors.New("invalid postal address")
+
+// ErrInvalidCountry is returned when a country isn't an ISO 3166-1 alpha-2 code, wrapped with the country
+var ErrInvalidCountry = errors.New("invalid country")
+
+// ErrInvalidLabel is returned when an address label is too long, wrapped with the label
+var ErrInvalidLabel = errors.New("invalid address label")
+
+// The maximum lengths of the fields of an address, as stored in the database
+const (
+	maxStreetLength     = 255
+	maxLocalityLength   = 100
+	maxRegionLength     = 100
+	maxPostalCodeLength = 20
+	maxLabelLength      = 50
+)
+
+// Parse validates the address, which must have at least one of its street, locality, region and postal code.
+// It returns the address trimmed, with the country code uppercased and DefaultLabel as its label when it has none.
+// The labels that are one of Labels are lowercased, the custom ones are kept as they were provided
+func Parse(data CreateAddressData) (CreateAddressData, error) {
+	fields := []struct {
+		name   string
+		value  *string
+		length int
+	}{
+		{"street", &data.Street, maxStreetLength},
+		{"locality", &data.Locality, maxLocalityLength},
+		{"region", &data.Region, maxRegionLength},
+		{"postal code", &data.PostalCode, maxPostalCodeLength},
+	}
+
+	empty := true
+
+	for _, f := range fields {
+		*f.value = strings.TrimSpace(*f.value)
+
+		if utf8.RuneCountInString(*f.value) > f.length {
+			return CreateAddressData{}, fmt.Errorf("%w: the %s is longer than %d characters", ErrInvalidAddress, f.name, f.length)
+		}
+
+		empty = empty && *f.value == ""
+	}
+
+	if empty {
+		return CreateAddressData{}, fmt.Errorf("%w: it needs a street, a locality, a region or a postal code", ErrInvalidAddress)
+	}
+
+	country, err := parseCountry(data.Country)
+	if err != nil {
+		return CreateAddressData{}, err
+	}
+
+	label, err := parseLabel(data.Label)
+	if err != nil {
+		return CreateAddressData{}, err
+	}
+
+	data.Country, data.Label = country, label
+	return data, nil
+}
+
+// ParseAll parses all the addresses of a contact
+func ParseAll(addresses ...CreateAddressData) ([]CreateAddressData, error) {
+	parsed := make([]CreateAddressData, 0, len(addresses))
+
+	for _, data := range addresses {
+		data, err := Parse(data)
+		if err != nil {
+			return nil, err
+		}
+
+		parsed = append(parsed, data)
+	}
+
+	return parsed, nil
+}
+
+// parseCountry returns the ISO 3166-1 alpha-2 code of the country, which may be empty. Deprecated codes are
+// replaced by the current ones, like UK by GB
+func parseCountry(country string) (string, error) {
+	country = strings.TrimSpace(country)
+	if country == "" {
+		return "", nil
+	}
+
+	region, err := language.ParseRegion(country)
+	if len(country) != 2 || err != nil || !region.IsCountry() {
+		return "", fmt.Errorf("%w: %q isn't an ISO 3166-1 alpha-2 code", ErrInvalidCountry, country)
+	}
+
+	return region.Canonicalize().String(), nil
+}
+
+// parseLabel returns the label trimmed, DefaultLabel when it's empty and lowercased when it's one of Labels
+func parseLabel(label string) (string, error) {
+	label = strings.TrimSpace(label)
+
+	if label == "" {
+		return DefaultLabel, nil
+	}
+
+	for _, known := range Labels {
+		if strings.EqualFold(label, known) {
+			return known, nil
+		}
+	}
+
+	if utf8.RuneCountInString(label) > maxLabelLength {
+		return "", fmt.Errorf("%w: %q is longer than %d characters", ErrInvalidLabel, label, maxLabelLength)
+	}
+
+	return label, nil
+}
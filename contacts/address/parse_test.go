@@ -0,0 +1,80 @@
+package address
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	var testCases = []struct {
+		testName string
+		data     CreateAddressData
+		expected CreateAddressData
+	}{
+		{
+			"full",
+			CreateAddressData{Street: " Av. Paulista, 1000 ", Locality: "São Paulo", Region: "SP", PostalCode: "01310-100", Country: "br", Label: "WORK"},
+			CreateAddressData{Street: "Av. Paulista, 1000", Locality: "São Paulo", Region: "SP", PostalCode: "01310-100", Country: "BR", Label: LabelWork},
+		},
+		{
+			"locality_only",
+			CreateAddressData{Locality: "Tokyo"},
+			CreateAddressData{Locality: "Tokyo", Label: DefaultLabel},
+		},
+		{
+			"deprecated_country",
+			CreateAddressData{PostalCode: "SW1A 1AA", Country: "UK", Label: " Grandma's "},
+			CreateAddressData{PostalCode: "SW1A 1AA", Country: "GB", Label: "Grandma's"},
+		},
+		{
+			"max_lengths",
+			CreateAddressData{Street: strings.Repeat("é", 255), PostalCode: strings.Repeat("9", 20), Label: strings.Repeat("é", 50)},
+			CreateAddressData{Street: strings.Repeat("é", 255), PostalCode: strings.Repeat("9", 20), Label: strings.Repeat("é", 50)},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			if parsed, err := Parse(tc.data); err != nil || parsed != tc.expected {
+				t.Errorf("Parse(%+v) = %+v, %v, want %+v", tc.data, parsed, err, tc.expected)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	var testCases = []struct {
+		testName string
+		data     CreateAddressData
+		expected error
+	}{
+		{"empty", CreateAddressData{Country: "BR", Label: LabelHome}, ErrInvalidAddress},
+		{"blank", CreateAddressData{Street: "  ", Locality: "\t"}, ErrInvalidAddress},
+		{"long_street", CreateAddressData{Street: strings.Repeat("a", 256)}, ErrInvalidAddress},
+		{"long_postal_code", CreateAddressData{PostalCode: strings.Repeat("9", 21)}, ErrInvalidAddress},
+		{"alpha_3_country", CreateAddressData{Locality: "Tokyo", Country: "JPN"}, ErrInvalidCountry},
+		{"unknown_country", CreateAddressData{Locality: "Tokyo", Country: "ZZ"}, ErrInvalidCountry},
+		{"numeric_country", CreateAddressData{Locality: "Tokyo", Country: "39"}, ErrInvalidCountry},
+		{"long_label", CreateAddressData{Locality: "Tokyo", Label: strings.Repeat("é", 51)}, ErrInvalidLabel},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			if _, err := Parse(tc.data); !errors.Is(err, tc.expected) {
+				t.Errorf("Parse(%+v) returned %v, want %v", tc.data, err, tc.expected)
+			}
+		})
+	}
+}
+
+func TestParseAll(t *testing.T) {
+	parsed, err := ParseAll(CreateAddressData{Locality: "Tokyo"}, CreateAddressData{Region: "SP", Country: "BR"})
+	if err != nil || len(parsed) != 2 || parsed[1].Label != DefaultLabel {
+		t.Errorf("ParseAll() = %+v, %v, want both addresses with the default label", parsed, err)
+	}
+
+	if _, err := ParseAll(CreateAddressData{Locality: "Tokyo"}, CreateAddressData{}); !errors.Is(err, ErrInvalidAddress) {
+		t.Errorf("ParseAll() with an empty address returned %v, want ErrInvalidAddress", err)
+	}
+}
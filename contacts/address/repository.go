@@ -0,0 +1,217 @@
+package address
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/LucasFrezarini/go-contacts/db"
+	"github.com/google/wire"
+	"go.uber.org/zap"
+)
+
+// CreateAddressData defines the fields that need to be provided in order to create a new
+// address record in the database. The label is DefaultLabel when empty
+type CreateAddressData struct {
+	Street     string `json:"street" validate:"max=255"`
+	Locality   string `json:"locality" validate:"max=100"`
+	Region     string `json:"region" validate:"max=100"`
+	PostalCode string `json:"postal_code" validate:"max=20"`
+	Country    string `json:"country" validate:"omitempty,len=2"`
+	Label      string `json:"label" validate:"max=50"`
+}
+
+// ErrAddressNotFound is returned when an address doesn't exist or belongs to another contact
+var ErrAddressNotFound = errors.New("address not found")
+
+// GenericRepository defines the structure of this package's repository
+// Defined especially to allow mocking in unit testing
+type GenericRepository interface {
+	FindByContactID(ctx context.Context, id int) ([]Address, error)
+	FindByID(ctx context.Context, contactID int, id int) (*Address, error)
+	Create(ctx context.Context, contactID int, addresses ...CreateAddressData) ([]Address, error)
+	Update(ctx context.Context, contactID int, id int, data CreateAddressData) (*Address, error)
+	DeleteByID(ctx context.Context, contactID int, id int) error
+	DeleteByContactID(ctx context.Context, contactID int) error
+	Reassign(ctx context.Context, id int, from int, to int) error
+}
+
+// Repository contains all the persistence related methods for the address entity
+type Repository struct {
+	DB     *sql.DB
+	Logger *zap.Logger
+}
+
+// ProvideRepository creates a new Repository with the dependencies provided.
+// Created especially for the use of Wire, which will inject the dependencies via DI
+func ProvideRepository(db *sql.DB, logger *zap.Logger) *Repository {
+	return &Repository{db, logger.Named("AddressRepository")}
+}
+
+// columns are the columns of the address table, in the order they're scanned by scan
+const columns = "id, contact_id, street, locality, region, postal_code, country, label"
+
+// scanner is implemented by both *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scan reads an address selected with columns
+func scan(s scanner) (Address, error) {
+	var a Address
+	err := s.Scan(&a.ID, &a.ContactID, &a.Street, &a.Locality, &a.Region, &a.PostalCode, &a.Country, &a.Label)
+	return a, err
+}
+
+// FindByContactID returns all the addresses registered for the provided contact id, in the order they were created
+func (r *Repository) FindByContactID(ctx context.Context, id int) ([]Address, error) {
+	raw := "SELECT " + columns + " FROM address WHERE contact_id = ? ORDER BY id"
+
+	rows, err := db.Conn(ctx, r.DB).QueryContext(ctx, raw, id)
+	if err != nil {
+		return nil, fmt.Errorf("FindByContactID(%d): error while executing query: %w", id, err)
+	}
+
+	defer rows.Close()
+	addresses := make([]Address, 0)
+
+	for rows.Next() {
+		a, err := scan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("FindByContactID(%d): error while scanning rows: %w", id, err)
+		}
+
+		addresses = append(addresses, a)
+	}
+
+	return addresses, nil
+}
+
+// FindByID returns the address with the provided id, as long as it belongs to the contact with the provided
+// contactID. Otherwise, ErrAddressNotFound is returned
+func (r *Repository) FindByID(ctx context.Context, contactID int, id int) (*Address, error) {
+	raw := "SELECT " + columns + " FROM address WHERE id = ? AND contact_id = ?"
+
+	a, err := scan(db.Conn(ctx, r.DB).QueryRowContext(ctx, raw, id, contactID))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("FindByID(%d, %d): %w", contactID, id, ErrAddressNotFound)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("FindByID(%d, %d): error while executing query: %w", contactID, id, err)
+	}
+
+	return &a, nil
+}
+
+// Create creates new addresses registered for the provided contactID
+func (r *Repository) Create(ctx context.Context, contactID int, addresses ...CreateAddressData) ([]Address, error) {
+	raw := "INSERT INTO address (contact_id, street, locality, region, postal_code, country, label) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	inserted := make([]Address, 0, len(addresses))
+
+	for _, data := range addresses {
+		result, err := db.Conn(ctx, r.DB).ExecContext(ctx, raw,
+			contactID, data.Street, data.Locality, data.Region, data.PostalCode, data.Country, data.Label)
+		if err != nil {
+			return nil, fmt.Errorf("Create(%d): error while executing the insert query: %w", contactID, err)
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, fmt.Errorf("Create(%d): error while fetching the last inserted ID: %w", contactID, err)
+		}
+
+		inserted = append(inserted, newAddress(int(id), contactID, data))
+	}
+
+	return inserted, nil
+}
+
+// Update changes the address with the provided id, as long as it belongs to the contact with the provided
+// contactID. The caller is responsible for checking that the address exists, since MySQL doesn't report
+// the rows matched by an update that changes nothing
+func (r *Repository) Update(ctx context.Context, contactID int, id int, data CreateAddressData) (*Address, error) {
+	raw := "UPDATE address SET street = ?, locality = ?, region = ?, postal_code = ?, country = ?, label = ? WHERE id = ? AND contact_id = ?"
+
+	_, err := db.Conn(ctx, r.DB).ExecContext(ctx, raw,
+		data.Street, data.Locality, data.Region, data.PostalCode, data.Country, data.Label, id, contactID)
+	if err != nil {
+		return nil, fmt.Errorf("Update(%d, %d): error while executing the update query: %w", contactID, id, err)
+	}
+
+	a := newAddress(id, contactID, data)
+	return &a, nil
+}
+
+// DeleteByID deletes the address with the provided id, as long as it belongs to the contact with the provided
+// contactID. Otherwise, ErrAddressNotFound is returned
+func (r *Repository) DeleteByID(ctx context.Context, contactID int, id int) error {
+	result, err := db.Conn(ctx, r.DB).ExecContext(ctx, "DELETE FROM address WHERE id = ? AND contact_id = ?", id, contactID)
+	if err != nil {
+		return fmt.Errorf("DeleteByID(%d, %d): error while executing the delete query: %w", contactID, id, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("DeleteByID(%d, %d): error while fetching the affected rows: %w", contactID, id, err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("DeleteByID(%d, %d): %w", contactID, id, ErrAddressNotFound)
+	}
+
+	return nil
+}
+
+// DeleteByContactID deletes all the addresses of the contact with the provided id
+func (r *Repository) DeleteByContactID(ctx context.Context, contactID int) error {
+	if _, err := db.Conn(ctx, r.DB).ExecContext(ctx, "DELETE FROM address WHERE contact_id = ?", contactID); err != nil {
+		return fmt.Errorf("DeleteByContactID(%d): error while executing the delete query: %w", contactID, err)
+	}
+
+	return nil
+}
+
+// Reassign moves the address with the provided id from the contact from to the contact to. When the address
+// doesn't belong to the contact from, ErrAddressNotFound is returned
+func (r *Repository) Reassign(ctx context.Context, id int, from int, to int) error {
+	raw := "UPDATE address SET contact_id = ? WHERE id = ? AND contact_id = ?"
+
+	result, err := db.Conn(ctx, r.DB).ExecContext(ctx, raw, to, id, from)
+	if err != nil {
+		return fmt.Errorf("Reassign(%d, %d, %d): error while executing the update query: %w", id, from, to, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("Reassign(%d, %d, %d): error while fetching the affected rows: %w", id, from, to, err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("Reassign(%d, %d, %d): %w", id, from, to, ErrAddressNotFound)
+	}
+
+	return nil
+}
+
+// newAddress returns the address with the provided ids and data
+func newAddress(id int, contactID int, data CreateAddressData) Address {
+	return Address{
+		ID:         id,
+		ContactID:  contactID,
+		Street:     data.Street,
+		Locality:   data.Locality,
+		Region:     data.Region,
+		PostalCode: data.PostalCode,
+		Country:    data.Country,
+		Label:      data.Label,
+	}
+}
+
+// RepositorySet is the wire set that contains all the providers for this repository
+var RepositorySet = wire.NewSet(
+	ProvideRepository,
+	wire.Bind(new(GenericRepository), new(*Repository)),
+)
@@ -0,0 +1,193 @@
+package address
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"go.uber.org/zap"
+)
+
+var cols = []string{"id", "contact_id", "street", "locality", "region", "postal_code", "country", "label"}
+
+func TestFindByContactID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	contactID := 2
+	expectedAddresses := []Address{
+		{ID: 1, ContactID: contactID, Street: "Av. Paulista, 1000", Locality: "São Paulo", Region: "SP", PostalCode: "01310-100", Country: "BR", Label: LabelHome},
+		{ID: 2, ContactID: contactID, Locality: "Tokyo", Country: "JP", Label: "grandma's"},
+	}
+
+	rows := sqlmock.NewRows(cols)
+
+	for _, a := range expectedAddresses {
+		rows.AddRow(a.ID, a.ContactID, a.Street, a.Locality, a.Region, a.PostalCode, a.Country, a.Label)
+	}
+
+	mock.ExpectQuery("SELECT (.+) FROM address WHERE contact_id = (.+) ORDER BY id").WithArgs(contactID).WillReturnRows(rows).RowsWillBeClosed()
+
+	repository := ProvideRepository(db, zap.NewNop())
+	addresses, err := repository.FindByContactID(context.Background(), contactID)
+
+	if err != nil || !reflect.DeepEqual(addresses, expectedAddresses) {
+		t.Errorf("FindByContactID(%d) = %v, %v, want %v", contactID, addresses, err, expectedAddresses)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("FindByContactID(%d) unfulfilled mock expectations: %v", contactID, err)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	contactID := 2
+	addressesData := []CreateAddressData{
+		{Street: "Av. Paulista, 1000", Locality: "São Paulo", Region: "SP", PostalCode: "01310-100", Country: "BR", Label: LabelWork},
+		{Locality: "Tokyo", Label: DefaultLabel},
+	}
+
+	for i, d := range addressesData {
+		mock.ExpectExec("INSERT INTO address").WithArgs(contactID, d.Street, d.Locality, d.Region, d.PostalCode, d.Country, d.Label).
+			WillReturnResult(sqlmock.NewResult(int64(i+1), 1))
+	}
+
+	repository := ProvideRepository(db, zap.NewNop())
+	inserted, err := repository.Create(context.Background(), contactID, addressesData...)
+
+	expected := []Address{
+		{ID: 1, ContactID: contactID, Street: "Av. Paulista, 1000", Locality: "São Paulo", Region: "SP", PostalCode: "01310-100", Country: "BR", Label: LabelWork},
+		{ID: 2, ContactID: contactID, Locality: "Tokyo", Label: DefaultLabel},
+	}
+
+	if err != nil || !reflect.DeepEqual(inserted, expected) {
+		t.Errorf("Create(%d) = %v, %v, want %v", contactID, inserted, err, expected)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Create(%d) unfulfilled mock expectations: %v", contactID, err)
+	}
+}
+
+func TestFindByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	expected := &Address{ID: 3, ContactID: 2, Locality: "Tokyo", Country: "JP", Label: LabelHome}
+
+	mock.ExpectQuery("SELECT (.+) FROM address WHERE id = (.+) AND contact_id = (.+)").WithArgs(3, 2).
+		WillReturnRows(sqlmock.NewRows(cols).AddRow(3, 2, "", "Tokyo", "", "", "JP", LabelHome))
+	mock.ExpectQuery("SELECT (.+) FROM address").WithArgs(3, 1).WillReturnRows(sqlmock.NewRows(cols))
+
+	repository := ProvideRepository(db, zap.NewNop())
+
+	if a, err := repository.FindByID(context.Background(), 2, 3); err != nil || !reflect.DeepEqual(a, expected) {
+		t.Errorf("FindByID(2, 3) = %v, %v, want %v", a, err, expected)
+	}
+
+	if _, err := repository.FindByID(context.Background(), 1, 3); !errors.Is(err, ErrAddressNotFound) {
+		t.Errorf("FindByID(1, 3) of an address of another contact returned %v, want ErrAddressNotFound", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("FindByID(): unfulfilled mock expectations: %v", err)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	data := CreateAddressData{Street: "1-1 Chiyoda", Locality: "Tokyo", PostalCode: "100-8111", Country: "JP", Label: LabelWork}
+
+	mock.ExpectExec("UPDATE address SET street = (.+), locality = (.+), region = (.+), postal_code = (.+), country = (.+), label = (.+) WHERE id = (.+) AND contact_id = (.+)").
+		WithArgs(data.Street, data.Locality, data.Region, data.PostalCode, data.Country, data.Label, 3, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	repository := ProvideRepository(db, zap.NewNop())
+	a, err := repository.Update(context.Background(), 2, 3, data)
+
+	expected := &Address{ID: 3, ContactID: 2, Street: data.Street, Locality: data.Locality, PostalCode: data.PostalCode, Country: data.Country, Label: data.Label}
+	if err != nil || !reflect.DeepEqual(a, expected) {
+		t.Errorf("Update(2, 3) = %v, %v, want %v", a, err, expected)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Update(): unfulfilled mock expectations: %v", err)
+	}
+}
+
+func TestDeleteByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	mock.ExpectExec("DELETE FROM address WHERE id = (.+) AND contact_id = (.+)").WithArgs(3, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM address").WithArgs(3, 1).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repository := ProvideRepository(db, zap.NewNop())
+
+	if err := repository.DeleteByID(context.Background(), 2, 3); err != nil {
+		t.Errorf("DeleteByID(2, 3) returned %v, want nil", err)
+	}
+
+	if err := repository.DeleteByID(context.Background(), 1, 3); !errors.Is(err, ErrAddressNotFound) {
+		t.Errorf("DeleteByID(1, 3) of an address of another contact returned %v, want ErrAddressNotFound", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("DeleteByID(): unfulfilled mock expectations: %v", err)
+	}
+}
+
+func TestReassign(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	id, from, to := 3, 2, 1
+
+	mock.ExpectExec("UPDATE address SET contact_id = (.+) WHERE id = (.+) AND contact_id = (.+)").WithArgs(to, id, from).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE address").WithArgs(to, id, from).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repository := ProvideRepository(db, zap.NewNop())
+
+	if err := repository.Reassign(context.Background(), id, from, to); err != nil {
+		t.Errorf("Reassign(%d, %d, %d) returned a non-nil error '%v', want nil", id, from, to, err)
+	}
+
+	// the address was moved to another contact since
+	if err := repository.Reassign(context.Background(), id, from, to); !errors.Is(err, ErrAddressNotFound) {
+		t.Errorf("Reassign(%d, %d, %d) returned %v, want %v", id, from, to, err, ErrAddressNotFound)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Reassign(%d, %d, %d): unfulfilled mock expectations: %v", id, from, to, err)
+	}
+}
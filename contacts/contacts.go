@@ -1,17 +1,71 @@
 package contacts
 
 import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/LucasFrezarini/go-contacts/contacts/address"
+	"github.com/LucasFrezarini/go-contacts/contacts/dedupe"
 	"github.com/LucasFrezarini/go-contacts/contacts/email"
 	"github.com/LucasFrezarini/go-contacts/contacts/phone"
 	"github.com/google/wire"
 )
 
 type Contact struct {
-	ID        int           `json:"id,omitempty"`
-	FirstName string        `json:"first_name" validate:"required"`
-	LastName  string        `json:"last_name" validate:"required"`
-	Emails    []email.Email `json:"emails"`
-	Phones    []phone.Phone `json:"phones"`
+	ID            int               `json:"id,omitempty"`
+	AddressBookID *int              `json:"address_book_id,omitempty"`
+	FirstName     string            `json:"first_name" validate:"required"`
+	LastName      string            `json:"last_name" validate:"required"`
+	Emails        []email.Email     `json:"emails"`
+	Phones        []phone.Phone     `json:"phones"`
+	Addresses     []address.Address `json:"addresses"`
+	// Organization, Department and Title are where the contact works and their job title, empty when unknown
+	Organization string `json:"organization" validate:"max=100"`
+	Department   string `json:"department" validate:"max=100"`
+	Title        string `json:"title" validate:"max=100"`
+	// DeletedAt is when the contact was moved to the trash
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// Version is incremented by every change made to the contact, for optimistic concurrency control
+	Version int `json:"version"`
+}
+
+// ETag returns the entity tag identifying the current version of the contact
+func (c *Contact) ETag() string {
+	return `"` + strconv.Itoa(c.Version) + `"`
+}
+
+// PrimaryEmail returns the primary email of the contact, or nil when it has none
+func (c *Contact) PrimaryEmail() *email.Email {
+	for i := range c.Emails {
+		if c.Emails[i].IsPrimary {
+			return &c.Emails[i]
+		}
+	}
+
+	return nil
+}
+
+// PrimaryPhone returns the primary phone of the contact, or nil when it has none
+func (c *Contact) PrimaryPhone() *phone.Phone {
+	for i := range c.Phones {
+		if c.Phones[i].IsPrimary {
+			return &c.Phones[i]
+		}
+	}
+
+	return nil
+}
+
+// MarshalJSON encodes the contact along with its entity tag, so clients can send it back in If-Match
+// when they change a contact they got from a list
+func (c Contact) MarshalJSON() ([]byte, error) {
+	type contact Contact
+
+	return json.Marshal(struct {
+		contact
+		ETag string `json:"etag"`
+	}{contact(c), c.ETag()})
 }
 
 // Set is a set that contains all the Wire providers from this package
@@ -19,6 +73,11 @@ var Set = wire.NewSet(
 	ControllerSet,
 	ServiceSet,
 	RepositorySet,
+	RevisionRepositorySet,
+	MergeRepositorySet,
+	ProvidePurgeJob,
+	dedupe.Set,
 	email.Set,
 	phone.Set,
+	address.Set,
 )
@@ -1,6 +1,14 @@
 package contacts
 
 import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/LucasFrezarini/go-contacts/addressbook"
+	"github.com/LucasFrezarini/go-contacts/audit"
+	"github.com/LucasFrezarini/go-contacts/contacts/address"
+	"github.com/LucasFrezarini/go-contacts/contacts/dedupe"
 	"github.com/LucasFrezarini/go-contacts/contacts/email"
 	"github.com/LucasFrezarini/go-contacts/contacts/phone"
 	"go.uber.org/zap"
@@ -8,14 +16,20 @@ import (
 
 var contactsList = []*Contact{
 	{
-		ID:        1,
-		FirstName: "Inosuke",
-		LastName:  "Hashibira",
+		ID:           1,
+		FirstName:    "Inosuke",
+		LastName:     "Hashibira",
+		Organization: "Demon Slayer Corps",
+		Department:   "Beast Breathing",
+		Title:        "Demon Slayer",
+		Version:      1,
 	},
 	{
-		ID:        2,
-		FirstName: "Gonpachiro",
-		LastName:  "Kamaboko",
+		ID:           2,
+		FirstName:    "Gonpachiro",
+		LastName:     "Kamaboko",
+		Organization: "Kamaboko Squad",
+		Version:      1,
 	},
 }
 
@@ -23,39 +37,139 @@ type MockedContactsRepository struct {
 	id int
 }
 
-func (m *MockedContactsRepository) FindAll() ([]*Contact, error) {
-	return contactsList, nil
+func (m *MockedContactsRepository) FindAll(ctx context.Context, f Filter) ([]*Contact, error) {
+	if f.EmailLabel == "" && f.Organization == "" && f.Sort == SortNone {
+		return contactsList, nil
+	}
+
+	found := make([]*Contact, 0)
+
+	for _, c := range contactsList {
+		if f.Organization != "" && c.Organization != f.Organization {
+			continue
+		}
+
+		if f.EmailLabel == "" {
+			found = append(found, c)
+			continue
+		}
+
+		for _, e := range filterEmailsByContactID(c.ID) {
+			if e.Label == f.EmailLabel {
+				found = append(found, c)
+				break
+			}
+		}
+	}
+
+	if f.Sort != SortNone {
+		sorted := make([]*Contact, len(found))
+		copy(sorted, found)
+
+		// the contacts without an organization are listed last, like in ContactsRepository
+		sort.SliceStable(sorted, func(i, j int) bool {
+			a, b := sorted[i].Organization, sorted[j].Organization
+			if (a == "") != (b == "") {
+				return b == ""
+			}
+
+			return (f.Sort == SortOrganization && a < b) || (f.Sort == SortOrganizationDesc && a > b)
+		})
+
+		found = sorted
+	}
+
+	return found, nil
+}
+
+func (m *MockedContactsRepository) Organizations(ctx context.Context) ([]Organization, error) {
+	counts := make(map[string]int)
+	organizations := make([]Organization, 0)
+
+	for _, c := range contactsList {
+		if c.Organization == "" {
+			continue
+		}
+
+		if counts[c.Organization] == 0 {
+			organizations = append(organizations, Organization{Name: c.Organization})
+		}
+
+		counts[c.Organization]++
+	}
+
+	for i := range organizations {
+		organizations[i].Contacts = counts[organizations[i].Name]
+	}
+
+	return organizations, nil
 }
 
-func (m *MockedContactsRepository) Create(c Contact) (*Contact, error) {
+func (m *MockedContactsRepository) FindByID(ctx context.Context, id int) (*Contact, error) {
+	for _, c := range contactsList {
+		if c.ID == id {
+			found := *c
+			return &found, nil
+		}
+	}
+
+	return nil, ErrContactNotFound
+}
+
+func (m *MockedContactsRepository) Create(ctx context.Context, c Contact) (*Contact, error) {
 	m.id++
 
 	return &Contact{
-		ID:        m.id,
-		FirstName: c.FirstName,
-		LastName:  c.LastName,
+		ID:           m.id,
+		FirstName:    c.FirstName,
+		LastName:     c.LastName,
+		Organization: c.Organization,
+		Department:   c.Department,
+		Title:        c.Title,
 	}, nil
 }
 
-func (m *MockedContactsRepository) DeleteByID(id int) error {
+func (m *MockedContactsRepository) Update(ctx context.Context, c Contact, version int) (*Contact, error) {
+	c.Version = version + 1
+	return &c, nil
+}
+
+func (m *MockedContactsRepository) DeleteByID(ctx context.Context, id int, version int) error {
 	return nil
 }
 
+func (m *MockedContactsRepository) FindDeleted(ctx context.Context) ([]*Contact, error) {
+	return []*Contact{}, nil
+}
+
+func (m *MockedContactsRepository) Restore(ctx context.Context, id int) error {
+	return nil
+}
+
+func (m *MockedContactsRepository) Purge(ctx context.Context, retention time.Duration) (int64, error) {
+	return 0, nil
+}
+
 var emailsList = []email.Email{
-	{ID: 1, ContactID: 1, Address: "inosuke@gmail.com"},
-	{ID: 2, ContactID: 1, Address: "pigassault@outlook.com"},
-	{ID: 3, ContactID: 2, Address: "tanjirou@gmail.com"},
+	{ID: 1, ContactID: 1, Address: "inosuke@gmail.com", Normalized: "inosuke@gmail.com", Label: email.LabelHome, IsPrimary: true},
+	{ID: 2, ContactID: 1, Address: "pigassault@outlook.com", Normalized: "pigassault@outlook.com", Label: "mountain"},
+	{ID: 3, ContactID: 2, Address: "tanjirou@gmail.com", Normalized: "tanjirou@gmail.com", Label: email.LabelWork, IsPrimary: true},
 }
 
 var phonesList = []phone.Phone{
-	{ID: 1, ContactID: 1, Number: "1122223333", Type: phone.PhoneTypeHome},
+	{ID: 1, ContactID: 1, Number: "1122223333", Type: phone.PhoneTypeHome, IsPrimary: true},
 	{ID: 2, ContactID: 1, Number: "33444445555", Type: phone.PhoneTypeMobile},
 	{ID: 3, ContactID: 1, Number: "+5511911112222", Type: phone.PhoneTypeWork},
 	{ID: 4, ContactID: 1, Number: "+5511933332222", Type: phone.PhoneTypeFax},
-	{ID: 5, ContactID: 2, Number: "11955554444", Type: phone.PhoneTypeMobile},
+	{ID: 5, ContactID: 2, Number: "11955554444", Type: phone.PhoneTypeMobile, IsPrimary: true},
 	{ID: 6, ContactID: 2, Number: "1122223333", Type: phone.PhoneTypeHome},
 }
 
+var addressesList = []address.Address{
+	{ID: 1, ContactID: 1, Street: "Av. Paulista, 1000", Locality: "São Paulo", Region: "SP", PostalCode: "01310-100", Country: "BR", Label: address.LabelHome},
+	{ID: 2, ContactID: 2, Locality: "Tokyo", Country: "JP", Label: address.LabelOther},
+}
+
 func filterEmailsByContactID(id int) []email.Email {
 	filteredEmails := make([]email.Email, 0)
 
@@ -80,23 +194,111 @@ func filterPhonesByContactID(id int) []phone.Phone {
 	return filteredPhones
 }
 
+func filterAddressesByContactID(id int) []address.Address {
+	filteredAddresses := make([]address.Address, 0)
+
+	for _, a := range addressesList {
+		if a.ContactID == id {
+			filteredAddresses = append(filteredAddresses, a)
+		}
+	}
+
+	return filteredAddresses
+}
+
 type MockedEmailRepository struct {
 	id int
+	// reassigned keeps the contact each email was moved to
+	reassigned map[int]int
+	// primary keeps the email each contact promoted, and deleted the deleted emails, both applied to the fixtures
+	primary map[int]int
+	deleted map[int]bool
 }
 
-func (m *MockedEmailRepository) FindByContactID(id int) ([]email.Email, error) {
-	return filterEmailsByContactID(id), nil
+func (m *MockedEmailRepository) FindByContactID(ctx context.Context, id int) ([]email.Email, error) {
+	emails := make([]email.Email, 0)
+
+	for _, e := range filterEmailsByContactID(id) {
+		if m.deleted[e.ID] {
+			continue
+		}
+
+		if p, ok := m.primary[id]; ok {
+			e.IsPrimary = e.ID == p
+		}
+
+		emails = append(emails, e)
+	}
+
+	return emails, nil
+}
+
+func (m *MockedEmailRepository) DeleteByContactID(ctx context.Context, contactID int) error {
+	return nil
+}
+
+func (m *MockedEmailRepository) FindByID(ctx context.Context, contactID int, id int) (*email.Email, error) {
+	for _, e := range filterEmailsByContactID(contactID) {
+		if e.ID == id {
+			return &e, nil
+		}
+	}
+
+	return nil, email.ErrEmailNotFound
+}
+
+func (m *MockedEmailRepository) Update(ctx context.Context, contactID int, id int, data email.CreateEmailData) (*email.Email, error) {
+	return &email.Email{ID: id, ContactID: contactID, Address: data.Address, Normalized: data.Normalized, Label: data.Label}, nil
+}
+
+func (m *MockedEmailRepository) DeleteByID(ctx context.Context, contactID int, id int) error {
+	if _, err := m.FindByID(ctx, contactID, id); err != nil {
+		return err
+	}
+
+	if m.deleted == nil {
+		m.deleted = make(map[int]bool)
+	}
+
+	m.deleted[id] = true
+	return nil
+}
+
+func (m *MockedEmailRepository) SetPrimary(ctx context.Context, contactID int, id int) error {
+	if m.primary == nil {
+		m.primary = make(map[int]int)
+	}
+
+	m.primary[contactID] = id
+	return nil
+}
+
+func (m *MockedEmailRepository) Reassign(ctx context.Context, id int, from int, to int) error {
+	if m.reassigned == nil {
+		m.reassigned = make(map[int]int)
+	}
+
+	// only the moves are kept, so the details never moved are assumed to be in from
+	if current, ok := m.reassigned[id]; ok && current != from {
+		return email.ErrEmailNotFound
+	}
+
+	m.reassigned[id] = to
+	return nil
 }
 
-func (m *MockedEmailRepository) Create(contactID int, emails ...string) ([]email.Email, error) {
+func (m *MockedEmailRepository) Create(ctx context.Context, contactID int, emails ...email.CreateEmailData) ([]email.Email, error) {
 	parsed := make([]email.Email, 0, len(emails))
 
 	for _, e := range emails {
 		m.id++
 		parsed = append(parsed, email.Email{
-			ID:        m.id,
-			Address:   e,
-			ContactID: contactID,
+			ID:         m.id,
+			Address:    e.Address,
+			Normalized: e.Normalized,
+			Label:      e.Label,
+			ContactID:  contactID,
+			IsPrimary:  e.IsPrimary,
 		})
 	}
 
@@ -105,13 +307,86 @@ func (m *MockedEmailRepository) Create(contactID int, emails ...string) ([]email
 
 type MockedPhoneRepository struct {
 	id int
+	// reassigned keeps the contact each phone was moved to
+	reassigned map[int]int
+	// primary keeps the phone each contact promoted, and deleted the deleted phones, both applied to the fixtures
+	primary map[int]int
+	deleted map[int]bool
+}
+
+func (pr *MockedPhoneRepository) FindByContactID(ctx context.Context, id int) ([]phone.Phone, error) {
+	phones := make([]phone.Phone, 0)
+
+	for _, p := range filterPhonesByContactID(id) {
+		if pr.deleted[p.ID] {
+			continue
+		}
+
+		if primary, ok := pr.primary[id]; ok {
+			p.IsPrimary = p.ID == primary
+		}
+
+		phones = append(phones, p)
+	}
+
+	return phones, nil
+}
+
+func (pr *MockedPhoneRepository) DeleteByContactID(ctx context.Context, contactID int) error {
+	return nil
+}
+
+func (pr *MockedPhoneRepository) FindByID(ctx context.Context, contactID int, id int) (*phone.Phone, error) {
+	for _, p := range filterPhonesByContactID(contactID) {
+		if p.ID == id {
+			return &p, nil
+		}
+	}
+
+	return nil, phone.ErrPhoneNotFound
+}
+
+func (pr *MockedPhoneRepository) Update(ctx context.Context, contactID int, id int, data phone.CreatePhoneData) (*phone.Phone, error) {
+	return &phone.Phone{ID: id, ContactID: contactID, Type: data.Type, Number: data.Number, E164: data.E164, Label: data.Label}, nil
+}
+
+func (pr *MockedPhoneRepository) DeleteByID(ctx context.Context, contactID int, id int) error {
+	if _, err := pr.FindByID(ctx, contactID, id); err != nil {
+		return err
+	}
+
+	if pr.deleted == nil {
+		pr.deleted = make(map[int]bool)
+	}
+
+	pr.deleted[id] = true
+	return nil
+}
+
+func (pr *MockedPhoneRepository) SetPrimary(ctx context.Context, contactID int, id int) error {
+	if pr.primary == nil {
+		pr.primary = make(map[int]int)
+	}
+
+	pr.primary[contactID] = id
+	return nil
 }
 
-func (pr *MockedPhoneRepository) FindByContactID(id int) ([]phone.Phone, error) {
-	return filterPhonesByContactID(id), nil
+func (pr *MockedPhoneRepository) Reassign(ctx context.Context, id int, from int, to int) error {
+	if pr.reassigned == nil {
+		pr.reassigned = make(map[int]int)
+	}
+
+	// only the moves are kept, so the details never moved are assumed to be in from
+	if current, ok := pr.reassigned[id]; ok && current != from {
+		return phone.ErrPhoneNotFound
+	}
+
+	pr.reassigned[id] = to
+	return nil
 }
 
-func (pr *MockedPhoneRepository) Create(contactID int, phones ...phone.CreatePhoneData) ([]phone.Phone, error) {
+func (pr *MockedPhoneRepository) Create(ctx context.Context, contactID int, phones ...phone.CreatePhoneData) ([]phone.Phone, error) {
 	parsed := make([]phone.Phone, 0, len(phones))
 
 	for _, p := range phones {
@@ -121,17 +396,239 @@ func (pr *MockedPhoneRepository) Create(contactID int, phones ...phone.CreatePho
 			ContactID: contactID,
 			Type:      p.Type,
 			Number:    p.Number,
+			E164:      p.E164,
+			Label:     p.Label,
+			IsPrimary: p.IsPrimary,
 		})
 	}
 
 	return parsed, nil
 }
 
+type MockedAddressRepository struct {
+	id int
+	// reassigned keeps the contact each address was moved to
+	reassigned map[int]int
+	// deleted keeps the deleted addresses, applied to the fixtures
+	deleted map[int]bool
+}
+
+func (ar *MockedAddressRepository) FindByContactID(ctx context.Context, id int) ([]address.Address, error) {
+	addresses := make([]address.Address, 0)
+
+	for _, a := range filterAddressesByContactID(id) {
+		if !ar.deleted[a.ID] {
+			addresses = append(addresses, a)
+		}
+	}
+
+	return addresses, nil
+}
+
+func (ar *MockedAddressRepository) FindByID(ctx context.Context, contactID int, id int) (*address.Address, error) {
+	for _, a := range filterAddressesByContactID(contactID) {
+		if a.ID == id {
+			return &a, nil
+		}
+	}
+
+	return nil, address.ErrAddressNotFound
+}
+
+func (ar *MockedAddressRepository) Create(ctx context.Context, contactID int, addresses ...address.CreateAddressData) ([]address.Address, error) {
+	created := make([]address.Address, 0, len(addresses))
+
+	for _, a := range addresses {
+		ar.id++
+		created = append(created, address.Address{
+			ID:         ar.id,
+			ContactID:  contactID,
+			Street:     a.Street,
+			Locality:   a.Locality,
+			Region:     a.Region,
+			PostalCode: a.PostalCode,
+			Country:    a.Country,
+			Label:      a.Label,
+		})
+	}
+
+	return created, nil
+}
+
+func (ar *MockedAddressRepository) Update(ctx context.Context, contactID int, id int, data address.CreateAddressData) (*address.Address, error) {
+	return &address.Address{ID: id, ContactID: contactID, Street: data.Street, Locality: data.Locality, Region: data.Region,
+		PostalCode: data.PostalCode, Country: data.Country, Label: data.Label}, nil
+}
+
+func (ar *MockedAddressRepository) DeleteByID(ctx context.Context, contactID int, id int) error {
+	if _, err := ar.FindByID(ctx, contactID, id); err != nil {
+		return err
+	}
+
+	if ar.deleted == nil {
+		ar.deleted = make(map[int]bool)
+	}
+
+	ar.deleted[id] = true
+	return nil
+}
+
+func (ar *MockedAddressRepository) DeleteByContactID(ctx context.Context, contactID int) error {
+	return nil
+}
+
+func (ar *MockedAddressRepository) Reassign(ctx context.Context, id int, from int, to int) error {
+	if ar.reassigned == nil {
+		ar.reassigned = make(map[int]int)
+	}
+
+	// only the moves are kept, so the details never moved are assumed to be in from
+	if current, ok := ar.reassigned[id]; ok && current != from {
+		return address.ErrAddressNotFound
+	}
+
+	ar.reassigned[id] = to
+	return nil
+}
+
+// MockedAuthorizer authorizes the actions allowed by its role. Without a role, everything is allowed
+type MockedAuthorizer struct {
+	role addressbook.Role
+}
+
+func (a *MockedAuthorizer) Authorize(ctx context.Context, p addressbook.Permission) error {
+	if a.role == "" || a.role.Allows(p) {
+		return nil
+	}
+
+	return addressbook.ErrForbidden
+}
+
+// MockedAuditor keeps the recorded entries in memory, or fails to record them when err is set
+type MockedAuditor struct {
+	entries []*audit.Entry
+	err     error
+}
+
+func (a *MockedAuditor) Record(ctx context.Context, action audit.Action, entity audit.Entity, entityID int, contactID int, before, after interface{}) error {
+	if a.err != nil {
+		return a.err
+	}
+
+	a.entries = append(a.entries, &audit.Entry{
+		ID:        int64(len(a.entries) + 1),
+		Action:    action,
+		Entity:    entity,
+		EntityID:  entityID,
+		ContactID: contactID,
+	})
+
+	return nil
+}
+
+func (a *MockedAuditor) History(ctx context.Context, contactID int) ([]*audit.Entry, error) {
+	history := make([]*audit.Entry, 0)
+
+	for _, e := range a.entries {
+		if e.ContactID == contactID {
+			history = append(history, e)
+		}
+	}
+
+	return history, nil
+}
+
+// MockedRevisionRepository keeps the revisions in memory
+type MockedRevisionRepository struct {
+	revisions []*Revision
+}
+
+func (m *MockedRevisionRepository) Create(ctx context.Context, r Revision) error {
+	m.revisions = append(m.revisions, &r)
+	return nil
+}
+
+func (m *MockedRevisionRepository) FindByContactID(ctx context.Context, contactID int) ([]*Revision, error) {
+	revisions := make([]*Revision, 0)
+
+	for _, r := range m.revisions {
+		if r.ContactID == contactID {
+			revisions = append(revisions, r)
+		}
+	}
+
+	return revisions, nil
+}
+
+func (m *MockedRevisionRepository) Find(ctx context.Context, contactID int, number int) (*Revision, error) {
+	for _, r := range m.revisions {
+		if r.ContactID == contactID && r.Number == number {
+			return r, nil
+		}
+	}
+
+	return nil, ErrRevisionNotFound
+}
+
+// MockedTransactor runs the functions without any transaction
+type MockedTransactor struct{}
+
+func (m *MockedTransactor) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// testParser parses the phone numbers without a country code as brazilian ones, like the ones of the fixtures
+var testParser = &phone.Parser{DefaultRegion: "BR"}
+
+// MockedMergeRepository keeps the merges in memory
+type MockedMergeRepository struct {
+	merges []*Merge
+}
+
+func (m *MockedMergeRepository) Create(ctx context.Context, merge Merge) (*Merge, error) {
+	merge.ID = len(m.merges) + 1
+	m.merges = append(m.merges, &merge)
+	return &merge, nil
+}
+
+func (m *MockedMergeRepository) FindByID(ctx context.Context, id int) (*Merge, error) {
+	if id < 1 || id > len(m.merges) {
+		return nil, ErrMergeNotFound
+	}
+
+	return m.merges[id-1], nil
+}
+
+func (m *MockedMergeRepository) MarkUndone(ctx context.Context, id int, at time.Time) error {
+	merge, err := m.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if merge.UndoneAt != nil {
+		return ErrMergeUndone
+	}
+
+	merge.UndoneAt = &at
+	return nil
+}
+
+// testEngine is a dedupe engine with the default configuration
+var testEngine = &dedupe.Engine{Threshold: 0.7, NameThreshold: 0.88, NameWeight: 0.75, EmailWeight: 0.9, PhoneWeight: 0.8}
+
 func ProvideContactMockedService() *Service {
 	return ProvideContactsService(
 		zap.NewNop(),
 		&MockedContactsRepository{},
 		&MockedEmailRepository{},
 		&MockedPhoneRepository{},
+		&MockedRevisionRepository{},
+		&MockedAuthorizer{},
+		&MockedAuditor{},
+		&MockedTransactor{},
+		testEngine,
+		&MockedMergeRepository{},
+		testParser,
+		&MockedAddressRepository{},
 	)
 }
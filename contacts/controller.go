@@ -1,10 +1,15 @@
 package contacts
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 
+	"github.com/LucasFrezarini/go-contacts/addressbook"
+	"github.com/LucasFrezarini/go-contacts/contacts/address"
+	"github.com/LucasFrezarini/go-contacts/contacts/email"
 	"github.com/LucasFrezarini/go-contacts/contacts/phone"
 	"github.com/google/wire"
 	"github.com/labstack/echo/v4"
@@ -26,31 +31,154 @@ func ProvideContactsController(s *Service, r Repository, logger *zap.Logger, ech
 	return &Controller{service: s, repository: r, logger: logger.Named("ContactsController"), echo: echo}
 }
 
+// scopedContext returns the request context targeting the address book provided in the
+// address_book query param. Without it, the request works on the principal's private contacts
+func scopedContext(c echo.Context) (context.Context, error) {
+	ctx := c.Request().Context()
+
+	param := c.QueryParam("address_book")
+	if param == "" {
+		return ctx, nil
+	}
+
+	id, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		c.JSON(400, map[string]interface{}{
+			"error": "malformed address_book",
+		})
+
+		return nil, err
+	}
+
+	return addressbook.WithBookID(ctx, int(id)), nil
+}
+
+// writeError writes the HTTP response matching the error returned by the service
+func (ct *Controller) writeError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, ErrVersionMismatch):
+		c.JSON(http.StatusPreconditionFailed, map[string]interface{}{"error": "the contact was changed since the version in If-Match"})
+	case errors.Is(err, email.ErrInvalidAddress), errors.Is(err, email.ErrDuplicateAddress), errors.Is(err, email.ErrInvalidLabel),
+		errors.Is(err, phone.ErrInvalidNumber), errors.Is(err, phone.ErrInvalidType), errors.Is(err, phone.ErrInvalidLabel):
+		c.JSON(http.StatusBadRequest, map[string]interface{}{"message": err.Error()})
+	case errors.Is(err, address.ErrInvalidAddress), errors.Is(err, address.ErrInvalidCountry), errors.Is(err, address.ErrInvalidLabel):
+		c.JSON(http.StatusBadRequest, map[string]interface{}{"message": err.Error()})
+	case errors.Is(err, ErrInvalidSort):
+		c.JSON(http.StatusBadRequest, map[string]interface{}{"message": err.Error()})
+	case errors.Is(err, ErrInvalidMerge):
+		c.JSON(http.StatusBadRequest, map[string]interface{}{"message": err.Error()})
+	case errors.Is(err, ErrMergeNotFound):
+		c.JSON(http.StatusNotFound, map[string]interface{}{"error": "merge not found"})
+	case errors.Is(err, ErrMergeUndone), errors.Is(err, ErrMergeOutdated):
+		c.JSON(http.StatusConflict, map[string]interface{}{"error": err.Error()})
+	case errors.Is(err, email.ErrEmailNotFound):
+		c.JSON(http.StatusNotFound, map[string]interface{}{"error": "email not found"})
+	case errors.Is(err, phone.ErrPhoneNotFound):
+		c.JSON(http.StatusNotFound, map[string]interface{}{"error": "phone not found"})
+	case errors.Is(err, address.ErrAddressNotFound):
+		c.JSON(http.StatusNotFound, map[string]interface{}{"error": "address not found"})
+	case errors.Is(err, ErrRevisionNotFound):
+		c.JSON(http.StatusNotFound, map[string]interface{}{"error": "revision not found"})
+	case errors.Is(err, ErrContactNotFound):
+		c.JSON(http.StatusNotFound, map[string]interface{}{"error": "contact not found"})
+	case errors.Is(err, addressbook.ErrAddressBookNotFound):
+		c.JSON(http.StatusNotFound, map[string]interface{}{"error": "address book not found"})
+	case errors.Is(err, addressbook.ErrForbidden):
+		c.JSON(http.StatusForbidden, map[string]interface{}{"error": "your role in this address book doesn't allow this action"})
+	default:
+		ct.logger.Error(fmt.Sprintf("%s %s internal server error: %v", c.Request().Method, c.Path(), err))
+		c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+	}
+
+	return err
+}
+
 // FindAll searches all the contacts that exists in the database and returns it
-// in a JSON response
+// in a JSON response. The contacts can be filtered by the label of their emails with the email_label query param,
+// parsed like the labels of the emails, so a label too long to exist is a bad request
 func (ct *Controller) FindAll(c echo.Context) error {
-	contacts, err := ct.service.FindAllContacts()
-
+	ctx, err := scopedContext(c)
 	if err != nil {
-		ct.logger.Error(fmt.Sprintf("GET / internal server error: %v", err))
-		c.NoContent(http.StatusInternalServerError)
 		return err
 	}
 
+	contacts, err := ct.service.FindAllContacts(ctx, Filter{
+		EmailLabel:   c.QueryParam("email_label"),
+		Organization: c.QueryParam("organization"),
+		Sort:         Sort(c.QueryParam("sort")),
+	})
+
+	if err != nil {
+		return ct.writeError(c, err)
+	}
+
 	var response = struct {
 		Contacts []*Contact `json:"contacts"`
 	}{contacts}
 
-	return c.JSON(http.StatusOK, response)
+	etag, err := bodyETag(response)
+	if err != nil {
+		return ct.writeError(c, err)
+	}
+
+	return writeWithETag(c, etag, response)
 }
 
-// Create creates a new contact with the info provided in the body
-func (ct *Controller) Create(c echo.Context) (err error) {
+// parseID parses the contact ID in the path, writing a bad request response when it's malformed
+func parseID(c echo.Context) (int, error) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	if err != nil {
+		c.JSON(400, map[string]interface{}{
+			"error": "malformed ID",
+		})
+
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// FindByID writes the contact with the ID in the path in a JSON response, along with its ETag
+func (ct *Controller) FindByID(c echo.Context) (err error) {
+	ctx, err := scopedContext(c)
+	if err != nil {
+		return
+	}
+
+	id, err := parseID(c)
+	if err != nil {
+		return
+	}
+
+	contact, err := ct.service.FindContactByID(ctx, id)
+
+	if err != nil {
+		return ct.writeError(c, err)
+	}
+
+	return writeWithETag(c, contact.ETag(), contact)
+}
+
+// Update replaces the names and the organization of the contact with the ID in the path. The contact is only
+// changed if it's still at the version in the If-Match header, which is required
+func (ct *Controller) Update(c echo.Context) (err error) {
 	type RequestBody struct {
-		FirstName string              `json:"first_name" validate:"required"`
-		LastName  string              `json:"last_name" validate:"required"`
-		Emails    []string            `json:"emails"`
-		Phones    []map[string]string `json:"phones"`
+		FirstName    string `json:"first_name" validate:"required"`
+		LastName     string `json:"last_name" validate:"required"`
+		Organization string `json:"organization" validate:"max=100"`
+		Department   string `json:"department" validate:"max=100"`
+		Title        string `json:"title" validate:"max=100"`
+	}
+
+	ctx, err := scopedContext(c)
+	if err != nil {
+		return
+	}
+
+	id, err := parseID(c)
+	if err != nil {
+		return
 	}
 
 	body := new(RequestBody)
@@ -66,52 +194,879 @@ func (ct *Controller) Create(c echo.Context) (err error) {
 		return
 	}
 
-	phonesData := make([]phone.CreatePhoneData, 0, len(body.Phones))
+	return ct.update(ctx, c, id, UpdateContactData{
+		FirstName:    &body.FirstName,
+		LastName:     &body.LastName,
+		Organization: &body.Organization,
+		Department:   &body.Department,
+		Title:        &body.Title,
+	})
+}
+
+// Patch changes the fields present in the body of the contact with the ID in the path. The organization,
+// department and title can be cleared with an empty string, unlike the names. The contact is only changed if it's
+// still at the version in the If-Match header, which is required
+func (ct *Controller) Patch(c echo.Context) (err error) {
+	type RequestBody struct {
+		FirstName    *string `json:"first_name"`
+		LastName     *string `json:"last_name"`
+		Organization *string `json:"organization" validate:"omitempty,max=100"`
+		Department   *string `json:"department" validate:"omitempty,max=100"`
+		Title        *string `json:"title" validate:"omitempty,max=100"`
+	}
+
+	ctx, err := scopedContext(c)
+	if err != nil {
+		return
+	}
+
+	id, err := parseID(c)
+	if err != nil {
+		return
+	}
+
+	body := new(RequestBody)
+	if err = c.Bind(body); err != nil {
+		return
+	}
+
+	for field, value := range map[string]*string{"first_name": body.FirstName, "last_name": body.LastName} {
+		if value != nil && *value == "" {
+			err = fmt.Errorf("%s can't be empty", field)
+			c.JSON(400, map[string]interface{}{
+				"message": err.Error(),
+			})
 
-	for _, p := range body.Phones {
-		phonesData = append(phonesData, phone.CreatePhoneData{
-			Number: p["number"],
-			Type:   p["type"],
+			return
+		}
+	}
+
+	if err = c.Validate(body); err != nil {
+		c.JSON(400, map[string]interface{}{
+			"message": err.Error(),
 		})
+
+		return
 	}
 
-	created, err := ct.service.Create(CreateContactData{
-		FirstName: body.FirstName,
-		LastName:  body.LastName,
-		Emails:    body.Emails,
-		Phones:    phonesData,
+	return ct.update(ctx, c, id, UpdateContactData{
+		FirstName:    body.FirstName,
+		LastName:     body.LastName,
+		Organization: body.Organization,
+		Department:   body.Department,
+		Title:        body.Title,
 	})
+}
 
+func (ct *Controller) update(ctx context.Context, c echo.Context, id int, d UpdateContactData) error {
+	version, err := ifMatchVersion(c)
 	if err != nil {
+		return err
+	}
+
+	updated, err := ct.service.UpdateContact(ctx, id, version, d)
+
+	if err != nil {
+		return ct.writeError(c, err)
+	}
+
+	c.Response().Header().Set(HeaderETag, updated.ETag())
+	return c.JSON(http.StatusOK, updated)
+}
+
+// Create creates a new contact with the info provided in the body
+func (ct *Controller) Create(c echo.Context) (err error) {
+	type RequestBody struct {
+		FirstName    string                      `json:"first_name" validate:"required"`
+		LastName     string                      `json:"last_name" validate:"required"`
+		Organization string                      `json:"organization" validate:"max=100"`
+		Department   string                      `json:"department" validate:"max=100"`
+		Title        string                      `json:"title" validate:"max=100"`
+		Emails       []email.Input               `json:"emails" validate:"dive"`
+		Phones       []phone.CreatePhoneData     `json:"phones" validate:"dive"`
+		Addresses    []address.CreateAddressData `json:"addresses" validate:"dive"`
+	}
+
+	ctx, err := scopedContext(c)
+	if err != nil {
+		return
+	}
+
+	body := new(RequestBody)
+	if err = c.Bind(body); err != nil {
 		return
 	}
 
+	if err = c.Validate(body); err != nil {
+		c.JSON(400, map[string]interface{}{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	created, err := ct.service.Create(ctx, CreateContactData{
+		FirstName:    body.FirstName,
+		LastName:     body.LastName,
+		Organization: body.Organization,
+		Department:   body.Department,
+		Title:        body.Title,
+		Emails:       body.Emails,
+		Phones:       body.Phones,
+		Addresses:    body.Addresses,
+	})
+
+	if err != nil {
+		return ct.writeError(c, err)
+	}
+
 	return c.JSON(http.StatusCreated, created)
 }
 
 func (ct *Controller) Delete(c echo.Context) (err error) {
-	param := c.Param("id")
-	id, err := strconv.ParseInt(param, 10, 64)
+	ctx, err := scopedContext(c)
+	if err != nil {
+		return
+	}
+
+	id, err := parseID(c)
+	if err != nil {
+		return
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return
+	}
+
+	err = ct.service.DeleteContactByID(ctx, id, version)
+
+	if err != nil {
+		return ct.writeError(c, err)
+	}
+
+	return c.NoContent(204)
+}
+
+// Trash lists the contacts in the trash in a JSON response
+func (ct *Controller) Trash(c echo.Context) error {
+	ctx, err := scopedContext(c)
+	if err != nil {
+		return err
+	}
+
+	contacts, err := ct.service.FindDeletedContacts(ctx)
+
+	if err != nil {
+		return ct.writeError(c, err)
+	}
+
+	var response = struct {
+		Contacts []*Contact `json:"contacts"`
+	}{contacts}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// Restore moves the contact with the ID in the path back from the trash
+func (ct *Controller) Restore(c echo.Context) (err error) {
+	ctx, err := scopedContext(c)
+	if err != nil {
+		return
+	}
+
+	id, err := parseID(c)
+	if err != nil {
+		return
+	}
+
+	err = ct.service.RestoreContactByID(ctx, id)
+
+	if err != nil {
+		return ct.writeError(c, err)
+	}
+
+	return c.NoContent(204)
+}
+
+// History lists the changes made to the contact with the ID in the path, from the oldest to the newest
+func (ct *Controller) History(c echo.Context) (err error) {
+	ctx, err := scopedContext(c)
+	if err != nil {
+		return
+	}
+
+	id, err := parseID(c)
+	if err != nil {
+		return
+	}
+
+	entries, err := ct.service.History(ctx, id)
+
+	if err != nil {
+		return ct.writeError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"history": entries})
+}
+
+// Revisions lists the revisions of the contact with the ID in the path, from the oldest to the newest
+func (ct *Controller) Revisions(c echo.Context) (err error) {
+	ctx, err := scopedContext(c)
+	if err != nil {
+		return
+	}
+
+	id, err := parseID(c)
+	if err != nil {
+		return
+	}
+
+	revisions, err := ct.service.Revisions(ctx, id)
 
 	if err != nil {
+		return ct.writeError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"revisions": revisions})
+}
+
+// parseRevision parses the revision number in the path, writing a bad request response when it's malformed
+func parseRevision(c echo.Context) (int, error) {
+	n, err := strconv.ParseInt(c.Param("n"), 10, 64)
+
+	if err != nil || n <= 0 {
 		c.JSON(400, map[string]interface{}{
-			"error": "malformed ID",
+			"error": "malformed revision",
+		})
+
+		return 0, fmt.Errorf("malformed revision %q", c.Param("n"))
+	}
+
+	return int(n), nil
+}
+
+// parseDetailID parses the ID of an email, phone or address in the path param, writing a bad request response
+// when it's malformed
+func parseDetailID(c echo.Context, param string, name string) (int, error) {
+	id, err := strconv.ParseInt(c.Param(param), 10, 64)
+
+	if err != nil {
+		c.JSON(400, map[string]interface{}{
+			"error": fmt.Sprintf("malformed %s ID", name),
+		})
+
+		return 0, fmt.Errorf("malformed %s ID %q", name, c.Param(param))
+	}
+
+	return int(id), nil
+}
+
+// A detailChange describes a request changing an email, a phone or an address of the contact with the ID in the path
+type detailChange struct {
+	// param and name identify the changed detail in the path, when it already exists
+	param string
+	name  string
+	// bind binds and validates the body, when the request has one
+	bind func() error
+	// status is the status of the response, written without content when change returns no detail
+	status int
+	// change makes the change, returning the changed detail along with the changed contact
+	change func(ctx context.Context, id int, detailID int, version int) (interface{}, *Contact, error)
+}
+
+// changeDetail parses the request of the detail change, makes it at the version in the If-Match header and writes
+// the changed detail along with the new ETag of the contact
+func (ct *Controller) changeDetail(c echo.Context, d detailChange) (err error) {
+	ctx, err := scopedContext(c)
+	if err != nil {
+		return
+	}
+
+	id, err := parseID(c)
+	if err != nil {
+		return
+	}
+
+	var detailID int
+	if d.param != "" {
+		if detailID, err = parseDetailID(c, d.param, d.name); err != nil {
+			return
+		}
+	}
+
+	if d.bind != nil {
+		if err = d.bind(); err != nil {
+			return
+		}
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return
+	}
+
+	detail, contact, err := d.change(ctx, id, detailID, version)
+
+	if err != nil {
+		return ct.writeError(c, err)
+	}
+
+	c.Response().Header().Set(HeaderETag, contact.ETag())
+	if detail == nil {
+		return c.NoContent(d.status)
+	}
+
+	return c.JSON(d.status, detail)
+}
+
+// Revision writes the contact with the ID in the path as it was at the revision in the path
+func (ct *Controller) Revision(c echo.Context) (err error) {
+	ctx, err := scopedContext(c)
+	if err != nil {
+		return
+	}
+
+	id, err := parseID(c)
+	if err != nil {
+		return
+	}
+
+	n, err := parseRevision(c)
+	if err != nil {
+		return
+	}
+
+	rev, err := ct.service.Revision(ctx, id, n)
+
+	if err != nil {
+		return ct.writeError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, rev)
+}
+
+// Revert restores the contact with the ID in the path as it was at the revision in the path. The contact is only
+// reverted if it's still at the version in the If-Match header, which is required
+func (ct *Controller) Revert(c echo.Context) (err error) {
+	ctx, err := scopedContext(c)
+	if err != nil {
+		return
+	}
+
+	id, err := parseID(c)
+	if err != nil {
+		return
+	}
+
+	n, err := parseRevision(c)
+	if err != nil {
+		return
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return
+	}
+
+	reverted, err := ct.service.RevertContact(ctx, id, n, version)
+
+	if err != nil {
+		return ct.writeError(c, err)
+	}
+
+	c.Response().Header().Set(HeaderETag, reverted.ETag())
+	return c.JSON(http.StatusOK, reverted)
+}
+
+// Duplicates lists the clusters of contacts that are likely to be duplicates. The threshold query param,
+// from 0 to 1, overrides the configured minimum score of the reported pairs
+func (ct *Controller) Duplicates(c echo.Context) (err error) {
+	ctx, err := scopedContext(c)
+	if err != nil {
+		return
+	}
+
+	var threshold float64
+	if param := c.QueryParam("threshold"); param != "" {
+		threshold, err = strconv.ParseFloat(param, 64)
+
+		if err != nil || threshold <= 0 || threshold > 1 {
+			c.JSON(400, map[string]interface{}{
+				"error": "threshold must be a number greater than 0 and up to 1",
+			})
+
+			return fmt.Errorf("malformed threshold %q", param)
+		}
+	}
+
+	clusters, err := ct.service.FindDuplicates(ctx, threshold)
+
+	if err != nil {
+		return ct.writeError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"clusters": clusters})
+}
+
+// Merge merges the source contacts of the body into the target one, writing the recorded merge and the merged
+// contact. The target is only changed if it's still at the version in the If-Match header, which is required
+func (ct *Controller) Merge(c echo.Context) (err error) {
+	type RequestBody struct {
+		TargetID  int                   `json:"target_id" validate:"required"`
+		SourceIDs []int                 `json:"source_ids" validate:"required,min=1"`
+		Strategy  Resolution            `json:"strategy"`
+		Fields    map[string]Resolution `json:"fields"`
+		Overrides map[string]string     `json:"overrides"`
+	}
+
+	ctx, err := scopedContext(c)
+	if err != nil {
+		return
+	}
+
+	body := new(RequestBody)
+	if err = c.Bind(body); err != nil {
+		return
+	}
+
+	if err = c.Validate(body); err != nil {
+		c.JSON(400, map[string]interface{}{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return
+	}
+
+	merge, merged, err := ct.service.MergeContacts(ctx, MergeContactsData{
+		TargetID:  body.TargetID,
+		SourceIDs: body.SourceIDs,
+		Strategy:  body.Strategy,
+		Fields:    body.Fields,
+		Overrides: body.Overrides,
+	}, version)
+
+	if err != nil {
+		return ct.writeError(c, err)
+	}
+
+	c.Response().Header().Set(HeaderETag, merged.ETag())
+	return c.JSON(http.StatusOK, map[string]interface{}{"merge": merge, "contact": merged})
+}
+
+// UndoMerge undoes the merge with the ID in the path, writing its target contact as it was before the merge.
+// The target is only reverted if it's still at the version in the If-Match header, which is required
+func (ct *Controller) UndoMerge(c echo.Context) (err error) {
+	ctx, err := scopedContext(c)
+	if err != nil {
+		return
+	}
+
+	id, err := parseID(c)
+	if err != nil {
+		return
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return
+	}
+
+	target, err := ct.service.UndoMerge(ctx, id, version)
+
+	if err != nil {
+		return ct.writeError(c, err)
+	}
+
+	c.Response().Header().Set(HeaderETag, target.ETag())
+	return c.JSON(http.StatusOK, target)
+}
+
+// Emails writes the emails of the contact with the ID in the path, along with the ETag of the contact
+func (ct *Controller) Emails(c echo.Context) (err error) {
+	ctx, err := scopedContext(c)
+	if err != nil {
+		return
+	}
+
+	id, err := parseID(c)
+	if err != nil {
+		return
+	}
+
+	contact, err := ct.service.FindContactByID(ctx, id)
+
+	if err != nil {
+		return ct.writeError(c, err)
+	}
+
+	return writeWithETag(c, contact.ETag(), map[string]interface{}{"emails": contact.Emails})
+}
+
+// Email writes the email with the ID in the path of the contact with the ID in the path, along with the ETag
+// of the contact
+func (ct *Controller) Email(c echo.Context) (err error) {
+	ctx, err := scopedContext(c)
+	if err != nil {
+		return
+	}
+
+	id, err := parseID(c)
+	if err != nil {
+		return
+	}
+
+	emailID, err := parseDetailID(c, "emailID", "email")
+	if err != nil {
+		return
+	}
+
+	e, contact, err := ct.service.FindEmail(ctx, id, emailID)
+
+	if err != nil {
+		return ct.writeError(c, err)
+	}
+
+	return writeWithETag(c, contact.ETag(), e)
+}
+
+// bindEmail binds and validates the email in the body, writing a bad request response when it's invalid
+func bindEmail(c echo.Context) (email.Input, error) {
+	var body email.Input
+	if err := c.Bind(&body); err != nil {
+		return body, err
+	}
+
+	if err := c.Validate(body); err != nil {
+		c.JSON(400, map[string]interface{}{
+			"message": err.Error(),
 		})
 
+		return body, err
+	}
+
+	return body, nil
+}
+
+// AddEmail adds the email in the body to the contact with the ID in the path, writing the created email along with
+// the new ETag of the contact. The contact is only changed if it's still at the version in the If-Match header,
+// which is required
+func (ct *Controller) AddEmail(c echo.Context) error {
+	var body email.Input
+
+	return ct.changeDetail(c, detailChange{
+		bind:   func() (err error) { body, err = bindEmail(c); return },
+		status: http.StatusCreated,
+		change: func(ctx context.Context, id int, _ int, version int) (interface{}, *Contact, error) {
+			created, contact, err := ct.service.AddEmail(ctx, id, body, version)
+			return created, contact, err
+		},
+	})
+}
+
+// UpdateEmail replaces the address of the email with the ID in the path, like AddEmail
+func (ct *Controller) UpdateEmail(c echo.Context) error {
+	var body email.Input
+
+	return ct.changeDetail(c, detailChange{
+		param:  "emailID",
+		name:   "email",
+		bind:   func() (err error) { body, err = bindEmail(c); return },
+		status: http.StatusOK,
+		change: func(ctx context.Context, id int, emailID int, version int) (interface{}, *Contact, error) {
+			updated, contact, err := ct.service.UpdateEmail(ctx, id, emailID, body, version)
+			return updated, contact, err
+		},
+	})
+}
+
+// DeleteEmail deletes the email with the ID in the path, writing the new ETag of the contact. The contact is only
+// changed if it's still at the version in the If-Match header, which is required
+func (ct *Controller) DeleteEmail(c echo.Context) error {
+	return ct.changeDetail(c, detailChange{
+		param:  "emailID",
+		name:   "email",
+		status: http.StatusNoContent,
+		change: func(ctx context.Context, id int, emailID int, version int) (interface{}, *Contact, error) {
+			contact, err := ct.service.DeleteEmail(ctx, id, emailID, version)
+			return nil, contact, err
+		},
+	})
+}
+
+// PromoteEmail makes the email with the ID in the path the primary one of the contact with the ID in the path,
+// writing the promoted email along with the new ETag of the contact. The contact is only changed if it's still at
+// the version in the If-Match header, which is required
+func (ct *Controller) PromoteEmail(c echo.Context) error {
+	return ct.changeDetail(c, detailChange{
+		param:  "emailID",
+		name:   "email",
+		status: http.StatusOK,
+		change: func(ctx context.Context, id int, emailID int, version int) (interface{}, *Contact, error) {
+			promoted, contact, err := ct.service.PromoteEmail(ctx, id, emailID, version)
+			return promoted, contact, err
+		},
+	})
+}
+
+// Phones writes the phones of the contact with the ID in the path, along with the ETag of the contact
+func (ct *Controller) Phones(c echo.Context) (err error) {
+	ctx, err := scopedContext(c)
+	if err != nil {
+		return
+	}
+
+	id, err := parseID(c)
+	if err != nil {
 		return
 	}
 
-	err = ct.service.DeleteContactByID(int(id))
+	contact, err := ct.service.FindContactByID(ctx, id)
+
+	if err != nil {
+		return ct.writeError(c, err)
+	}
+
+	return writeWithETag(c, contact.ETag(), map[string]interface{}{"phones": contact.Phones})
+}
+
+// Phone writes the phone with the ID in the path of the contact with the ID in the path, along with the ETag
+// of the contact
+func (ct *Controller) Phone(c echo.Context) (err error) {
+	ctx, err := scopedContext(c)
+	if err != nil {
+		return
+	}
 
+	id, err := parseID(c)
 	if err != nil {
-		c.JSON(500, map[string]interface{}{
-			"error": err.Error(),
+		return
+	}
+
+	phoneID, err := parseDetailID(c, "phoneID", "phone")
+	if err != nil {
+		return
+	}
+
+	p, contact, err := ct.service.FindPhone(ctx, id, phoneID)
+
+	if err != nil {
+		return ct.writeError(c, err)
+	}
+
+	return writeWithETag(c, contact.ETag(), p)
+}
+
+// bindPhone binds and validates the phone in the body, writing a bad request response when it's invalid
+func bindPhone(c echo.Context) (phone.CreatePhoneData, error) {
+	var body phone.CreatePhoneData
+	if err := c.Bind(&body); err != nil {
+		return body, err
+	}
+
+	if err := c.Validate(body); err != nil {
+		c.JSON(400, map[string]interface{}{
+			"message": err.Error(),
 		})
 
+		return body, err
+	}
+
+	return body, nil
+}
+
+// AddPhone adds the phone in the body to the contact with the ID in the path, writing the created phone along with
+// the new ETag of the contact. The contact is only changed if it's still at the version in the If-Match header,
+// which is required
+func (ct *Controller) AddPhone(c echo.Context) error {
+	var body phone.CreatePhoneData
+
+	return ct.changeDetail(c, detailChange{
+		bind:   func() (err error) { body, err = bindPhone(c); return },
+		status: http.StatusCreated,
+		change: func(ctx context.Context, id int, _ int, version int) (interface{}, *Contact, error) {
+			created, contact, err := ct.service.AddPhone(ctx, id, body, version)
+			return created, contact, err
+		},
+	})
+}
+
+// UpdatePhone replaces the phone with the ID in the path, like AddPhone
+func (ct *Controller) UpdatePhone(c echo.Context) error {
+	var body phone.CreatePhoneData
+
+	return ct.changeDetail(c, detailChange{
+		param:  "phoneID",
+		name:   "phone",
+		bind:   func() (err error) { body, err = bindPhone(c); return },
+		status: http.StatusOK,
+		change: func(ctx context.Context, id int, phoneID int, version int) (interface{}, *Contact, error) {
+			updated, contact, err := ct.service.UpdatePhone(ctx, id, phoneID, body, version)
+			return updated, contact, err
+		},
+	})
+}
+
+// DeletePhone deletes the phone with the ID in the path, writing the new ETag of the contact. The contact is only
+// changed if it's still at the version in the If-Match header, which is required
+func (ct *Controller) DeletePhone(c echo.Context) error {
+	return ct.changeDetail(c, detailChange{
+		param:  "phoneID",
+		name:   "phone",
+		status: http.StatusNoContent,
+		change: func(ctx context.Context, id int, phoneID int, version int) (interface{}, *Contact, error) {
+			contact, err := ct.service.DeletePhone(ctx, id, phoneID, version)
+			return nil, contact, err
+		},
+	})
+}
+
+// PromotePhone makes the phone with the ID in the path the primary one of the contact with the ID in the path,
+// writing the promoted phone along with the new ETag of the contact. The contact is only changed if it's still at
+// the version in the If-Match header, which is required
+func (ct *Controller) PromotePhone(c echo.Context) error {
+	return ct.changeDetail(c, detailChange{
+		param:  "phoneID",
+		name:   "phone",
+		status: http.StatusOK,
+		change: func(ctx context.Context, id int, phoneID int, version int) (interface{}, *Contact, error) {
+			promoted, contact, err := ct.service.PromotePhone(ctx, id, phoneID, version)
+			return promoted, contact, err
+		},
+	})
+}
+
+// Addresses writes the addresses of the contact with the ID in the path, along with the ETag of the contact
+func (ct *Controller) Addresses(c echo.Context) (err error) {
+	ctx, err := scopedContext(c)
+	if err != nil {
 		return
 	}
 
-	return c.NoContent(204)
+	id, err := parseID(c)
+	if err != nil {
+		return
+	}
+
+	contact, err := ct.service.FindContactByID(ctx, id)
+
+	if err != nil {
+		return ct.writeError(c, err)
+	}
+
+	return writeWithETag(c, contact.ETag(), map[string]interface{}{"addresses": contact.Addresses})
+}
+
+// Address writes the address with the ID in the path of the contact with the ID in the path, along with the ETag
+// of the contact
+func (ct *Controller) Address(c echo.Context) (err error) {
+	ctx, err := scopedContext(c)
+	if err != nil {
+		return
+	}
+
+	id, err := parseID(c)
+	if err != nil {
+		return
+	}
+
+	addressID, err := parseDetailID(c, "addressID", "address")
+	if err != nil {
+		return
+	}
+
+	a, contact, err := ct.service.FindAddress(ctx, id, addressID)
+
+	if err != nil {
+		return ct.writeError(c, err)
+	}
+
+	return writeWithETag(c, contact.ETag(), a)
+}
+
+// bindAddress binds and validates the address in the body, writing a bad request response when it's invalid
+func bindAddress(c echo.Context) (address.CreateAddressData, error) {
+	var body address.CreateAddressData
+	if err := c.Bind(&body); err != nil {
+		return body, err
+	}
+
+	if err := c.Validate(body); err != nil {
+		c.JSON(400, map[string]interface{}{
+			"message": err.Error(),
+		})
+
+		return body, err
+	}
+
+	return body, nil
+}
+
+// AddAddress adds the address in the body to the contact with the ID in the path, writing the created address
+// along with the new ETag of the contact. The contact is only changed if it's still at the version in the If-Match
+// header, which is required
+func (ct *Controller) AddAddress(c echo.Context) error {
+	var body address.CreateAddressData
+
+	return ct.changeDetail(c, detailChange{
+		bind:   func() (err error) { body, err = bindAddress(c); return },
+		status: http.StatusCreated,
+		change: func(ctx context.Context, id int, _ int, version int) (interface{}, *Contact, error) {
+			created, contact, err := ct.service.AddAddress(ctx, id, body, version)
+			return created, contact, err
+		},
+	})
+}
+
+// UpdateAddress replaces the address with the ID in the path, like AddAddress
+func (ct *Controller) UpdateAddress(c echo.Context) error {
+	var body address.CreateAddressData
+
+	return ct.changeDetail(c, detailChange{
+		param:  "addressID",
+		name:   "address",
+		bind:   func() (err error) { body, err = bindAddress(c); return },
+		status: http.StatusOK,
+		change: func(ctx context.Context, id int, addressID int, version int) (interface{}, *Contact, error) {
+			updated, contact, err := ct.service.UpdateAddress(ctx, id, addressID, body, version)
+			return updated, contact, err
+		},
+	})
+}
+
+// DeleteAddress deletes the address with the ID in the path, writing the new ETag of the contact. The contact is
+// only changed if it's still at the version in the If-Match header, which is required
+func (ct *Controller) DeleteAddress(c echo.Context) error {
+	return ct.changeDetail(c, detailChange{
+		param:  "addressID",
+		name:   "address",
+		status: http.StatusNoContent,
+		change: func(ctx context.Context, id int, addressID int, version int) (interface{}, *Contact, error) {
+			contact, err := ct.service.DeleteAddress(ctx, id, addressID, version)
+			return nil, contact, err
+		},
+	})
+}
+
+// Organizations writes the organizations of the contacts, along with how many contacts work for each of them
+func (ct *Controller) Organizations(c echo.Context) error {
+	ctx, err := scopedContext(c)
+	if err != nil {
+		return err
+	}
+
+	organizations, err := ct.service.FindOrganizations(ctx)
+
+	if err != nil {
+		return ct.writeError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"organizations": organizations})
 }
 
 // EchoGroup is responsible for building an echo group with all routes for this controller
@@ -121,7 +1076,39 @@ func (ct *Controller) EchoGroup() *echo.Group {
 	gp := ct.echo.Group("/contacts")
 	gp.GET("/", ct.FindAll)
 	gp.POST("/", ct.Create)
+	gp.GET("/trash", ct.Trash)
+	gp.GET("/duplicates", ct.Duplicates)
+	gp.POST("/merge", ct.Merge)
+	gp.POST("/merges/:id/undo", ct.UndoMerge)
+	gp.GET("/:id", ct.FindByID)
+	gp.PUT("/:id", ct.Update)
+	gp.PATCH("/:id", ct.Patch)
 	gp.DELETE("/:id", ct.Delete)
+	gp.POST("/:id/restore", ct.Restore)
+	gp.GET("/:id/history", ct.History)
+	gp.GET("/:id/revisions", ct.Revisions)
+	gp.GET("/:id/revisions/:n", ct.Revision)
+	gp.POST("/:id/revisions/:n/revert", ct.Revert)
+	gp.GET("/:id/emails", ct.Emails)
+	gp.POST("/:id/emails", ct.AddEmail)
+	gp.GET("/:id/emails/:emailID", ct.Email)
+	gp.PUT("/:id/emails/:emailID", ct.UpdateEmail)
+	gp.DELETE("/:id/emails/:emailID", ct.DeleteEmail)
+	gp.POST("/:id/emails/:emailID/primary", ct.PromoteEmail)
+	gp.GET("/:id/phones", ct.Phones)
+	gp.POST("/:id/phones", ct.AddPhone)
+	gp.GET("/:id/phones/:phoneID", ct.Phone)
+	gp.PUT("/:id/phones/:phoneID", ct.UpdatePhone)
+	gp.DELETE("/:id/phones/:phoneID", ct.DeletePhone)
+	gp.POST("/:id/phones/:phoneID/primary", ct.PromotePhone)
+	gp.GET("/:id/addresses", ct.Addresses)
+	gp.POST("/:id/addresses", ct.AddAddress)
+	gp.GET("/:id/addresses/:addressID", ct.Address)
+	gp.PUT("/:id/addresses/:addressID", ct.UpdateAddress)
+	gp.DELETE("/:id/addresses/:addressID", ct.DeleteAddress)
+
+	// the organizations are shared by the contacts, so they're listed outside of the group
+	ct.echo.GET("/organizations", ct.Organizations)
 
 	return gp
 }
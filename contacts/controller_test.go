@@ -6,12 +6,15 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
 
+	"github.com/LucasFrezarini/go-contacts/addressbook"
 	"github.com/LucasFrezarini/go-contacts/contacts/email"
 	"github.com/LucasFrezarini/go-contacts/contacts/phone"
 	"github.com/LucasFrezarini/go-contacts/server/validator"
+	"github.com/golang/mock/gomock"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
@@ -24,7 +27,7 @@ func TestGetAllContacts(t *testing.T) {
 	c := e.NewContext(req, rec)
 
 	controller := ProvideContactsController(
-		ProvideContactsService(zap.NewNop(), &MockedContactsRepository{}, &MockedEmailRepository{}, &MockedPhoneRepository{}),
+		ProvideContactsService(zap.NewNop(), &MockedContactsRepository{}, &MockedEmailRepository{}, &MockedPhoneRepository{}, &MockedRevisionRepository{}, &MockedAuthorizer{}, &MockedAuditor{}, &MockedTransactor{}, testEngine, &MockedMergeRepository{}, testParser, &MockedAddressRepository{}),
 		&MockedContactsRepository{},
 		zap.NewNop(),
 		e,
@@ -64,6 +67,141 @@ func TestGetAllContacts(t *testing.T) {
 	}
 }
 
+func TestGetAllContactsByEmailLabel(t *testing.T) {
+	var testCases = []struct {
+		testName string
+		query    string
+		status   int
+		ids      []int
+	}{
+		{"email_label", "/?email_label=work", http.StatusOK, []int{2}},
+		{"email_label_case", "/?email_label=+Work+", http.StatusOK, []int{2}},
+		{"long_email_label", "/?email_label=" + strings.Repeat("a", 51), http.StatusBadRequest, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, tc.query, nil)
+			rec := httptest.NewRecorder()
+
+			controller := ProvideContactsController(ProvideContactMockedService(), &MockedContactsRepository{}, zap.NewNop(), e)
+			_ = controller.FindAll(e.NewContext(req, rec))
+
+			if rec.Code != tc.status {
+				t.Fatalf("FindAll() %s wrote respose status %d, want %d: %s", tc.query, rec.Code, tc.status, rec.Body.String())
+			}
+
+			var response struct {
+				Contacts []*Contact `json:"contacts"`
+			}
+
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatalf("FindAll() error while unmarshaling response body: %v", err)
+			}
+
+			ids := make([]int, 0, len(response.Contacts))
+			for _, c := range response.Contacts {
+				ids = append(ids, c.ID)
+			}
+
+			if tc.ids != nil && !reflect.DeepEqual(ids, tc.ids) {
+				t.Errorf("FindAll() %s wrote the contacts %v, want %v", tc.query, ids, tc.ids)
+			}
+		})
+	}
+}
+
+func TestGetAllContactsByOrganization(t *testing.T) {
+	var testCases = []struct {
+		testName string
+		query    string
+		status   int
+		ids      []int
+	}{
+		{"organization", "/?organization=Demon+Slayer+Corps", http.StatusOK, []int{1}},
+		{"sort", "/?sort=organization", http.StatusOK, []int{1, 2}},
+		{"sort_desc", "/?sort=-organization", http.StatusOK, []int{2, 1}},
+		{"unknown_sort", "/?sort=title", http.StatusBadRequest, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, tc.query, nil)
+			rec := httptest.NewRecorder()
+
+			controller := ProvideContactsController(ProvideContactMockedService(), &MockedContactsRepository{}, zap.NewNop(), e)
+			_ = controller.FindAll(e.NewContext(req, rec))
+
+			if rec.Code != tc.status {
+				t.Fatalf("FindAll() %s wrote respose status %d, want %d: %s", tc.query, rec.Code, tc.status, rec.Body.String())
+			}
+
+			var response struct {
+				Contacts []*Contact `json:"contacts"`
+			}
+
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatalf("FindAll() error while unmarshaling response body: %v", err)
+			}
+
+			ids := make([]int, 0, len(response.Contacts))
+			for _, c := range response.Contacts {
+				ids = append(ids, c.ID)
+			}
+
+			if tc.ids != nil && !reflect.DeepEqual(ids, tc.ids) {
+				t.Errorf("FindAll() %s wrote the contacts %v, want %v", tc.query, ids, tc.ids)
+			}
+		})
+	}
+}
+
+func TestGetOrganizations(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/organizations", nil)
+	rec := httptest.NewRecorder()
+
+	controller := ProvideContactsController(ProvideContactMockedService(), &MockedContactsRepository{}, zap.NewNop(), e)
+
+	if err := controller.Organizations(e.NewContext(req, rec)); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("Organizations() = %v, wrote status %d, want %d: %s", err, rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	if expected, got := `{"organizations":[{"name":"Demon Slayer Corps","contacts":1},{"name":"Kamaboko Squad","contacts":1}]}`, strings.TrimSpace(rec.Body.String()); got != expected {
+		t.Errorf("Organizations() wrote %s, want %s", got, expected)
+	}
+}
+
+func TestCreateContactEmailLabels(t *testing.T) {
+	e := echo.New()
+	e.Validator = validator.NewCustomValidator()
+
+	body := `{"first_name": "Zenitsu", "last_name": "Agatsuma", "emails": ["zenitsu@gmail.com", {"address": "thunder@gmail.com", "label": "Work"}, {"address": "zen@gmail.com", "label": "Old University"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	controller := ProvideContactsController(ProvideContactMockedService(), &MockedContactsRepository{}, zap.NewNop(), e)
+
+	if err := controller.Create(e.NewContext(req, rec)); err != nil || rec.Code != http.StatusCreated {
+		t.Fatalf("Create() = %v, wrote status %d, want %d: %s", err, rec.Code, http.StatusCreated, rec.Body.String())
+	}
+
+	var created Contact
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Create() error while unmarshaling response body: %v", err)
+	}
+
+	expected := []string{email.LabelOther, email.LabelWork, "Old University"}
+	for i, e := range created.Emails {
+		if e.Label != expected[i] {
+			t.Errorf("Create() emails[%d].label = %q, want %q", i, e.Label, expected[i])
+		}
+	}
+}
+
 func TestCreateContactSuccess(t *testing.T) {
 	var testCases = []struct {
 		body map[string]interface{}
@@ -97,11 +235,11 @@ func TestCreateContactSuccess(t *testing.T) {
 					},
 					{
 						"type":   "fax",
-						"number": "5511666677777",
+						"number": "551133337777",
 					},
 					{
 						"type":   "work",
-						"number": "551188889999",
+						"number": "551144448888",
 					},
 				},
 			},
@@ -199,28 +337,109 @@ func TestCreateContactSuccess(t *testing.T) {
 func TestCreateContactBadRequest(t *testing.T) {
 	var testCases = []struct {
 		testName      string
-		body          map[string]string
+		body          map[string]interface{}
 		invalidFields []string
 	}{
 		{
 			"missing_last_name",
-			map[string]string{
+			map[string]interface{}{
 				"first_name": "Zenitsu",
 			},
 			[]string{"LastName"},
 		},
 		{
 			"missing_first_name",
-			map[string]string{
+			map[string]interface{}{
 				"last_name": "Agatsuma",
 			},
 			[]string{"FirstName"},
 		},
 		{
 			"missing_all_fields",
-			map[string]string{},
+			map[string]interface{}{},
 			[]string{"FirstName", "LastName"},
 		},
+		{
+			"invalid_email",
+			map[string]interface{}{
+				"first_name": "Zenitsu",
+				"last_name":  "Agatsuma",
+				"emails":     []string{"zenitsu@gmail.com", "Zenitsu <zenitsu@gmail>"},
+			},
+			[]string{"invalid email address", "Zenitsu <zenitsu@gmail>"},
+		},
+		{
+			"duplicate_email",
+			map[string]interface{}{
+				"first_name": "Zenitsu",
+				"last_name":  "Agatsuma",
+				"emails":     []string{"zenitsu@gmail.com", " Zenitsu@GMAIL.com"},
+			},
+			[]string{"duplicate email address"},
+		},
+		{
+			"invalid_phone",
+			map[string]interface{}{
+				"first_name": "Zenitsu",
+				"last_name":  "Agatsuma",
+				"phones":     []map[string]string{{"type": "mobile", "number": "123"}},
+			},
+			[]string{"invalid phone number", "123"},
+		},
+		{
+			"invalid_phone_type",
+			map[string]interface{}{
+				"first_name": "Zenitsu",
+				"last_name":  "Agatsuma",
+				"phones":     []map[string]string{{"type": "mobil", "number": "11955554444"}},
+			},
+			[]string{"Phones[0].Type", `"mobil" must be one of mobile, home, work, fax`},
+		},
+		{
+			"missing_email_address",
+			map[string]interface{}{
+				"first_name": "Zenitsu",
+				"last_name":  "Agatsuma",
+				"emails":     []map[string]string{{"label": "work"}},
+			},
+			[]string{"Emails[0].Address"},
+		},
+		{
+			"long_email_label",
+			map[string]interface{}{
+				"first_name": "Zenitsu",
+				"last_name":  "Agatsuma",
+				"emails":     []map[string]string{{"address": "zenitsu@gmail.com", "label": strings.Repeat("a", 51)}},
+			},
+			[]string{"Emails[0].Label"},
+		},
+		{
+			"missing_phone_number",
+			map[string]interface{}{
+				"first_name": "Zenitsu",
+				"last_name":  "Agatsuma",
+				"phones":     []map[string]string{{"type": "home"}},
+			},
+			[]string{"Phones[0].Number"},
+		},
+		{
+			"alpha_3_address_country",
+			map[string]interface{}{
+				"first_name": "Zenitsu",
+				"last_name":  "Agatsuma",
+				"addresses":  []map[string]string{{"locality": "Tokyo", "country": "JPN"}},
+			},
+			[]string{"Addresses[0].Country"},
+		},
+		{
+			"long_organization",
+			map[string]interface{}{
+				"first_name":   "Zenitsu",
+				"last_name":    "Agatsuma",
+				"organization": strings.Repeat("a", 101),
+			},
+			[]string{"Organization"},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -239,7 +458,7 @@ func TestCreateContactBadRequest(t *testing.T) {
 			rec := httptest.NewRecorder()
 			c := e.NewContext(req, rec)
 			controller := ProvideContactsController(
-				nil,
+				ProvideContactMockedService(),
 				&MockedContactsRepository{},
 				zap.NewNop(),
 				e,
@@ -281,6 +500,7 @@ func TestDeleteContactByID(t *testing.T) {
 	id := 2
 	e := echo.New()
 	req := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/%d", id), nil)
+	req.Header.Set(HeaderIfMatch, "*")
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
 	c.SetPath("/:id")
@@ -288,7 +508,7 @@ func TestDeleteContactByID(t *testing.T) {
 	c.SetParamValues("2")
 
 	controller := ProvideContactsController(
-		ProvideContactsService(zap.NewNop(), &MockedContactsRepository{}, &MockedEmailRepository{}, &MockedPhoneRepository{}),
+		ProvideContactsService(zap.NewNop(), &MockedContactsRepository{}, &MockedEmailRepository{}, &MockedPhoneRepository{}, &MockedRevisionRepository{}, &MockedAuthorizer{}, &MockedAuditor{}, &MockedTransactor{}, testEngine, &MockedMergeRepository{}, testParser, &MockedAddressRepository{}),
 		&MockedContactsRepository{},
 		zap.NewNop(),
 		e,
@@ -304,3 +524,584 @@ func TestDeleteContactByID(t *testing.T) {
 		t.Errorf("Delete wrote respose status %d, want %d", rec.Code, expected)
 	}
 }
+
+func TestDeleteContactByIDNotFound(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodDelete, "/3", nil)
+	req.Header.Set(HeaderIfMatch, "*")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/:id")
+	c.SetParamNames("id")
+	c.SetParamValues("3")
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repository := NewMockRepository(ctrl)
+	repository.EXPECT().FindByID(gomock.Any(), gomock.Eq(3)).Return(nil, fmt.Errorf("FindByID(3): %w", ErrContactNotFound))
+
+	controller := ProvideContactsController(
+		ProvideContactsService(zap.NewNop(), repository, &MockedEmailRepository{}, &MockedPhoneRepository{}, &MockedRevisionRepository{}, &MockedAuthorizer{}, &MockedAuditor{}, &MockedTransactor{}, testEngine, &MockedMergeRepository{}, testParser, &MockedAddressRepository{}),
+		repository,
+		zap.NewNop(),
+		e,
+	)
+
+	_ = controller.Delete(c)
+
+	if expected := http.StatusNotFound; rec.Code != expected {
+		t.Errorf("Delete wrote respose status %d, want %d", rec.Code, expected)
+	}
+}
+
+func TestViewerCannotWrite(t *testing.T) {
+	e := echo.New()
+	e.Validator = validator.NewCustomValidator()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// the repository must never be reached when the role doesn't allow the action
+	repository := NewMockRepository(ctrl)
+
+	controller := ProvideContactsController(
+		ProvideContactsService(zap.NewNop(), repository, &MockedEmailRepository{}, &MockedPhoneRepository{}, &MockedRevisionRepository{}, &MockedAuthorizer{role: addressbook.RoleViewer}, &MockedAuditor{}, &MockedTransactor{}, testEngine, &MockedMergeRepository{}, testParser, &MockedAddressRepository{}),
+		repository,
+		zap.NewNop(),
+		e,
+	)
+
+	body := `{"first_name": "Shinobu", "last_name": "Kocho"}`
+	req := httptest.NewRequest(http.MethodPost, "/?address_book=7", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	_ = controller.Create(e.NewContext(req, rec))
+
+	if expected := http.StatusForbidden; rec.Code != expected {
+		t.Errorf("Create wrote respose status %d for a viewer, want %d", rec.Code, expected)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/2?address_book=7", nil)
+	req.Header.Set(HeaderIfMatch, "*")
+	rec = httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/:id")
+	c.SetParamNames("id")
+	c.SetParamValues("2")
+
+	_ = controller.Delete(c)
+
+	if expected := http.StatusForbidden; rec.Code != expected {
+		t.Errorf("Delete wrote respose status %d for a viewer, want %d", rec.Code, expected)
+	}
+}
+
+func TestMalformedAddressBook(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/?address_book=abc", nil)
+	rec := httptest.NewRecorder()
+
+	controller := ProvideContactsController(ProvideContactMockedService(), &MockedContactsRepository{}, zap.NewNop(), e)
+
+	_ = controller.FindAll(e.NewContext(req, rec))
+
+	if expected := http.StatusBadRequest; rec.Code != expected {
+		t.Errorf("FindAll wrote respose status %d, want %d", rec.Code, expected)
+	}
+}
+
+func TestTrash(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/trash", nil)
+	rec := httptest.NewRecorder()
+
+	controller := ProvideContactsController(ProvideContactMockedService(), &MockedContactsRepository{}, zap.NewNop(), e)
+
+	if err := controller.Trash(e.NewContext(req, rec)); err != nil {
+		t.Errorf("controller Trash() returned an error: %v", err)
+	}
+
+	if expected := http.StatusOK; rec.Code != expected {
+		t.Errorf("Trash wrote respose status %d, want %d", rec.Code, expected)
+	}
+
+	if expected, got := `{"contacts":[]}`, strings.TrimSpace(rec.Body.String()); got != expected {
+		t.Errorf("Trash wrote body %s, want %s", got, expected)
+	}
+}
+
+func TestRestoreContact(t *testing.T) {
+	var testCases = []struct {
+		testName string
+		err      error
+		status   int
+	}{
+		{"restored", nil, http.StatusNoContent},
+		{"not_in_trash", fmt.Errorf("restore: %w", ErrContactNotFound), http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/4/restore", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetPath("/:id/restore")
+			c.SetParamNames("id")
+			c.SetParamValues("4")
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repository := NewMockRepository(ctrl)
+			repository.EXPECT().Restore(gomock.Any(), gomock.Eq(4)).Return(tc.err)
+			if tc.err == nil {
+				repository.EXPECT().FindByID(gomock.Any(), gomock.Eq(4)).Return(&Contact{ID: 4}, nil)
+			}
+
+			controller := ProvideContactsController(
+				ProvideContactsService(zap.NewNop(), repository, &MockedEmailRepository{}, &MockedPhoneRepository{}, &MockedRevisionRepository{}, &MockedAuthorizer{}, &MockedAuditor{}, &MockedTransactor{}, testEngine, &MockedMergeRepository{}, testParser, &MockedAddressRepository{}),
+				repository,
+				zap.NewNop(),
+				e,
+			)
+
+			_ = controller.Restore(c)
+
+			if rec.Code != tc.status {
+				t.Errorf("Restore wrote respose status %d, want %d", rec.Code, tc.status)
+			}
+		})
+	}
+}
+
+func TestContactHistory(t *testing.T) {
+	var testCases = []struct {
+		testName string
+		id       string
+		status   int
+	}{
+		{"found", "2", http.StatusOK},
+		{"not_found", "42", http.StatusNotFound},
+		{"malformed_id", "abc", http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/"+tc.id+"/history", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetPath("/:id/history")
+			c.SetParamNames("id")
+			c.SetParamValues(tc.id)
+
+			controller := ProvideContactsController(ProvideContactMockedService(), &MockedContactsRepository{}, zap.NewNop(), e)
+
+			_ = controller.History(c)
+
+			if rec.Code != tc.status {
+				t.Errorf("History wrote respose status %d, want %d", rec.Code, tc.status)
+			}
+
+			if tc.status == http.StatusOK {
+				if expected, got := `{"history":[]}`, strings.TrimSpace(rec.Body.String()); got != expected {
+					t.Errorf("History wrote body %s, want %s", got, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestFindContactByIDConditional(t *testing.T) {
+	var testCases = []struct {
+		testName    string
+		ifNoneMatch string
+		status      int
+	}{
+		{"no_condition", "", http.StatusOK},
+		{"current_version", `"1"`, http.StatusNotModified},
+		{"weak_current_version", `W/"1"`, http.StatusNotModified},
+		{"old_version", `"7", "8"`, http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/1", nil)
+			if tc.ifNoneMatch != "" {
+				req.Header.Set(HeaderIfNoneMatch, tc.ifNoneMatch)
+			}
+
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetPath("/:id")
+			c.SetParamNames("id")
+			c.SetParamValues("1")
+
+			controller := ProvideContactsController(ProvideContactMockedService(), &MockedContactsRepository{}, zap.NewNop(), e)
+
+			if err := controller.FindByID(c); err != nil {
+				t.Errorf("controller FindByID() returned an error: %v", err)
+			}
+
+			if rec.Code != tc.status {
+				t.Errorf("FindByID wrote respose status %d, want %d", rec.Code, tc.status)
+			}
+
+			if expected, got := `"1"`, rec.Header().Get(HeaderETag); got != expected {
+				t.Errorf("FindByID wrote ETag %s, want %s", got, expected)
+			}
+		})
+	}
+}
+
+func TestFindAllNotModified(t *testing.T) {
+	e := echo.New()
+	controller := ProvideContactsController(ProvideContactMockedService(), &MockedContactsRepository{}, zap.NewNop(), e)
+
+	rec := httptest.NewRecorder()
+	_ = controller.FindAll(e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec))
+
+	etag := rec.Header().Get(HeaderETag)
+	if !strings.HasPrefix(etag, `W/"`) {
+		t.Fatalf("FindAll wrote ETag %q, want a weak entity tag", etag)
+	}
+
+	if !strings.Contains(rec.Body.String(), `"etag":"\"1\""`) {
+		t.Errorf("FindAll wrote body %s, want the ETag of each contact", rec.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderIfNoneMatch, etag)
+	rec = httptest.NewRecorder()
+	_ = controller.FindAll(e.NewContext(req, rec))
+
+	if expected := http.StatusNotModified; rec.Code != expected {
+		t.Errorf("FindAll with a matching If-None-Match wrote respose status %d, want %d", rec.Code, expected)
+	}
+}
+
+func TestContactMarshalJSON(t *testing.T) {
+	// the entity tag is encoded whether the contact is addressable or not
+	for _, v := range []interface{}{Contact{ID: 1, Version: 3}, &Contact{ID: 1, Version: 3}, []Contact{{ID: 1, Version: 3}}} {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("json.Marshal(%T) returned an error: %v", v, err)
+		}
+
+		if !strings.Contains(string(b), `"etag":"\"3\""`) {
+			t.Errorf("json.Marshal(%T) == %s, want the ETag of the contact", v, b)
+		}
+	}
+}
+
+func TestUpdateContactIfMatch(t *testing.T) {
+	var testCases = []struct {
+		testName string
+		method   string
+		body     string
+		ifMatch  string
+		status   int
+	}{
+		{"put_any_version", http.MethodPut, `{"first_name": "Inosuke", "last_name": "Boar"}`, "*", http.StatusOK},
+		{"put_missing_if_match", http.MethodPut, `{"first_name": "Inosuke", "last_name": "Boar"}`, "", http.StatusPreconditionRequired},
+		{"put_current_version", http.MethodPut, `{"first_name": "Inosuke", "last_name": "Boar"}`, `"1"`, http.StatusOK},
+		{"put_old_version", http.MethodPut, `{"first_name": "Inosuke", "last_name": "Boar"}`, `"3"`, http.StatusPreconditionFailed},
+		{"put_weak_tag", http.MethodPut, `{"first_name": "Inosuke", "last_name": "Boar"}`, `W/"1"`, http.StatusPreconditionFailed},
+		{"put_missing_name", http.MethodPut, `{"first_name": "Inosuke"}`, "", http.StatusBadRequest},
+		{"patch", http.MethodPatch, `{"last_name": "Boar"}`, "*", http.StatusOK},
+		{"patch_empty_name", http.MethodPatch, `{"last_name": ""}`, "", http.StatusBadRequest},
+		{"put_organization", http.MethodPut, `{"first_name": "Inosuke", "last_name": "Boar", "organization": "Demon Slayer Corps", "title": "Hashira"}`, `"1"`, http.StatusOK},
+		{"put_long_title", http.MethodPut, `{"first_name": "Inosuke", "last_name": "Boar", "title": "` + strings.Repeat("a", 101) + `"}`, "", http.StatusBadRequest},
+		{"patch_clear_organization", http.MethodPatch, `{"last_name": "Boar", "organization": ""}`, `"1"`, http.StatusOK},
+		{"patch_long_department", http.MethodPatch, `{"department": "` + strings.Repeat("é", 101) + `"}`, "", http.StatusBadRequest},
+		{"delete_old_version", http.MethodDelete, "", `"3"`, http.StatusPreconditionFailed},
+		{"delete_current_version", http.MethodDelete, "", `"1"`, http.StatusNoContent},
+		{"delete_missing_if_match", http.MethodDelete, "", "", http.StatusPreconditionRequired},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			e := echo.New()
+			e.Validator = validator.NewCustomValidator()
+
+			req := httptest.NewRequest(tc.method, "/1", strings.NewReader(tc.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			if tc.ifMatch != "" {
+				req.Header.Set(HeaderIfMatch, tc.ifMatch)
+			}
+
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetPath("/:id")
+			c.SetParamNames("id")
+			c.SetParamValues("1")
+
+			controller := ProvideContactsController(ProvideContactMockedService(), &MockedContactsRepository{}, zap.NewNop(), e)
+
+			switch tc.method {
+			case http.MethodPut:
+				_ = controller.Update(c)
+			case http.MethodPatch:
+				_ = controller.Patch(c)
+			case http.MethodDelete:
+				_ = controller.Delete(c)
+			}
+
+			if rec.Code != tc.status {
+				t.Errorf("%s wrote respose status %d, want %d", tc.method, rec.Code, tc.status)
+			}
+
+			if rec.Code == http.StatusOK {
+				if expected, got := `"2"`, rec.Header().Get(HeaderETag); got != expected {
+					t.Errorf("%s wrote ETag %s, want %s", tc.method, got, expected)
+				}
+
+				if !strings.Contains(rec.Body.String(), `"last_name":"Boar"`) {
+					t.Errorf("%s wrote body %s, want the updated contact", tc.method, rec.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestContactRevision(t *testing.T) {
+	var testCases = []struct {
+		testName string
+		n        string
+		status   int
+	}{
+		{"missing_revision", "3", http.StatusNotFound},
+		{"malformed_revision", "first", http.StatusBadRequest},
+		{"zero_revision", "0", http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/1/revisions/"+tc.n, nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetPath("/:id/revisions/:n")
+			c.SetParamNames("id", "n")
+			c.SetParamValues("1", tc.n)
+
+			controller := ProvideContactsController(ProvideContactMockedService(), &MockedContactsRepository{}, zap.NewNop(), e)
+
+			_ = controller.Revision(c)
+
+			if rec.Code != tc.status {
+				t.Errorf("Revision wrote respose status %d, want %d", rec.Code, tc.status)
+			}
+		})
+	}
+}
+
+func TestDuplicates(t *testing.T) {
+	var testCases = []struct {
+		testName string
+		query    string
+		status   int
+		clusters int
+	}{
+		{"configured_threshold", "", http.StatusOK, 1},
+		{"higher_threshold", "?threshold=0.95", http.StatusOK, 0},
+		{"malformed_threshold", "?threshold=high", http.StatusBadRequest, 0},
+		{"out_of_range_threshold", "?threshold=2", http.StatusBadRequest, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/duplicates"+tc.query, nil)
+			rec := httptest.NewRecorder()
+
+			controller := ProvideContactsController(ProvideContactMockedService(), &MockedContactsRepository{}, zap.NewNop(), e)
+
+			_ = controller.Duplicates(e.NewContext(req, rec))
+
+			if rec.Code != tc.status {
+				t.Fatalf("Duplicates wrote respose status %d, want %d", rec.Code, tc.status)
+			}
+
+			if tc.status != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Clusters []*DuplicateCluster `json:"clusters"`
+			}
+
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatalf("controller Duplicates() error while unmarshaling response body: %v", err)
+			}
+
+			if len(response.Clusters) != tc.clusters {
+				t.Errorf("Duplicates wrote %d clusters, want %d", len(response.Clusters), tc.clusters)
+			}
+		})
+	}
+}
+
+func TestEmailsEndpoints(t *testing.T) {
+	var testCases = []struct {
+		testName string
+		method   string
+		path     string
+		emailID  string
+		body     string
+		handler  func(ct *Controller) echo.HandlerFunc
+		status   int
+	}{
+		{"list", http.MethodGet, "/:id/emails", "", "", func(ct *Controller) echo.HandlerFunc { return ct.Emails }, http.StatusOK},
+		{"find", http.MethodGet, "/:id/emails/:emailID", "2", "", func(ct *Controller) echo.HandlerFunc { return ct.Email }, http.StatusOK},
+		{"find_other_contact", http.MethodGet, "/:id/emails/:emailID", "3", "", func(ct *Controller) echo.HandlerFunc { return ct.Email }, http.StatusNotFound},
+		{"find_malformed_id", http.MethodGet, "/:id/emails/:emailID", "abc", "", func(ct *Controller) echo.HandlerFunc { return ct.Email }, http.StatusBadRequest},
+		{"add", http.MethodPost, "/:id/emails", "", `{"address": "boar@gmail.com"}`, func(ct *Controller) echo.HandlerFunc { return ct.AddEmail }, http.StatusCreated},
+		{"add_missing_address", http.MethodPost, "/:id/emails", "", `{}`, func(ct *Controller) echo.HandlerFunc { return ct.AddEmail }, http.StatusBadRequest},
+		{"add_invalid", http.MethodPost, "/:id/emails", "", `{"address": "boar"}`, func(ct *Controller) echo.HandlerFunc { return ct.AddEmail }, http.StatusBadRequest},
+		{"add_labeled", http.MethodPost, "/:id/emails", "", `{"address": "boar@gmail.com", "label": "mountain"}`, func(ct *Controller) echo.HandlerFunc { return ct.AddEmail }, http.StatusCreated},
+		{"update", http.MethodPut, "/:id/emails/:emailID", "2", `{"address": "boar@gmail.com"}`, func(ct *Controller) echo.HandlerFunc { return ct.UpdateEmail }, http.StatusOK},
+		{"update_other_contact", http.MethodPut, "/:id/emails/:emailID", "3", `{"address": "boar@gmail.com"}`, func(ct *Controller) echo.HandlerFunc { return ct.UpdateEmail }, http.StatusNotFound},
+		{"delete", http.MethodDelete, "/:id/emails/:emailID", "2", "", func(ct *Controller) echo.HandlerFunc { return ct.DeleteEmail }, http.StatusNoContent},
+		{"delete_other_contact", http.MethodDelete, "/:id/emails/:emailID", "3", "", func(ct *Controller) echo.HandlerFunc { return ct.DeleteEmail }, http.StatusNotFound},
+		{"promote", http.MethodPost, "/:id/emails/:emailID/primary", "2", "", func(ct *Controller) echo.HandlerFunc { return ct.PromoteEmail }, http.StatusOK},
+		{"promote_other_contact", http.MethodPost, "/:id/emails/:emailID/primary", "3", "", func(ct *Controller) echo.HandlerFunc { return ct.PromoteEmail }, http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			e := echo.New()
+			e.Validator = validator.NewCustomValidator()
+			req := httptest.NewRequest(tc.method, "/", strings.NewReader(tc.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			req.Header.Set(HeaderIfMatch, "*")
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetPath(tc.path)
+			c.SetParamNames("id", "emailID")
+			c.SetParamValues("1", tc.emailID)
+
+			controller := ProvideContactsController(ProvideContactMockedService(), &MockedContactsRepository{}, zap.NewNop(), e)
+
+			_ = tc.handler(controller)(c)
+
+			if rec.Code != tc.status {
+				t.Errorf("%s %s wrote respose status %d, want %d: %s", tc.method, tc.path, rec.Code, tc.status, rec.Body.String())
+			}
+
+			if rec.Code < 300 && rec.Header().Get(HeaderETag) == "" {
+				t.Errorf("%s %s didn't write the ETag of the contact", tc.method, tc.path)
+			}
+		})
+	}
+}
+
+func TestPhonesEndpoints(t *testing.T) {
+	var testCases = []struct {
+		testName string
+		method   string
+		path     string
+		phoneID  string
+		body     string
+		handler  func(ct *Controller) echo.HandlerFunc
+		status   int
+	}{
+		{"list", http.MethodGet, "/:id/phones", "", "", func(ct *Controller) echo.HandlerFunc { return ct.Phones }, http.StatusOK},
+		{"find", http.MethodGet, "/:id/phones/:phoneID", "2", "", func(ct *Controller) echo.HandlerFunc { return ct.Phone }, http.StatusOK},
+		{"find_other_contact", http.MethodGet, "/:id/phones/:phoneID", "5", "", func(ct *Controller) echo.HandlerFunc { return ct.Phone }, http.StatusNotFound},
+		{"find_malformed_id", http.MethodGet, "/:id/phones/:phoneID", "abc", "", func(ct *Controller) echo.HandlerFunc { return ct.Phone }, http.StatusBadRequest},
+		{"add", http.MethodPost, "/:id/phones", "", `{"number": "11988887777", "type": "work"}`, func(ct *Controller) echo.HandlerFunc { return ct.AddPhone }, http.StatusCreated},
+		{"add_missing_number", http.MethodPost, "/:id/phones", "", `{}`, func(ct *Controller) echo.HandlerFunc { return ct.AddPhone }, http.StatusBadRequest},
+		{"add_invalid_type", http.MethodPost, "/:id/phones", "", `{"number": "11988887777", "type": "pager"}`, func(ct *Controller) echo.HandlerFunc { return ct.AddPhone }, http.StatusBadRequest},
+		{"add_invalid_number", http.MethodPost, "/:id/phones", "", `{"number": "123"}`, func(ct *Controller) echo.HandlerFunc { return ct.AddPhone }, http.StatusBadRequest},
+		{"update", http.MethodPut, "/:id/phones/:phoneID", "2", `{"number": "11988887777"}`, func(ct *Controller) echo.HandlerFunc { return ct.UpdatePhone }, http.StatusOK},
+		{"update_other_contact", http.MethodPut, "/:id/phones/:phoneID", "5", `{"number": "11988887777"}`, func(ct *Controller) echo.HandlerFunc { return ct.UpdatePhone }, http.StatusNotFound},
+		{"delete", http.MethodDelete, "/:id/phones/:phoneID", "2", "", func(ct *Controller) echo.HandlerFunc { return ct.DeletePhone }, http.StatusNoContent},
+		{"delete_other_contact", http.MethodDelete, "/:id/phones/:phoneID", "5", "", func(ct *Controller) echo.HandlerFunc { return ct.DeletePhone }, http.StatusNotFound},
+		{"promote", http.MethodPost, "/:id/phones/:phoneID/primary", "2", "", func(ct *Controller) echo.HandlerFunc { return ct.PromotePhone }, http.StatusOK},
+		{"promote_other_contact", http.MethodPost, "/:id/phones/:phoneID/primary", "5", "", func(ct *Controller) echo.HandlerFunc { return ct.PromotePhone }, http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			e := echo.New()
+			e.Validator = validator.NewCustomValidator()
+			req := httptest.NewRequest(tc.method, "/", strings.NewReader(tc.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			req.Header.Set(HeaderIfMatch, "*")
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetPath(tc.path)
+			c.SetParamNames("id", "phoneID")
+			c.SetParamValues("1", tc.phoneID)
+
+			controller := ProvideContactsController(ProvideContactMockedService(), &MockedContactsRepository{}, zap.NewNop(), e)
+
+			_ = tc.handler(controller)(c)
+
+			if rec.Code != tc.status {
+				t.Errorf("%s %s wrote respose status %d, want %d: %s", tc.method, tc.path, rec.Code, tc.status, rec.Body.String())
+			}
+
+			if rec.Code < 300 && rec.Header().Get(HeaderETag) == "" {
+				t.Errorf("%s %s didn't write the ETag of the contact", tc.method, tc.path)
+			}
+		})
+	}
+}
+
+func TestAddressesEndpoints(t *testing.T) {
+	var testCases = []struct {
+		testName  string
+		method    string
+		path      string
+		addressID string
+		body      string
+		handler   func(ct *Controller) echo.HandlerFunc
+		status    int
+	}{
+		{"list", http.MethodGet, "/:id/addresses", "", "", func(ct *Controller) echo.HandlerFunc { return ct.Addresses }, http.StatusOK},
+		{"find", http.MethodGet, "/:id/addresses/:addressID", "1", "", func(ct *Controller) echo.HandlerFunc { return ct.Address }, http.StatusOK},
+		{"find_other_contact", http.MethodGet, "/:id/addresses/:addressID", "2", "", func(ct *Controller) echo.HandlerFunc { return ct.Address }, http.StatusNotFound},
+		{"find_malformed_id", http.MethodGet, "/:id/addresses/:addressID", "abc", "", func(ct *Controller) echo.HandlerFunc { return ct.Address }, http.StatusBadRequest},
+		{"add", http.MethodPost, "/:id/addresses", "", `{"locality": "Tokyo", "country": "JP", "label": "work"}`, func(ct *Controller) echo.HandlerFunc { return ct.AddAddress }, http.StatusCreated},
+		{"add_empty", http.MethodPost, "/:id/addresses", "", `{"country": "JP"}`, func(ct *Controller) echo.HandlerFunc { return ct.AddAddress }, http.StatusBadRequest},
+		{"add_alpha_3_country", http.MethodPost, "/:id/addresses", "", `{"locality": "Tokyo", "country": "JPN"}`, func(ct *Controller) echo.HandlerFunc { return ct.AddAddress }, http.StatusBadRequest},
+		{"add_unknown_country", http.MethodPost, "/:id/addresses", "", `{"locality": "Tokyo", "country": "ZZ"}`, func(ct *Controller) echo.HandlerFunc { return ct.AddAddress }, http.StatusBadRequest},
+		{"update", http.MethodPut, "/:id/addresses/:addressID", "1", `{"postal_code": "01310-100"}`, func(ct *Controller) echo.HandlerFunc { return ct.UpdateAddress }, http.StatusOK},
+		{"update_other_contact", http.MethodPut, "/:id/addresses/:addressID", "2", `{"postal_code": "01310-100"}`, func(ct *Controller) echo.HandlerFunc { return ct.UpdateAddress }, http.StatusNotFound},
+		{"delete", http.MethodDelete, "/:id/addresses/:addressID", "1", "", func(ct *Controller) echo.HandlerFunc { return ct.DeleteAddress }, http.StatusNoContent},
+		{"delete_other_contact", http.MethodDelete, "/:id/addresses/:addressID", "2", "", func(ct *Controller) echo.HandlerFunc { return ct.DeleteAddress }, http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			e := echo.New()
+			e.Validator = validator.NewCustomValidator()
+			req := httptest.NewRequest(tc.method, "/", strings.NewReader(tc.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			req.Header.Set(HeaderIfMatch, "*")
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetPath(tc.path)
+			c.SetParamNames("id", "addressID")
+			c.SetParamValues("1", tc.addressID)
+
+			controller := ProvideContactsController(ProvideContactMockedService(), &MockedContactsRepository{}, zap.NewNop(), e)
+
+			_ = tc.handler(controller)(c)
+
+			if rec.Code != tc.status {
+				t.Errorf("%s %s wrote respose status %d, want %d: %s", tc.method, tc.path, rec.Code, tc.status, rec.Body.String())
+			}
+
+			if rec.Code < 300 && rec.Header().Get(HeaderETag) == "" {
+				t.Errorf("%s %s didn't write the ETag of the contact", tc.method, tc.path)
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package dedupe
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/LucasFrezarini/go-contacts/config"
+	"github.com/google/wire"
+)
+
+// A Record holds the data of a contact compared to find its duplicates
+type Record struct {
+	ID        int
+	FirstName string
+	LastName  string
+	Emails    []string
+	Phones    []string
+}
+
+// A Reason explains why two records were considered duplicates
+type Reason string
+
+// The reasons for two records to be duplicates
+const (
+	ReasonSimilarName Reason = "similar_name"
+	ReasonSharedEmail Reason = "shared_email"
+	ReasonSharedPhone Reason = "shared_phone"
+)
+
+// A Pair is two records that are likely to be duplicates, A being the lowest ID
+type Pair struct {
+	A              int      `json:"a"`
+	B              int      `json:"b"`
+	Score          float64  `json:"score"`
+	NameSimilarity float64  `json:"name_similarity"`
+	Reasons        []Reason `json:"reasons"`
+}
+
+// A Cluster is a group of records connected by duplicate pairs. Its score is the one of its strongest pair
+type Cluster struct {
+	IDs   []int   `json:"ids"`
+	Score float64 `json:"score"`
+	Pairs []Pair  `json:"pairs"`
+}
+
+// An Engine scores the pairs of records by the similarity of their names and the emails and phones they share,
+// combining the weighted signals so each one raises the score further: 1 - (1 - w1*s1) * (1 - w2*s2) * ...
+type Engine struct {
+	// Threshold is the minimum score of a pair for it to be a duplicate
+	Threshold float64
+	// NameThreshold is the minimum similarity of two names for them to count as the same name
+	NameThreshold float64
+	NameWeight    float64
+	EmailWeight   float64
+	PhoneWeight   float64
+}
+
+// ProvideEngine creates an Engine with the configured thresholds and weights.
+// Created especially for the use of Wire, which will inject the dependencies via DI
+func ProvideEngine(cfg *config.Config) *Engine {
+	d := cfg.Dedupe
+	return &Engine{d.Threshold, d.NameThreshold, d.NameWeight, d.EmailWeight, d.PhoneWeight}
+}
+
+// normalized is a record ready to be compared
+type normalized struct {
+	id     int
+	name   string
+	emails map[string]bool
+	phones []string
+}
+
+func normalize(r Record) normalized {
+	n := normalized{id: r.ID, name: NormalizeName(r.FirstName + " " + r.LastName), emails: make(map[string]bool)}
+
+	for _, e := range r.Emails {
+		if e := NormalizeEmail(e); e != "" {
+			n.emails[e] = true
+		}
+	}
+
+	for _, p := range r.Phones {
+		if p := NormalizePhone(p); p != "" {
+			n.phones = append(n.phones, p)
+		}
+	}
+
+	return n
+}
+
+// blockingKeys returns the keys grouping the records worth comparing: only the records sharing
+// an email, the end of a phone number or the beginning of a word of their names are scored
+func (n normalized) blockingKeys() []string {
+	keys := make([]string, 0, len(n.emails)+len(n.phones)+2)
+
+	for e := range n.emails {
+		keys = append(keys, "e:"+e)
+	}
+
+	for _, p := range n.phones {
+		if len(p) > minPhoneDigits {
+			p = p[len(p)-minPhoneDigits:]
+		}
+
+		keys = append(keys, "p:"+p)
+	}
+
+	for _, word := range strings.Fields(n.name) {
+		if r := []rune(word); len(r) > 2 {
+			word = string(r[:2])
+		}
+
+		keys = append(keys, "n:"+word)
+	}
+
+	return keys
+}
+
+// Score compares two records, returning their pair. The pair is a duplicate if its score reaches the threshold
+func (e *Engine) Score(a, b Record) Pair {
+	return e.score(normalize(a), normalize(b))
+}
+
+func (e *Engine) score(a, b normalized) Pair {
+	if a.id > b.id {
+		a, b = b, a
+	}
+
+	p := Pair{A: a.id, B: b.id, Reasons: []Reason{}}
+	remaining := 1.0
+
+	if p.NameSimilarity = similarity(a.name, b.name); p.NameSimilarity >= e.NameThreshold {
+		p.Reasons = append(p.Reasons, ReasonSimilarName)
+		remaining *= 1 - e.NameWeight*p.NameSimilarity
+	}
+
+	for address := range a.emails {
+		if b.emails[address] {
+			p.Reasons = append(p.Reasons, ReasonSharedEmail)
+			remaining *= 1 - e.EmailWeight
+			break
+		}
+	}
+
+shared:
+	for _, pa := range a.phones {
+		for _, pb := range b.phones {
+			if samePhone(pa, pb) {
+				p.Reasons = append(p.Reasons, ReasonSharedPhone)
+				remaining *= 1 - e.PhoneWeight
+				break shared
+			}
+		}
+	}
+
+	p.Score = float64(int((1-remaining)*1000+0.5)) / 1000
+	return p
+}
+
+// Clusters finds the duplicate pairs among the records with a score reaching the threshold, grouping the
+// records they connect in clusters. The strongest clusters come first
+func (e *Engine) Clusters(records []Record, threshold float64) []Cluster {
+	items := make([]normalized, len(records))
+	blocks := make(map[string][]int)
+
+	for i, r := range records {
+		items[i] = normalize(r)
+
+		for _, key := range items[i].blockingKeys() {
+			blocks[key] = append(blocks[key], i)
+		}
+	}
+
+	compared := make(map[[2]int]bool)
+	parent := make([]int, len(items))
+	for i := range parent {
+		parent[i] = i
+	}
+
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+
+		return parent[i]
+	}
+
+	var found []Pair
+
+	for _, block := range blocks {
+		for x := 0; x < len(block); x++ {
+			for y := x + 1; y < len(block); y++ {
+				i, j := block[x], block[y]
+				if i > j {
+					i, j = j, i
+				}
+
+				if i == j || compared[[2]int{i, j}] {
+					continue
+				}
+
+				compared[[2]int{i, j}] = true
+
+				if p := e.score(items[i], items[j]); p.Score >= threshold && p.Score > 0 {
+					found = append(found, p)
+					parent[find(i)] = find(j)
+				}
+			}
+		}
+	}
+
+	index := make(map[int]int, len(items))
+	for i, item := range items {
+		index[item.id] = i
+	}
+
+	pairs := make(map[int][]Pair)
+	for _, p := range found {
+		root := find(index[p.A])
+		pairs[root] = append(pairs[root], p)
+	}
+
+	members := make(map[int][]int, len(pairs))
+	for i, item := range items {
+		if root := find(i); pairs[root] != nil {
+			members[root] = append(members[root], item.id)
+		}
+	}
+
+	clusters := make([]Cluster, 0, len(pairs))
+
+	for root, ps := range pairs {
+		c := Cluster{IDs: members[root], Pairs: ps}
+
+		sort.Ints(c.IDs)
+		sort.Slice(c.Pairs, func(i, j int) bool {
+			if c.Pairs[i].A != c.Pairs[j].A {
+				return c.Pairs[i].A < c.Pairs[j].A
+			}
+
+			return c.Pairs[i].B < c.Pairs[j].B
+		})
+
+		for _, p := range c.Pairs {
+			if p.Score > c.Score {
+				c.Score = p.Score
+			}
+		}
+
+		clusters = append(clusters, c)
+	}
+
+	sort.Slice(clusters, func(i, j int) bool {
+		if clusters[i].Score != clusters[j].Score {
+			return clusters[i].Score > clusters[j].Score
+		}
+
+		return clusters[i].IDs[0] < clusters[j].IDs[0]
+	})
+
+	return clusters
+}
+
+// Set is a wire set that contains all the providers of this package
+var Set = wire.NewSet(ProvideEngine)
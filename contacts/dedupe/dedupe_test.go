@@ -0,0 +1,122 @@
+package dedupe
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+var engine = &Engine{Threshold: 0.7, NameThreshold: 0.88, NameWeight: 0.75, EmailWeight: 0.9, PhoneWeight: 0.8}
+
+func TestNormalize(t *testing.T) {
+	if expected, got := "tanjiro kamado", NormalizeName("  Tanjirô   KAMADO! "); got != expected {
+		t.Errorf("NormalizeName() = %q, want %q", got, expected)
+	}
+
+	if expected, got := "nezuko@gmail.com", NormalizeEmail(" Nezuko@Gmail.com "); got != expected {
+		t.Errorf("NormalizeEmail() = %q, want %q", got, expected)
+	}
+
+	if expected, got := "5511944445555", NormalizePhone("+55 (11) 94444-5555"); got != expected {
+		t.Errorf("NormalizePhone() = %q, want %q", got, expected)
+	}
+}
+
+func TestJaroWinkler(t *testing.T) {
+	var testCases = []struct {
+		a, b     string
+		expected float64
+	}{
+		{"martha", "marhta", 0.961},
+		{"dwayne", "duane", 0.84},
+		{"abc", "xyz", 0},
+		{"same", "same", 1},
+	}
+
+	for _, tc := range testCases {
+		if got := jaroWinkler(tc.a, tc.b); math.Abs(got-tc.expected) > 0.001 {
+			t.Errorf("jaroWinkler(%q, %q) = %v, want %v", tc.a, tc.b, got, tc.expected)
+		}
+	}
+}
+
+func TestScore(t *testing.T) {
+	var testCases = []struct {
+		testName string
+		a, b     Record
+		reasons  []Reason
+		score    float64
+	}{
+		{
+			"swapped_names",
+			Record{ID: 1, FirstName: "Tanjiro", LastName: "Kamado"},
+			Record{ID: 2, FirstName: "Kamado", LastName: "Tanjirô"},
+			[]Reason{ReasonSimilarName}, 0.75,
+		},
+		{
+			"shared_email",
+			Record{ID: 1, FirstName: "Zenitsu", LastName: "Agatsuma", Emails: []string{"zenitsu@gmail.com"}},
+			Record{ID: 2, FirstName: "Thunder", LastName: "Boy", Emails: []string{"ZENITSU@gmail.com "}},
+			[]Reason{ReasonSharedEmail}, 0.9,
+		},
+		{
+			"phone_without_country_code",
+			Record{ID: 2, FirstName: "Inosuke", LastName: "Hashibira", Phones: []string{"+55 11 94444-5555"}},
+			Record{ID: 1, FirstName: "Inosuke", LastName: "Hashibira", Phones: []string{"(11) 94444-5555"}},
+			[]Reason{ReasonSimilarName, ReasonSharedPhone}, 0.95,
+		},
+		{
+			"different",
+			Record{ID: 1, FirstName: "Giyu", LastName: "Tomioka", Phones: []string{"1234"}},
+			Record{ID: 2, FirstName: "Shinobu", LastName: "Kocho", Phones: []string{"991234"}},
+			[]Reason{}, 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			p := engine.Score(tc.a, tc.b)
+
+			if p.A != 1 || p.B != 2 {
+				t.Errorf("Score() pair = (%d, %d), want (1, 2)", p.A, p.B)
+			}
+
+			if !reflect.DeepEqual(p.Reasons, tc.reasons) {
+				t.Errorf("Score() reasons = %v, want %v", p.Reasons, tc.reasons)
+			}
+
+			if p.Score != tc.score {
+				t.Errorf("Score() = %v, want %v", p.Score, tc.score)
+			}
+		})
+	}
+}
+
+func TestClusters(t *testing.T) {
+	records := []Record{
+		{ID: 1, FirstName: "Tanjiro", LastName: "Kamado", Emails: []string{"tanjiro@gmail.com"}},
+		{ID: 2, FirstName: "Tanjiro", LastName: "Kamado"},
+		{ID: 3, FirstName: "Tan", LastName: "K.", Emails: []string{"Tanjiro@gmail.com"}},
+		{ID: 4, FirstName: "Nezuko", LastName: "Kamado", Phones: []string{"11 94444-5555"}},
+		{ID: 5, FirstName: "Giyu", LastName: "Tomioka"},
+		{ID: 6, FirstName: "Demon", LastName: "Sister", Phones: []string{"+55 11 94444 5555"}},
+	}
+
+	clusters := engine.Clusters(records, engine.Threshold)
+
+	if len(clusters) != 2 {
+		t.Fatalf("Clusters() returned %d clusters, want 2: %+v", len(clusters), clusters)
+	}
+
+	if expected := []int{1, 2, 3}; !reflect.DeepEqual(clusters[0].IDs, expected) || clusters[0].Score != 0.9 || len(clusters[0].Pairs) != 2 {
+		t.Errorf("Clusters()[0] = %+v, want contacts %v connected by 2 pairs with score 0.9", clusters[0], expected)
+	}
+
+	if expected := []int{4, 6}; !reflect.DeepEqual(clusters[1].IDs, expected) || clusters[1].Score != 0.8 {
+		t.Errorf("Clusters()[1] = %+v, want contacts %v with score 0.8", clusters[1], expected)
+	}
+
+	if clusters := engine.Clusters(records, 0.85); len(clusters) != 1 {
+		t.Errorf("Clusters() with a higher threshold returned %d clusters, want 1", len(clusters))
+	}
+}
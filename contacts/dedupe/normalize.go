@@ -0,0 +1,163 @@
+package dedupe
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// minPhoneDigits is the minimum length of a phone number for it to match a longer one ending with it,
+// which is the same number written with its country or area code
+const minPhoneDigits = 8
+
+// NormalizeName lowercases the name, strips its accents and punctuation and collapses its spaces
+func NormalizeName(name string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+	stripped, _, err := transform.String(t, name)
+	if err != nil {
+		stripped = name
+	}
+
+	cleaned := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+
+		return ' '
+	}, stripped)
+
+	return strings.Join(strings.Fields(cleaned), " ")
+}
+
+// NormalizeEmail trims and lowercases the email address
+func NormalizeEmail(address string) string {
+	return strings.ToLower(strings.TrimSpace(address))
+}
+
+// NormalizePhone keeps only the digits of the phone number, without the international call prefix
+// and the leading zeros of trunk prefixes
+func NormalizePhone(number string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+
+		return -1
+	}, number)
+
+	return strings.TrimLeft(digits, "0")
+}
+
+// samePhone reports whether both normalized numbers are the same, allowing one of them to be written
+// without its country or area code
+func samePhone(a, b string) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+
+	if a == b {
+		return a != ""
+	}
+
+	return len(a) >= minPhoneDigits && strings.HasSuffix(b, a)
+}
+
+// similarity returns how similar two normalized names are, from 0 to 1, ignoring the order of their words
+func similarity(a, b string) float64 {
+	if a == "" || b == "" {
+		return 0
+	}
+
+	sorted := func(s string) string {
+		words := strings.Fields(s)
+		sort.Strings(words)
+		return strings.Join(words, " ")
+	}
+
+	return max(jaroWinkler(a, b), jaroWinkler(sorted(a), sorted(b)))
+}
+
+func max(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+
+	return b
+}
+
+// jaroWinkler returns the Jaro-Winkler similarity of two strings, which favours the ones sharing a prefix
+func jaroWinkler(a, b string) float64 {
+	s1, s2 := []rune(a), []rune(b)
+	if len(s1) == 0 || len(s2) == 0 {
+		return 0
+	}
+
+	window := len(s1)
+	if len(s2) > window {
+		window = len(s2)
+	}
+
+	window = window/2 - 1
+	if window < 0 {
+		window = 0
+	}
+
+	matched1 := make([]bool, len(s1))
+	matched2 := make([]bool, len(s2))
+	matches := 0
+
+	for i := range s1 {
+		lo, hi := i-window, i+window+1
+		if lo < 0 {
+			lo = 0
+		}
+
+		if hi > len(s2) {
+			hi = len(s2)
+		}
+
+		for j := lo; j < hi; j++ {
+			if !matched2[j] && s1[i] == s2[j] {
+				matched1[i], matched2[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+
+	if matches == 0 {
+		return 0
+	}
+
+	transpositions, j := 0, 0
+	for i := range s1 {
+		if !matched1[i] {
+			continue
+		}
+
+		for !matched2[j] {
+			j++
+		}
+
+		if s1[i] != s2[j] {
+			transpositions++
+		}
+
+		j++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(s1)) + m/float64(len(s2)) + (m-float64(transpositions)/2)/m) / 3
+
+	prefix := 0
+	for prefix < len(s1) && prefix < len(s2) && prefix < 4 && s1[prefix] == s2[prefix] {
+		prefix++
+	}
+
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
@@ -2,11 +2,30 @@ package email
 
 import "github.com/google/wire"
 
+// Well-known email labels. Any other label up to 50 characters is a custom one
+const (
+	LabelHome  = "home"
+	LabelWork  = "work"
+	LabelOther = "other"
+)
+
+// DefaultLabel is the label of the emails created without one
+const DefaultLabel = LabelOther
+
+// Labels are the well-known email labels
+var Labels = []string{LabelHome, LabelWork, LabelOther}
+
 // Email represents a contact's email
 type Email struct {
 	ID        int    `json:"id,omitempty"`
 	ContactID int    `json:"contact_id"`
 	Address   string `json:"address"`
+	// Normalized is the address lowercased with its domain in punycode, which addresses are compared by
+	Normalized string `json:"normalized"`
+	// Label is one of Labels or a custom label given by the user
+	Label string `json:"label"`
+	// IsPrimary tells whether it's the email to use for the contact, which has at most one primary email
+	IsPrimary bool `json:"is_primary"`
 }
 
 var Set = wire.NewSet(RepositorySet)
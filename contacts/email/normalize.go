@@ -0,0 +1,147 @@
+package email
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+)
+
+// ErrInvalidAddress is returned when an email address isn't valid, wrapped with the address
+var ErrInvalidAddress = errors.New("invalid email address")
+
+// ErrDuplicateAddress is returned when a contact has the same email address more than once, wrapped with the address
+var ErrDuplicateAddress = errors.New("duplicate email address")
+
+// ErrInvalidLabel is returned when an email label is too long, wrapped with the label
+var ErrInvalidLabel = errors.New("invalid email label")
+
+// The maximum lengths of an address and of its local part, as limited by RFC 5321, and of a label
+const (
+	maxAddressLength = 254
+	maxLocalLength   = 64
+	maxLabelLength   = 50
+)
+
+// CreateEmailData is the structure of an email that will be created
+type CreateEmailData struct {
+	Address    string
+	Normalized string
+	Label      string
+	IsPrimary  bool
+}
+
+// Input is an email as provided by the clients, either as its address alone or as an object with its label
+type Input struct {
+	Address string `json:"address" validate:"required"`
+	Label   string `json:"label" validate:"max=50"`
+}
+
+// UnmarshalJSON decodes the input from either a JSON string, the address, or a JSON object
+func (in *Input) UnmarshalJSON(b []byte) error {
+	var address string
+	if err := json.Unmarshal(b, &address); err == nil {
+		*in = Input{Address: address}
+		return nil
+	}
+
+	type input Input
+	return json.Unmarshal(b, (*input)(in))
+}
+
+// Parse validates the address as an RFC 5322 addr-spec, without display names or comments, whose domain may be
+// internationalized. The domain must have at least one dot. It returns the address trimmed, with its domain
+// lowercased, and its normalized form, fully lowercased with the domain in punycode, used for comparisons
+func Parse(address string) (CreateEmailData, error) {
+	address = strings.TrimSpace(address)
+
+	at := strings.LastIndex(address, "@")
+	if at <= 0 || at > maxLocalLength {
+		return CreateEmailData{}, fmt.Errorf("%w: %q", ErrInvalidAddress, address)
+	}
+
+	local, domain := address[:at], address[at+1:]
+
+	ascii, err := idna.Lookup.ToASCII(domain)
+	if err != nil || !strings.Contains(strings.Trim(ascii, "."), ".") {
+		return CreateEmailData{}, fmt.Errorf("%w: %q", ErrInvalidAddress, address)
+	}
+
+	normalized := local + "@" + ascii
+	if len(normalized) > maxAddressLength {
+		return CreateEmailData{}, fmt.Errorf("%w: %q", ErrInvalidAddress, address)
+	}
+
+	if parsed, err := mail.ParseAddress(normalized); err != nil || parsed.Name != "" {
+		return CreateEmailData{}, fmt.Errorf("%w: %q", ErrInvalidAddress, address)
+	}
+
+	unicode, err := idna.Lookup.ToUnicode(ascii)
+	if err != nil {
+		return CreateEmailData{}, fmt.Errorf("%w: %q", ErrInvalidAddress, address)
+	}
+
+	return CreateEmailData{Address: local + "@" + unicode, Normalized: strings.ToLower(normalized)}, nil
+}
+
+// ParseLabel returns the label trimmed, DefaultLabel when it's empty and lowercased when it's one of Labels.
+// Custom labels are kept as they were provided, as long as they aren't longer than 50 characters
+func ParseLabel(label string) (string, error) {
+	label = strings.TrimSpace(label)
+
+	if label == "" {
+		return DefaultLabel, nil
+	}
+
+	for _, known := range Labels {
+		if strings.EqualFold(label, known) {
+			return known, nil
+		}
+	}
+
+	if utf8.RuneCountInString(label) > maxLabelLength {
+		return "", fmt.Errorf("%w: %q is longer than %d characters", ErrInvalidLabel, label, maxLabelLength)
+	}
+
+	return label, nil
+}
+
+// ParseInput parses the address and the label of the email
+func ParseInput(in Input) (CreateEmailData, error) {
+	e, err := Parse(in.Address)
+	if err != nil {
+		return CreateEmailData{}, err
+	}
+
+	if e.Label, err = ParseLabel(in.Label); err != nil {
+		return CreateEmailData{}, err
+	}
+
+	return e, nil
+}
+
+// ParseAll parses all the emails of a contact, rejecting the ones whose addresses are the same once normalized
+func ParseAll(inputs ...Input) ([]CreateEmailData, error) {
+	parsed := make([]CreateEmailData, 0, len(inputs))
+	seen := make(map[string]bool, len(inputs))
+
+	for _, in := range inputs {
+		e, err := ParseInput(in)
+		if err != nil {
+			return nil, err
+		}
+
+		if seen[e.Normalized] {
+			return nil, fmt.Errorf("%w: %q", ErrDuplicateAddress, e.Address)
+		}
+
+		seen[e.Normalized] = true
+		parsed = append(parsed, e)
+	}
+
+	return parsed, nil
+}
@@ -0,0 +1,134 @@
+package email
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	var testCases = []struct {
+		testName   string
+		address    string
+		expected   string
+		normalized string
+	}{
+		{"simple", "inosuke@gmail.com", "inosuke@gmail.com", "inosuke@gmail.com"},
+		{"trimmed", "  inosuke@gmail.com\n", "inosuke@gmail.com", "inosuke@gmail.com"},
+		{"domain_lowercased", "Inosuke@GMAIL.com", "Inosuke@gmail.com", "inosuke@gmail.com"},
+		{"idn_domain", "zenitsu@Bücher.CH", "zenitsu@bücher.ch", "zenitsu@xn--bcher-kva.ch"},
+		{"punycode_domain", "zenitsu@xn--bcher-kva.ch", "zenitsu@bücher.ch", "zenitsu@xn--bcher-kva.ch"},
+		{"unicode_local", "ñandú@example.com", "ñandú@example.com", "ñandú@example.com"},
+		{"quoted_local", `"tanjiro kamado"@example.com`, `"tanjiro kamado"@example.com`, `"tanjiro kamado"@example.com`},
+		{"plus_tag", "nezuko+box@example.com", "nezuko+box@example.com", "nezuko+box@example.com"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			e, err := Parse(tc.address)
+			if err != nil {
+				t.Fatalf("Parse(%q) returned an error %v, want nil", tc.address, err)
+			}
+
+			if e.Address != tc.expected || e.Normalized != tc.normalized {
+				t.Errorf("Parse(%q) = %+v, want address %q normalized as %q", tc.address, e, tc.expected, tc.normalized)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	var testCases = []struct {
+		testName string
+		address  string
+	}{
+		{"empty", ""},
+		{"blank", "   "},
+		{"garbage", "not an email"},
+		{"missing_local", "@example.com"},
+		{"missing_domain", "inosuke@"},
+		{"dotless_domain", "inosuke@localhost"},
+		{"display_name", "Inosuke <inosuke@gmail.com>"},
+		{"comment", "inosuke@gmail.com (boar)"},
+		{"consecutive_dots", "ino..suke@gmail.com"},
+		{"invalid_domain", "inosuke@-gmail.com"},
+		{"underscore_domain", "inosuke@g_mail.com"},
+		{"long_local", strings.Repeat("a", 65) + "@gmail.com"},
+		{"long_address", "inosuke@" + strings.Repeat("a", 63) + "." + strings.Repeat("b", 63) + "." + strings.Repeat("c", 63) + "." + strings.Repeat("d", 60) + ".com"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			if e, err := Parse(tc.address); !errors.Is(err, ErrInvalidAddress) {
+				t.Errorf("Parse(%q) = %+v, %v, want ErrInvalidAddress", tc.address, e, err)
+			}
+		})
+	}
+}
+
+func TestParseAll(t *testing.T) {
+	emails, err := ParseAll(Input{Address: "inosuke@gmail.com"}, Input{Address: "zenitsu@bücher.ch", Label: "Work"})
+	if err != nil || len(emails) != 2 {
+		t.Fatalf("ParseAll() = %v, %v, want 2 emails", emails, err)
+	}
+
+	if emails[0].Label != DefaultLabel || emails[1].Label != LabelWork {
+		t.Errorf("ParseAll() labels = %q, %q, want %q, %q", emails[0].Label, emails[1].Label, DefaultLabel, LabelWork)
+	}
+
+	if _, err := ParseAll(Input{Address: "inosuke@gmail.com"}, Input{Address: " INOSUKE@gmail.COM "}); !errors.Is(err, ErrDuplicateAddress) {
+		t.Errorf("ParseAll() of the same address twice returned %v, want ErrDuplicateAddress", err)
+	}
+
+	if _, err := ParseAll(Input{Address: "zenitsu@bücher.ch"}, Input{Address: "zenitsu@xn--bcher-kva.ch"}); !errors.Is(err, ErrDuplicateAddress) {
+		t.Errorf("ParseAll() of the same IDN address twice returned %v, want ErrDuplicateAddress", err)
+	}
+
+	if _, err := ParseAll(Input{Address: "inosuke@gmail.com"}, Input{Address: "boar"}); !errors.Is(err, ErrInvalidAddress) {
+		t.Errorf("ParseAll() with an invalid address returned %v, want ErrInvalidAddress", err)
+	}
+}
+
+func TestParseLabel(t *testing.T) {
+	var testCases = []struct {
+		testName string
+		label    string
+		expected string
+	}{
+		{"empty", "", DefaultLabel},
+		{"known", "work", LabelWork},
+		{"known_any_case", " HOME ", LabelHome},
+		{"custom", " Old University ", "Old University"},
+		{"custom_max_length", strings.Repeat("é", 50), strings.Repeat("é", 50)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			if label, err := ParseLabel(tc.label); err != nil || label != tc.expected {
+				t.Errorf("ParseLabel(%q) = %q, %v, want %q", tc.label, label, err, tc.expected)
+			}
+		})
+	}
+
+	if _, err := ParseLabel(strings.Repeat("é", 51)); !errors.Is(err, ErrInvalidLabel) {
+		t.Errorf("ParseLabel() of a label longer than 50 characters returned %v, want ErrInvalidLabel", err)
+	}
+}
+
+func TestInputUnmarshalJSON(t *testing.T) {
+	var inputs []Input
+	if err := json.Unmarshal([]byte(`["inosuke@gmail.com", {"address": "boar@gmail.com", "label": "mountain"}]`), &inputs); err != nil {
+		t.Fatalf("json.Unmarshal() returned an error %v, want nil", err)
+	}
+
+	expected := []Input{{Address: "inosuke@gmail.com"}, {Address: "boar@gmail.com", Label: "mountain"}}
+	if !reflect.DeepEqual(inputs, expected) {
+		t.Errorf("json.Unmarshal() = %+v, want %+v", inputs, expected)
+	}
+
+	if err := json.Unmarshal([]byte(`[42]`), &inputs); err == nil {
+		t.Errorf("json.Unmarshal() of a number returned nil, want an error")
+	}
+}
@@ -1,19 +1,30 @@
 package email
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 
+	"github.com/LucasFrezarini/go-contacts/db"
 	"github.com/google/wire"
 	"go.uber.org/zap"
 )
 
+// ErrEmailNotFound is returned when an email doesn't exist or belongs to another contact
+var ErrEmailNotFound = errors.New("email not found")
+
 // GenericRepository defines the structure of a generic email's repository
 // created to facilitate the mocking in unit testing
 type GenericRepository interface {
-	FindByContactID(id int) ([]Email, error)
-	Create(contactID int, emails ...string) ([]Email, error)
+	FindByContactID(ctx context.Context, id int) ([]Email, error)
+	FindByID(ctx context.Context, contactID int, id int) (*Email, error)
+	Create(ctx context.Context, contactID int, emails ...CreateEmailData) ([]Email, error)
+	Update(ctx context.Context, contactID int, id int, data CreateEmailData) (*Email, error)
+	DeleteByID(ctx context.Context, contactID int, id int) error
+	DeleteByContactID(ctx context.Context, contactID int) error
+	Reassign(ctx context.Context, id int, from int, to int) error
+	SetPrimary(ctx context.Context, contactID int, id int) error
 }
 
 // A Repository can perform all the CRUD logic of the
@@ -30,11 +41,11 @@ func ProvideEmailRepository(db *sql.DB, logger *zap.Logger) *Repository {
 }
 
 // FindByContactID return all the emails registered for the contact with
-// the id provided as parameter
-func (r *Repository) FindByContactID(id int) ([]Email, error) {
-	raw := "SELECT id, contact_id, address FROM email WHERE contact_id = ?"
+// the id provided as parameter, in the order they were created
+func (r *Repository) FindByContactID(ctx context.Context, id int) ([]Email, error) {
+	raw := "SELECT id, contact_id, address, normalized, label, is_primary FROM email WHERE contact_id = ? ORDER BY id"
 
-	rows, err := r.DB.Query(raw, id)
+	rows, err := db.Conn(ctx, r.DB).QueryContext(ctx, raw, id)
 	if err != nil {
 		msg := fmt.Sprintf("FindByContactID(%d): error while preparing statement: %v", id, err)
 		r.Logger.Error(msg)
@@ -47,7 +58,7 @@ func (r *Repository) FindByContactID(id int) ([]Email, error) {
 	for rows.Next() {
 		var email Email
 
-		if err := rows.Scan(&email.ID, &email.ContactID, &email.Address); err != nil {
+		if err := rows.Scan(&email.ID, &email.ContactID, &email.Address, &email.Normalized, &email.Label, &email.IsPrimary); err != nil {
 			msg := fmt.Sprintf("FindByContactID(%d): error while scanning row: %v", id, err)
 			r.Logger.Error(msg)
 			return nil, errors.New(msg)
@@ -59,12 +70,32 @@ func (r *Repository) FindByContactID(id int) ([]Email, error) {
 	return emails, nil
 }
 
+// FindByID returns the email with the provided id, as long as it belongs to the contact with the provided
+// contactID. Otherwise, ErrEmailNotFound is returned
+func (r *Repository) FindByID(ctx context.Context, contactID int, id int) (*Email, error) {
+	raw := "SELECT id, contact_id, address, normalized, label, is_primary FROM email WHERE id = ? AND contact_id = ?"
+
+	var email Email
+	err := db.Conn(ctx, r.DB).QueryRowContext(ctx, raw, id, contactID).
+		Scan(&email.ID, &email.ContactID, &email.Address, &email.Normalized, &email.Label, &email.IsPrimary)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("FindByID(%d, %d): %w", contactID, id, ErrEmailNotFound)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("FindByID(%d, %d): error while executing query: %w", contactID, id, err)
+	}
+
+	return &email, nil
+}
+
 // Create creates one or more emails for the contactID provided
-func (r *Repository) Create(contactID int, emails ...string) ([]Email, error) {
+func (r *Repository) Create(ctx context.Context, contactID int, emails ...CreateEmailData) ([]Email, error) {
 	insertedEmails := make([]Email, 0, len(emails))
 
-	for _, address := range emails {
-		email, err := r.createSingleEmail(contactID, address)
+	for _, data := range emails {
+		email, err := r.createSingleEmail(ctx, contactID, data)
 
 		if err != nil {
 			return nil, fmt.Errorf("error while inserting email into the database: %w", err)
@@ -76,16 +107,16 @@ func (r *Repository) Create(contactID int, emails ...string) ([]Email, error) {
 	return insertedEmails, nil
 }
 
-func (r *Repository) createSingleEmail(contactID int, address string) (Email, error) {
-	raw := "INSERT INTO email (contact_id, address) VALUES (?, ?)"
+func (r *Repository) createSingleEmail(ctx context.Context, contactID int, data CreateEmailData) (Email, error) {
+	raw := "INSERT INTO email (contact_id, address, normalized, label, is_primary) VALUES (?, ?, ?, ?, ?)"
 
-	stmt, err := r.DB.Prepare(raw)
+	stmt, err := db.Conn(ctx, r.DB).PrepareContext(ctx, raw)
 	if err != nil {
 		return Email{}, fmt.Errorf("createSingleEmail: error while preparing statement: %w", err)
 	}
 	defer stmt.Close()
 
-	result, err := stmt.Exec(contactID, address)
+	result, err := stmt.ExecContext(ctx, contactID, data.Address, data.Normalized, data.Label, data.IsPrimary)
 	if err != nil {
 		return Email{}, fmt.Errorf("createSingleEmail: error while executing insert query: %w", err)
 	}
@@ -96,12 +127,93 @@ func (r *Repository) createSingleEmail(contactID int, address string) (Email, er
 	}
 
 	return Email{
-		ID:        int(id),
-		ContactID: contactID,
-		Address:   address,
+		ID:         int(id),
+		ContactID:  contactID,
+		Address:    data.Address,
+		Normalized: data.Normalized,
+		Label:      data.Label,
+		IsPrimary:  data.IsPrimary,
 	}, nil
 }
 
+// Update changes the address and the label of the email with the provided id, as long as it belongs to the
+// contact with the provided contactID. The caller is responsible for checking that the email exists, since MySQL
+// doesn't report the rows matched by an update that changes nothing. Whether the email is primary doesn't change
+func (r *Repository) Update(ctx context.Context, contactID int, id int, data CreateEmailData) (*Email, error) {
+	raw := "UPDATE email SET address = ?, normalized = ?, label = ? WHERE id = ? AND contact_id = ?"
+
+	if _, err := db.Conn(ctx, r.DB).ExecContext(ctx, raw, data.Address, data.Normalized, data.Label, id, contactID); err != nil {
+		return nil, fmt.Errorf("Update(%d, %d): error while executing the update query: %w", contactID, id, err)
+	}
+
+	return &Email{ID: id, ContactID: contactID, Address: data.Address, Normalized: data.Normalized, Label: data.Label}, nil
+}
+
+// DeleteByID deletes the email with the provided id, as long as it belongs to the contact with the provided
+// contactID. Otherwise, ErrEmailNotFound is returned
+func (r *Repository) DeleteByID(ctx context.Context, contactID int, id int) error {
+	result, err := db.Conn(ctx, r.DB).ExecContext(ctx, "DELETE FROM email WHERE id = ? AND contact_id = ?", id, contactID)
+	if err != nil {
+		return fmt.Errorf("DeleteByID(%d, %d): error while executing the delete query: %w", contactID, id, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("DeleteByID(%d, %d): error while fetching the affected rows: %w", contactID, id, err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("DeleteByID(%d, %d): %w", contactID, id, ErrEmailNotFound)
+	}
+
+	return nil
+}
+
+// DeleteByContactID deletes all the emails of the contact with the provided id
+func (r *Repository) DeleteByContactID(ctx context.Context, contactID int) error {
+	if _, err := db.Conn(ctx, r.DB).ExecContext(ctx, "DELETE FROM email WHERE contact_id = ?", contactID); err != nil {
+		return fmt.Errorf("DeleteByContactID(%d): error while executing the delete query: %w", contactID, err)
+	}
+
+	return nil
+}
+
+// Reassign moves the email with the provided id from the contact from to the contact to. It's no longer primary,
+// since the other contact may already have a primary email. When the email doesn't belong to the contact from,
+// ErrEmailNotFound is returned
+func (r *Repository) Reassign(ctx context.Context, id int, from int, to int) error {
+	raw := "UPDATE email SET contact_id = ?, is_primary = FALSE WHERE id = ? AND contact_id = ?"
+
+	result, err := db.Conn(ctx, r.DB).ExecContext(ctx, raw, to, id, from)
+	if err != nil {
+		return fmt.Errorf("Reassign(%d, %d, %d): error while executing the update query: %w", id, from, to, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("Reassign(%d, %d, %d): error while fetching the affected rows: %w", id, from, to, err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("Reassign(%d, %d, %d): %w", id, from, to, ErrEmailNotFound)
+	}
+
+	return nil
+}
+
+// SetPrimary makes the email with the provided id the primary one of the contact with the provided contactID,
+// and the other emails of the contact no longer primary, all at once. The caller is responsible for checking
+// that the email belongs to the contact, otherwise the contact is left without a primary email
+func (r *Repository) SetPrimary(ctx context.Context, contactID int, id int) error {
+	raw := "UPDATE email SET is_primary = (id = ?) WHERE contact_id = ?"
+
+	if _, err := db.Conn(ctx, r.DB).ExecContext(ctx, raw, id, contactID); err != nil {
+		return fmt.Errorf("SetPrimary(%d, %d): error while executing the update query: %w", contactID, id, err)
+	}
+
+	return nil
+}
+
 // RepositorySet is the wire set which contains all the binding necessary
 // to create a new email Repository
 var RepositorySet = wire.NewSet(
@@ -1,6 +1,8 @@
 package email
 
 import (
+	"context"
+	"errors"
 	"reflect"
 	"testing"
 
@@ -19,20 +21,20 @@ func TestFindByContactID(t *testing.T) {
 	contactID := 2
 
 	expectedEmails := []Email{
-		Email{ID: 1, ContactID: contactID, Address: "inosuke@gmail.com"},
-		Email{ID: 2, ContactID: contactID, Address: "zenitsu@yahoo.com"},
+		Email{ID: 1, ContactID: contactID, Address: "inosuke@gmail.com", Normalized: "inosuke@gmail.com", Label: LabelHome, IsPrimary: true},
+		Email{ID: 2, ContactID: contactID, Address: "Zenitsu@Yahoo.com", Normalized: "zenitsu@yahoo.com", Label: "thunder"},
 	}
 
-	rows := sqlmock.NewRows([]string{"id", "contact_id", "address"})
+	rows := sqlmock.NewRows([]string{"id", "contact_id", "address", "normalized", "label", "is_primary"})
 
 	for _, e := range expectedEmails {
-		rows.AddRow(e.ID, e.ContactID, e.Address)
+		rows.AddRow(e.ID, e.ContactID, e.Address, e.Normalized, e.Label, e.IsPrimary)
 	}
 
 	mock.ExpectQuery("SELECT (.+) FROM email").WillReturnRows(rows).RowsWillBeClosed()
 
 	repository := ProvideEmailRepository(db, zap.NewNop())
-	emails, err := repository.FindByContactID(contactID)
+	emails, err := repository.FindByContactID(context.Background(), contactID)
 
 	if err != nil {
 		t.Errorf("FindByContactID(%d) returned an error: '%v', want nil", contactID, err)
@@ -63,14 +65,17 @@ func TestEmailCreate(t *testing.T) {
 
 	contactID := 2
 
-	emails := []string{"zenitsu01@gmail.com", "zenitsu02@yahoo.com"}
+	emails := []CreateEmailData{
+		{Address: "zenitsu01@gmail.com", Normalized: "zenitsu01@gmail.com", Label: LabelOther, IsPrimary: true},
+		{Address: "Zenitsu02@bücher.ch", Normalized: "zenitsu02@xn--bcher-kva.ch", Label: "thunder"},
+	}
 
 	for i, e := range emails {
-		mock.ExpectPrepare("INSERT INTO email").ExpectExec().WithArgs(contactID, e).WillReturnResult(sqlmock.NewResult(int64(i+1), 1))
+		mock.ExpectPrepare("INSERT INTO email").ExpectExec().WithArgs(contactID, e.Address, e.Normalized, e.Label, e.IsPrimary).WillReturnResult(sqlmock.NewResult(int64(i+1), 1))
 	}
 
 	repository := ProvideEmailRepository(db, zap.NewNop())
-	insertedEmails, err := repository.Create(contactID, emails...)
+	insertedEmails, err := repository.Create(context.Background(), contactID, emails...)
 
 	if err != nil {
 		t.Errorf("Create(%d, %v) returned a non-nil error '%v', want nil", contactID, emails, err)
@@ -84,9 +89,171 @@ func TestEmailCreate(t *testing.T) {
 		if e.ID == 0 {
 			t.Errorf("Create(%d, %v) email[%d].ID == 0, want != 0", contactID, emails, i)
 		}
+
+		if e.Label != emails[i].Label {
+			t.Errorf("Create(%d, %v) email[%d].Label == %q, want %q", contactID, emails, i, e.Label, emails[i].Label)
+		}
+
+		if e.IsPrimary != emails[i].IsPrimary {
+			t.Errorf("Create(%d, %v) email[%d].IsPrimary == %t, want %t", contactID, emails, i, e.IsPrimary, emails[i].IsPrimary)
+		}
 	}
 
 	if err = mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("Create(%d, %v): unfulfilled mock expectations: %v", contactID, emails, err)
 	}
 }
+
+func TestDeleteByContactID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	contactID := 2
+
+	mock.ExpectExec("DELETE FROM email WHERE contact_id = (.+)").WithArgs(contactID).WillReturnResult(sqlmock.NewResult(0, 2))
+
+	repository := ProvideEmailRepository(db, zap.NewNop())
+
+	if err := repository.DeleteByContactID(context.Background(), contactID); err != nil {
+		t.Errorf("DeleteByContactID(%d) returned a non-nil error '%v', want nil", contactID, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("DeleteByContactID(%d): unfulfilled mock expectations: %v", contactID, err)
+	}
+}
+
+func TestReassign(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	id, from, to := 3, 2, 1
+
+	mock.ExpectExec("UPDATE email SET contact_id = (.+), is_primary = FALSE WHERE id = (.+) AND contact_id = (.+)").WithArgs(to, id, from).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE email").WithArgs(to, id, from).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repository := ProvideEmailRepository(db, zap.NewNop())
+
+	if err := repository.Reassign(context.Background(), id, from, to); err != nil {
+		t.Errorf("Reassign(%d, %d, %d) returned a non-nil error '%v', want nil", id, from, to, err)
+	}
+
+	// the email was moved to another contact since
+	if err := repository.Reassign(context.Background(), id, from, to); !errors.Is(err, ErrEmailNotFound) {
+		t.Errorf("Reassign(%d, %d, %d) returned %v, want %v", id, from, to, err, ErrEmailNotFound)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Reassign(%d, %d, %d): unfulfilled mock expectations: %v", id, from, to, err)
+	}
+}
+
+func TestEmailFindByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	cols := []string{"id", "contact_id", "address", "normalized", "label", "is_primary"}
+	expected := &Email{ID: 3, ContactID: 2, Address: "Zenitsu@gmail.com", Normalized: "zenitsu@gmail.com", Label: LabelWork, IsPrimary: true}
+
+	mock.ExpectQuery("SELECT (.+) FROM email WHERE id = (.+) AND contact_id = (.+)").WithArgs(3, 2).
+		WillReturnRows(sqlmock.NewRows(cols).AddRow(3, 2, "Zenitsu@gmail.com", "zenitsu@gmail.com", LabelWork, true))
+	mock.ExpectQuery("SELECT (.+) FROM email").WithArgs(3, 1).WillReturnRows(sqlmock.NewRows(cols))
+
+	repository := ProvideEmailRepository(db, zap.NewNop())
+
+	if e, err := repository.FindByID(context.Background(), 2, 3); err != nil || !reflect.DeepEqual(e, expected) {
+		t.Errorf("FindByID(2, 3) = %v, %v, want %v", e, err, expected)
+	}
+
+	if _, err := repository.FindByID(context.Background(), 1, 3); !errors.Is(err, ErrEmailNotFound) {
+		t.Errorf("FindByID(1, 3) of an email of another contact returned %v, want ErrEmailNotFound", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("FindByID(): unfulfilled mock expectations: %v", err)
+	}
+}
+
+func TestEmailUpdate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	data := CreateEmailData{Address: "Zenitsu@bücher.ch", Normalized: "zenitsu@xn--bcher-kva.ch", Label: LabelWork}
+
+	mock.ExpectExec("UPDATE email SET address = (.+), normalized = (.+), label = (.+) WHERE id = (.+) AND contact_id = (.+)").
+		WithArgs(data.Address, data.Normalized, data.Label, 3, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	repository := ProvideEmailRepository(db, zap.NewNop())
+	e, err := repository.Update(context.Background(), 2, 3, data)
+
+	if expected := (&Email{ID: 3, ContactID: 2, Address: data.Address, Normalized: data.Normalized, Label: data.Label}); err != nil || !reflect.DeepEqual(e, expected) {
+		t.Errorf("Update(2, 3) = %v, %v, want %v", e, err, expected)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Update(): unfulfilled mock expectations: %v", err)
+	}
+}
+
+func TestEmailDeleteByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	mock.ExpectExec("DELETE FROM email WHERE id = (.+) AND contact_id = (.+)").WithArgs(3, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM email").WithArgs(3, 1).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repository := ProvideEmailRepository(db, zap.NewNop())
+
+	if err := repository.DeleteByID(context.Background(), 2, 3); err != nil {
+		t.Errorf("DeleteByID(2, 3) returned %v, want nil", err)
+	}
+
+	if err := repository.DeleteByID(context.Background(), 1, 3); !errors.Is(err, ErrEmailNotFound) {
+		t.Errorf("DeleteByID(1, 3) of an email of another contact returned %v, want ErrEmailNotFound", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("DeleteByID(): unfulfilled mock expectations: %v", err)
+	}
+}
+
+func TestEmailSetPrimary(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	mock.ExpectExec("UPDATE email SET is_primary = \\(id = (.+)\\) WHERE contact_id = (.+)").WithArgs(3, 2).WillReturnResult(sqlmock.NewResult(0, 2))
+
+	repository := ProvideEmailRepository(db, zap.NewNop())
+
+	if err := repository.SetPrimary(context.Background(), 2, 3); err != nil {
+		t.Errorf("SetPrimary(2, 3) returned %v, want nil", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("SetPrimary(): unfulfilled mock expectations: %v", err)
+	}
+}
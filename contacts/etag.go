@@ -0,0 +1,88 @@
+package contacts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Entity tag and conditional request headers
+const (
+	HeaderETag        = "ETag"
+	HeaderIfMatch     = "If-Match"
+	HeaderIfNoneMatch = "If-None-Match"
+)
+
+// errIfMatchRequired is returned when a change is requested without the If-Match header
+var errIfMatchRequired = errors.New("missing If-Match header")
+
+// ifMatchVersion returns the contact version required by the If-Match header, or 0 when "*" accepts any version.
+// Only a single strong entity tag is supported: anything else can never match, so -1 is returned. Changes must be
+// conditional, so a precondition required response is written when the header is missing
+func ifMatchVersion(c echo.Context) (int, error) {
+	tag := strings.TrimSpace(c.Request().Header.Get(HeaderIfMatch))
+	if tag == "" {
+		c.JSON(http.StatusPreconditionRequired, map[string]interface{}{
+			"error": "the If-Match header is required, with the ETag of the contact or *",
+		})
+
+		return 0, errIfMatchRequired
+	}
+
+	if tag == "*" {
+		return 0, nil
+	}
+
+	version, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(tag, `"`), `"`))
+	if err != nil || !strings.HasPrefix(tag, `"`) || version <= 0 {
+		return -1, nil
+	}
+
+	return version, nil
+}
+
+// noneMatch reports whether the If-None-Match header matches the entity tag, using the weak comparison
+func noneMatch(c echo.Context, etag string) bool {
+	header := c.Request().Header.Get(HeaderIfNoneMatch)
+	if header == "" {
+		return false
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// writeWithETag writes the JSON response along with its entity tag, or 304 when the client already has it
+func writeWithETag(c echo.Context, etag string, v interface{}) error {
+	c.Response().Header().Set(HeaderETag, etag)
+
+	if noneMatch(c, etag) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	return c.JSON(http.StatusOK, v)
+}
+
+// bodyETag returns a weak entity tag computed from the JSON representation of v, for the responses
+// which don't have a version of their own, like lists
+func bodyETag(v interface{}) (string, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(body)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
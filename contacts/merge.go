@@ -0,0 +1,269 @@
+package contacts
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/LucasFrezarini/go-contacts/db"
+	"github.com/google/wire"
+	"go.uber.org/zap"
+)
+
+// ErrMergeNotFound is returned when a merge doesn't exist or its target isn't visible
+var ErrMergeNotFound = errors.New("merge not found")
+
+// ErrMergeUndone is returned when undoing a merge that was already undone
+var ErrMergeUndone = errors.New("merge already undone")
+
+// ErrMergeOutdated is returned when undoing a merge whose target or moved details were changed since,
+// wrapped with the reason
+var ErrMergeOutdated = errors.New("merge outdated")
+
+// ErrInvalidMerge is returned when the merge request is inconsistent, wrapped with the reason
+var ErrInvalidMerge = errors.New("invalid merge")
+
+// A Resolution is the rule choosing the value of a field of the merged contact
+type Resolution string
+
+// The resolution rules of a merge
+const (
+	// ResolutionKeepTarget keeps the value of the target contact
+	ResolutionKeepTarget Resolution = "keep_target"
+	// ResolutionPreferNonEmpty keeps the value of the target contact, unless it's empty. In this case
+	// the first non empty value of the sources is used
+	ResolutionPreferNonEmpty Resolution = "prefer_non_empty"
+)
+
+// mergeableFields returns the fields of the contact that can be resolved by a merge, by their JSON name
+func mergeableFields(c *Contact) map[string]*string {
+	return map[string]*string{
+		"first_name":   &c.FirstName,
+		"last_name":    &c.LastName,
+		"organization": &c.Organization,
+		"department":   &c.Department,
+		"title":        &c.Title,
+	}
+}
+
+func validResolution(r Resolution) bool {
+	return r == ResolutionKeepTarget || r == ResolutionPreferNonEmpty
+}
+
+// validate checks the consistency of the merge, returning an error wrapping ErrInvalidMerge if it isn't
+func (d MergeContactsData) validate() error {
+	if len(d.SourceIDs) == 0 {
+		return fmt.Errorf("%w: at least one source is required", ErrInvalidMerge)
+	}
+
+	seen := make(map[int]bool, len(d.SourceIDs))
+	for _, id := range d.SourceIDs {
+		if id == d.TargetID {
+			return fmt.Errorf("%w: the target %d can't be one of the sources", ErrInvalidMerge, id)
+		}
+
+		if seen[id] {
+			return fmt.Errorf("%w: the source %d is repeated", ErrInvalidMerge, id)
+		}
+
+		seen[id] = true
+	}
+
+	if d.Strategy != "" && !validResolution(d.Strategy) {
+		return fmt.Errorf("%w: unknown strategy %q", ErrInvalidMerge, d.Strategy)
+	}
+
+	fields := mergeableFields(&Contact{})
+
+	for field, r := range d.Fields {
+		if _, ok := fields[field]; !ok {
+			return fmt.Errorf("%w: %s can't be merged", ErrInvalidMerge, field)
+		}
+
+		if !validResolution(r) {
+			return fmt.Errorf("%w: unknown resolution %q of %s", ErrInvalidMerge, r, field)
+		}
+	}
+
+	for field, value := range d.Overrides {
+		if _, ok := fields[field]; !ok {
+			return fmt.Errorf("%w: %s can't be merged", ErrInvalidMerge, field)
+		}
+
+		if value == "" {
+			return fmt.Errorf("%w: the override of %s can't be empty", ErrInvalidMerge, field)
+		}
+	}
+
+	return nil
+}
+
+// resolve applies the resolution rules of the merge to the fields of the target
+func (d MergeContactsData) resolve(target *Contact, sources []*Contact) Contact {
+	merged := *target
+
+	for field, value := range mergeableFields(&merged) {
+		rule := d.Fields[field]
+		if rule == "" {
+			rule = d.Strategy
+		}
+
+		if rule == ResolutionPreferNonEmpty && *value == "" {
+			for _, src := range sources {
+				if v := *mergeableFields(src)[field]; v != "" {
+					*value = v
+					break
+				}
+			}
+		}
+
+		if override, ok := d.Overrides[field]; ok {
+			*value = override
+		}
+	}
+
+	return merged
+}
+
+// A MovedDetail is an email or phone moved from a source to the target of a merge
+type MovedDetail struct {
+	Entity string `json:"entity"`
+	ID     int    `json:"id"`
+	From   int    `json:"from"`
+}
+
+// A Merge records a merge of contacts, with everything needed to undo it
+type Merge struct {
+	ID        int   `json:"id"`
+	TargetID  int   `json:"target_id"`
+	SourceIDs []int `json:"source_ids"`
+	// Target is the state of the target contact before the merge
+	Target *Contact `json:"-"`
+	// TargetVersion is the version of the target contact right after the merge
+	TargetVersion int           `json:"target_version"`
+	Moved         []MovedDetail `json:"moved"`
+	Actor         string        `json:"actor"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UndoneAt      *time.Time    `json:"undone_at,omitempty"`
+}
+
+// MergeRepository defines the structure of a merges repository, created to facilitate the mocking
+// in unit testing. The caller is responsible for checking that the target contact is visible by the principal
+type MergeRepository interface {
+	Create(ctx context.Context, m Merge) (*Merge, error)
+	FindByID(ctx context.Context, id int) (*Merge, error)
+	MarkUndone(ctx context.Context, id int, at time.Time) error
+}
+
+// A MergesRepository persists the merges of contacts
+type MergesRepository struct {
+	DB     *sql.DB
+	Logger *zap.Logger
+}
+
+// ProvideMergesRepository creates a new MergesRepository with the dependencies provided.
+// Created especially for the use of Wire, which will inject the dependencies via DI
+func ProvideMergesRepository(db *sql.DB, logger *zap.Logger) *MergesRepository {
+	return &MergesRepository{DB: db, Logger: logger.Named("MergesRepository")}
+}
+
+// Create stores the merge
+func (r *MergesRepository) Create(ctx context.Context, m Merge) (*Merge, error) {
+	sources, err := json.Marshal(m.SourceIDs)
+	if err != nil {
+		return nil, fmt.Errorf("create: error while encoding the sources: %w", err)
+	}
+
+	target, err := json.Marshal(m.Target)
+	if err != nil {
+		return nil, fmt.Errorf("create: error while encoding the target: %w", err)
+	}
+
+	moved, err := json.Marshal(m.Moved)
+	if err != nil {
+		return nil, fmt.Errorf("create: error while encoding the moved details: %w", err)
+	}
+
+	raw := "INSERT INTO contact_merge (target_id, source_ids, target_state, target_version, moved, actor, created_at) " +
+		"VALUES (?, ?, ?, ?, ?, ?, ?)"
+
+	result, err := db.Conn(ctx, r.DB).ExecContext(ctx, raw, m.TargetID, sources, target, m.TargetVersion, moved, m.Actor, m.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("create: error while executing insert query: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("create: error while fetching the last inserted ID: %w", err)
+	}
+
+	m.ID = int(id)
+	return &m, nil
+}
+
+// FindByID returns the merge with the provided ID. If there's no such merge, ErrMergeNotFound is returned
+func (r *MergesRepository) FindByID(ctx context.Context, id int) (*Merge, error) {
+	raw := "SELECT id, target_id, source_ids, target_state, target_version, moved, actor, created_at, undone_at " +
+		"FROM contact_merge WHERE id = ?"
+
+	var m Merge
+	var sources, target, moved []byte
+	var undoneAt sql.NullTime
+
+	err := db.Conn(ctx, r.DB).QueryRowContext(ctx, raw, id).
+		Scan(&m.ID, &m.TargetID, &sources, &target, &m.TargetVersion, &moved, &m.Actor, &m.CreatedAt, &undoneAt)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("FindByID(%d): %w", id, ErrMergeNotFound)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("FindByID(%d): error while executing query: %w", id, err)
+	}
+
+	for _, field := range []struct {
+		raw  []byte
+		dest interface{}
+	}{{sources, &m.SourceIDs}, {target, &m.Target}, {moved, &m.Moved}} {
+		if err := json.Unmarshal(field.raw, field.dest); err != nil {
+			return nil, fmt.Errorf("FindByID(%d): error while decoding the merge: %w", id, err)
+		}
+	}
+
+	if undoneAt.Valid {
+		m.UndoneAt = &undoneAt.Time
+	}
+
+	return &m, nil
+}
+
+// MarkUndone records that the merge was undone, as long as it wasn't already.
+// Otherwise, ErrMergeUndone is returned
+func (r *MergesRepository) MarkUndone(ctx context.Context, id int, at time.Time) error {
+	raw := "UPDATE contact_merge SET undone_at = ? WHERE id = ? AND undone_at IS NULL"
+
+	result, err := db.Conn(ctx, r.DB).ExecContext(ctx, raw, at, id)
+	if err != nil {
+		return fmt.Errorf("MarkUndone(%d): error while executing the update query: %w", id, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("MarkUndone(%d): error while fetching the affected rows: %w", id, err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("MarkUndone(%d): %w", id, ErrMergeUndone)
+	}
+
+	return nil
+}
+
+// MergeRepositorySet is the wire set that contains all the providers for the merges repository
+var MergeRepositorySet = wire.NewSet(
+	ProvideMergesRepository,
+	wire.Bind(new(MergeRepository), new(*MergesRepository)),
+)
@@ -0,0 +1,379 @@
+package contacts
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/LucasFrezarini/go-contacts/server/validator"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+func TestMergesRepositoryCreate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	m := Merge{
+		TargetID:      1,
+		SourceIDs:     []int{2},
+		Target:        &Contact{ID: 1, FirstName: "Inosuke", LastName: "Hashibira", Version: 1},
+		TargetVersion: 2,
+		Moved:         []MovedDetail{{Entity: "email", ID: 3, From: 2}},
+		Actor:         owner,
+		CreatedAt:     now,
+	}
+
+	mock.ExpectExec("INSERT INTO contact_merge").
+		WithArgs(1, []byte("[2]"), sqlmock.AnyArg(), 2, []byte(`[{"entity":"email","id":3,"from":2}]`), owner, now).
+		WillReturnResult(sqlmock.NewResult(7, 1))
+
+	repository := ProvideMergesRepository(db, zap.NewNop())
+	created, err := repository.Create(context.Background(), m)
+
+	if err != nil {
+		t.Fatalf("Create() returned an error %v, want nil", err)
+	}
+
+	if expected, got := 7, created.ID; expected != got {
+		t.Errorf("Create() ID == %d, want %d", got, expected)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled mock expectations: %v", err)
+	}
+}
+
+func TestMergesRepositoryFindByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	state := `{"id":1,"first_name":"Inosuke","last_name":"Hashibira","emails":null,"phones":null,"version":1}`
+	cols := []string{"id", "target_id", "source_ids", "target_state", "target_version", "moved", "actor", "created_at", "undone_at"}
+
+	mock.ExpectQuery("SELECT (.+) FROM contact_merge WHERE id = (.+)").WithArgs(7).
+		WillReturnRows(sqlmock.NewRows(cols).AddRow(7, 1, "[2]", state, 2, `[{"entity":"phone","id":5,"from":2}]`, owner, now, nil))
+	mock.ExpectQuery("SELECT (.+) FROM contact_merge").WithArgs(8).WillReturnRows(sqlmock.NewRows(cols))
+
+	repository := ProvideMergesRepository(db, zap.NewNop())
+	m, err := repository.FindByID(context.Background(), 7)
+
+	if err != nil {
+		t.Fatalf("FindByID() returned an error %v, want nil", err)
+	}
+
+	expected := &Merge{
+		ID:            7,
+		TargetID:      1,
+		SourceIDs:     []int{2},
+		Target:        &Contact{ID: 1, FirstName: "Inosuke", LastName: "Hashibira", Version: 1},
+		TargetVersion: 2,
+		Moved:         []MovedDetail{{Entity: "phone", ID: 5, From: 2}},
+		Actor:         owner,
+		CreatedAt:     now,
+	}
+
+	if !reflect.DeepEqual(m, expected) {
+		t.Errorf("FindByID() = %+v, want %+v", m, expected)
+	}
+
+	if _, err := repository.FindByID(context.Background(), 8); !errors.Is(err, ErrMergeNotFound) {
+		t.Errorf("FindByID() of a missing merge returned %v, want ErrMergeNotFound", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled mock expectations: %v", err)
+	}
+}
+
+func TestMergesRepositoryMarkUndone(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectExec("UPDATE contact_merge SET undone_at = (.+) WHERE id = (.+) AND undone_at IS NULL").WithArgs(now, 7).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE contact_merge").WithArgs(now, 7).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repository := ProvideMergesRepository(db, zap.NewNop())
+
+	if err := repository.MarkUndone(context.Background(), 7, now); err != nil {
+		t.Errorf("MarkUndone() returned an error %v, want nil", err)
+	}
+
+	if err := repository.MarkUndone(context.Background(), 7, now); !errors.Is(err, ErrMergeUndone) {
+		t.Errorf("MarkUndone() of an undone merge returned %v, want ErrMergeUndone", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled mock expectations: %v", err)
+	}
+}
+
+func TestMergeResolve(t *testing.T) {
+	target := &Contact{ID: 1, FirstName: "", LastName: "Hashibira"}
+	sources := []*Contact{{ID: 2, FirstName: "", LastName: "Pig"}, {ID: 3, FirstName: "Inosuke", LastName: "Boar"}}
+
+	var testCases = []struct {
+		testName string
+		data     MergeContactsData
+		first    string
+		last     string
+	}{
+		{"keep_target", MergeContactsData{}, "", "Hashibira"},
+		{"prefer_non_empty", MergeContactsData{Strategy: ResolutionPreferNonEmpty}, "Inosuke", "Hashibira"},
+		{"field_rule", MergeContactsData{Fields: map[string]Resolution{"first_name": ResolutionPreferNonEmpty}}, "Inosuke", "Hashibira"},
+		{"override", MergeContactsData{Strategy: ResolutionPreferNonEmpty, Overrides: map[string]string{"last_name": "Boar"}}, "Inosuke", "Boar"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			merged := tc.data.resolve(target, sources)
+
+			if merged.FirstName != tc.first || merged.LastName != tc.last {
+				t.Errorf("resolve() = %q %q, want %q %q", merged.FirstName, merged.LastName, tc.first, tc.last)
+			}
+		})
+	}
+}
+
+func TestMergeResolveOrganization(t *testing.T) {
+	target := &Contact{ID: 1, FirstName: "Inosuke", LastName: "Hashibira", Title: "Demon Slayer"}
+	sources := []*Contact{{ID: 2, FirstName: "Inosuke", LastName: "Boar", Organization: "Demon Slayer Corps", Title: "Hashira"}}
+
+	merged := MergeContactsData{Strategy: ResolutionPreferNonEmpty}.resolve(target, sources)
+
+	if merged.Organization != "Demon Slayer Corps" || merged.Title != "Demon Slayer" {
+		t.Errorf("resolve() = %+v, want the organization of the source and the title of the target", merged)
+	}
+}
+
+func TestServiceMergeContacts(t *testing.T) {
+	emails, phones, merges, revisions, auditor := &MockedEmailRepository{}, &MockedPhoneRepository{}, &MockedMergeRepository{}, &MockedRevisionRepository{}, &MockedAuditor{}
+	addresses := &MockedAddressRepository{}
+	service := ProvideContactsService(zap.NewNop(), &MockedContactsRepository{}, emails, phones, revisions,
+		&MockedAuthorizer{}, auditor, &MockedTransactor{}, testEngine, merges, testParser, addresses)
+
+	merge, merged, err := service.MergeContacts(context.Background(), MergeContactsData{
+		TargetID:  1,
+		SourceIDs: []int{2},
+		Overrides: map[string]string{"last_name": "Boar"},
+	}, 1)
+
+	if err != nil {
+		t.Fatalf("MergeContacts() returned an error %v, want nil", err)
+	}
+
+	if merged.FirstName != "Inosuke" || merged.LastName != "Boar" || merged.Version != 2 {
+		t.Errorf("MergeContacts() = %+v, want Inosuke Boar at version 2", merged)
+	}
+
+	// The phone 6 of contact 2 has the same number as the phone 1 of contact 1, so it isn't moved
+	expected := []MovedDetail{{Entity: "email", ID: 3, From: 2}, {Entity: "phone", ID: 5, From: 2}, {Entity: "address", ID: 2, From: 2}}
+	if !reflect.DeepEqual(merge.Moved, expected) {
+		t.Errorf("MergeContacts() moved %v, want %v", merge.Moved, expected)
+	}
+
+	if !reflect.DeepEqual(emails.reassigned, map[int]int{3: 1}) || !reflect.DeepEqual(phones.reassigned, map[int]int{5: 1}) {
+		t.Errorf("MergeContacts() reassigned emails %v and phones %v, want email 3 and phone 5 to contact 1", emails.reassigned, phones.reassigned)
+	}
+
+	if !reflect.DeepEqual(addresses.reassigned, map[int]int{2: 1}) {
+		t.Errorf("MergeContacts() reassigned addresses %v, want address 2 to contact 1", addresses.reassigned)
+	}
+
+	if merge.Target.LastName != "Hashibira" {
+		t.Errorf("MergeContacts() recorded the target %+v, want its state before the merge", merge.Target)
+	}
+
+	if merge.TargetVersion != merged.Version {
+		t.Errorf("MergeContacts() recorded the target version %d, want %d", merge.TargetVersion, merged.Version)
+	}
+
+	if expected, got := 2, len(revisions.revisions); expected != got {
+		t.Errorf("MergeContacts() saved %d revisions, want %d", got, expected)
+	}
+
+	if expected, got := 5, len(auditor.entries); expected != got {
+		t.Errorf("MergeContacts() recorded %d audit entries, want %d", got, expected)
+	}
+
+	// the mocked contacts repository doesn't keep the merged target, which is found at version 1 again,
+	// as if it was changed since the merge
+	if _, err := service.UndoMerge(context.Background(), merge.ID, 0); !errors.Is(err, ErrMergeOutdated) {
+		t.Errorf("UndoMerge() of a changed target returned %v, want ErrMergeOutdated", err)
+	}
+
+	merge.TargetVersion = 1
+
+	if _, err := service.UndoMerge(context.Background(), merge.ID, 5); !errors.Is(err, ErrVersionMismatch) {
+		t.Errorf("UndoMerge() with a stale version returned %v, want ErrVersionMismatch", err)
+	}
+
+	undone, err := service.UndoMerge(context.Background(), merge.ID, 1)
+	if err != nil {
+		t.Fatalf("UndoMerge() returned an error %v, want nil", err)
+	}
+
+	if undone.LastName != "Hashibira" {
+		t.Errorf("UndoMerge() = %+v, want the names before the merge", undone)
+	}
+
+	if !reflect.DeepEqual(emails.reassigned, map[int]int{3: 2}) || !reflect.DeepEqual(phones.reassigned, map[int]int{5: 2}) {
+		t.Errorf("UndoMerge() reassigned emails %v and phones %v, want email 3 and phone 5 back to contact 2", emails.reassigned, phones.reassigned)
+	}
+
+	if !reflect.DeepEqual(addresses.reassigned, map[int]int{2: 2}) {
+		t.Errorf("UndoMerge() reassigned addresses %v, want address 2 back to contact 2", addresses.reassigned)
+	}
+
+	if _, err := service.UndoMerge(context.Background(), merge.ID, 0); !errors.Is(err, ErrMergeUndone) {
+		t.Errorf("UndoMerge() of an undone merge returned %v, want ErrMergeUndone", err)
+	}
+
+	if _, err := service.UndoMerge(context.Background(), 42, 0); !errors.Is(err, ErrMergeNotFound) {
+		t.Errorf("UndoMerge() of a missing merge returned %v, want ErrMergeNotFound", err)
+	}
+}
+
+func TestServiceMergeContactsErrors(t *testing.T) {
+	var testCases = []struct {
+		testName string
+		data     MergeContactsData
+		version  int
+		err      error
+	}{
+		{"no_sources", MergeContactsData{TargetID: 1}, 0, ErrInvalidMerge},
+		{"target_in_sources", MergeContactsData{TargetID: 1, SourceIDs: []int{1}}, 0, ErrInvalidMerge},
+		{"repeated_source", MergeContactsData{TargetID: 1, SourceIDs: []int{2, 2}}, 0, ErrInvalidMerge},
+		{"unknown_strategy", MergeContactsData{TargetID: 1, SourceIDs: []int{2}, Strategy: "newest"}, 0, ErrInvalidMerge},
+		{"unknown_field", MergeContactsData{TargetID: 1, SourceIDs: []int{2}, Fields: map[string]Resolution{"id": ResolutionKeepTarget}}, 0, ErrInvalidMerge},
+		{"empty_override", MergeContactsData{TargetID: 1, SourceIDs: []int{2}, Overrides: map[string]string{"first_name": ""}}, 0, ErrInvalidMerge},
+		{"missing_source", MergeContactsData{TargetID: 1, SourceIDs: []int{42}}, 0, ErrContactNotFound},
+		{"stale_target", MergeContactsData{TargetID: 1, SourceIDs: []int{2}}, 5, ErrVersionMismatch},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			service := ProvideContactMockedService()
+
+			if _, _, err := service.MergeContacts(context.Background(), tc.data, tc.version); !errors.Is(err, tc.err) {
+				t.Errorf("MergeContacts() returned %v, want %v", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestMergeContactsController(t *testing.T) {
+	var testCases = []struct {
+		testName string
+		body     string
+		status   int
+	}{
+		{"merged", `{"target_id": 1, "source_ids": [2], "strategy": "prefer_non_empty"}`, http.StatusOK},
+		{"missing_sources", `{"target_id": 1}`, http.StatusBadRequest},
+		{"invalid_merge", `{"target_id": 1, "source_ids": [1]}`, http.StatusBadRequest},
+		{"missing_source", `{"target_id": 1, "source_ids": [42]}`, http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			e := echo.New()
+			e.Validator = validator.NewCustomValidator()
+			req := httptest.NewRequest(http.MethodPost, "/merge", bytes.NewBufferString(tc.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			req.Header.Set(HeaderIfMatch, "*")
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			controller := ProvideContactsController(ProvideContactMockedService(), &MockedContactsRepository{}, zap.NewNop(), e)
+
+			_ = controller.Merge(c)
+
+			if rec.Code != tc.status {
+				t.Errorf("Merge wrote respose status %d, want %d: %s", rec.Code, tc.status, rec.Body.String())
+			}
+
+			if tc.status == http.StatusOK && rec.Header().Get(HeaderETag) != `"2"` {
+				t.Errorf("Merge wrote the ETag %q, want %q", rec.Header().Get(HeaderETag), `"2"`)
+			}
+		})
+	}
+}
+
+func TestUndoMergeController(t *testing.T) {
+	var testCases = []struct {
+		testName      string
+		ifMatch       string
+		targetVersion int
+		movedTo       int
+		status        int
+	}{
+		{"undone", `"1"`, 1, 0, http.StatusOK},
+		{"any_version", "*", 1, 0, http.StatusOK},
+		{"missing_if_match", "", 1, 0, http.StatusPreconditionRequired},
+		{"stale_version", `"5"`, 1, 0, http.StatusPreconditionFailed},
+		{"changed_target", "*", 2, 0, http.StatusConflict},
+		{"moved_email", "*", 1, 9, http.StatusConflict},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			e := echo.New()
+			emails := &MockedEmailRepository{}
+			service := ProvideContactsService(zap.NewNop(), &MockedContactsRepository{}, emails, &MockedPhoneRepository{},
+				&MockedRevisionRepository{}, &MockedAuthorizer{}, &MockedAuditor{}, &MockedTransactor{}, testEngine,
+				&MockedMergeRepository{}, testParser, &MockedAddressRepository{})
+
+			merge, _, err := service.MergeContacts(context.Background(), MergeContactsData{TargetID: 1, SourceIDs: []int{2}}, 0)
+			if err != nil {
+				t.Fatalf("MergeContacts() returned an error %v, want nil", err)
+			}
+
+			// the mocked contacts repository doesn't keep the merged target, which is found at version 1 again
+			merge.TargetVersion = tc.targetVersion
+			if tc.movedTo != 0 {
+				emails.reassigned[3] = tc.movedTo
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/merges/1/undo", nil)
+			if tc.ifMatch != "" {
+				req.Header.Set(HeaderIfMatch, tc.ifMatch)
+			}
+
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetPath("/merges/:id/undo")
+			c.SetParamNames("id")
+			c.SetParamValues("1")
+
+			controller := ProvideContactsController(service, &MockedContactsRepository{}, zap.NewNop(), e)
+
+			_ = controller.UndoMerge(c)
+
+			if rec.Code != tc.status {
+				t.Errorf("UndoMerge wrote respose status %d, want %d: %s", rec.Code, tc.status, rec.Body.String())
+			}
+		})
+	}
+}
@@ -5,7 +5,9 @@
 package contacts
 
 import (
+	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -34,45 +36,134 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 }
 
 // FindAll mocks base method
-func (m *MockRepository) FindAll() ([]*Contact, error) {
+func (m *MockRepository) FindAll(ctx context.Context, f Filter) ([]*Contact, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindAll")
+	ret := m.ctrl.Call(m, "FindAll", ctx, f)
 	ret0, _ := ret[0].([]*Contact)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // FindAll indicates an expected call of FindAll
-func (mr *MockRepositoryMockRecorder) FindAll() *gomock.Call {
+func (mr *MockRepositoryMockRecorder) FindAll(ctx, f interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAll", reflect.TypeOf((*MockRepository)(nil).FindAll))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAll", reflect.TypeOf((*MockRepository)(nil).FindAll), ctx, f)
+}
+
+// FindDeleted mocks base method
+func (m *MockRepository) FindDeleted(ctx context.Context) ([]*Contact, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindDeleted", ctx)
+	ret0, _ := ret[0].([]*Contact)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindDeleted indicates an expected call of FindDeleted
+func (mr *MockRepositoryMockRecorder) FindDeleted(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindDeleted", reflect.TypeOf((*MockRepository)(nil).FindDeleted), ctx)
+}
+
+// FindByID mocks base method
+func (m *MockRepository) FindByID(ctx context.Context, id int) (*Contact, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByID", ctx, id)
+	ret0, _ := ret[0].(*Contact)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByID indicates an expected call of FindByID
+func (mr *MockRepositoryMockRecorder) FindByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByID", reflect.TypeOf((*MockRepository)(nil).FindByID), ctx, id)
 }
 
 // Create mocks base method
-func (m *MockRepository) Create(c Contact) (*Contact, error) {
+func (m *MockRepository) Create(ctx context.Context, c Contact) (*Contact, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", c)
+	ret := m.ctrl.Call(m, "Create", ctx, c)
 	ret0, _ := ret[0].(*Contact)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Create indicates an expected call of Create
-func (mr *MockRepositoryMockRecorder) Create(c interface{}) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) Create(ctx, c interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockRepository)(nil).Create), c)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockRepository)(nil).Create), ctx, c)
+}
+
+// Update mocks base method
+func (m *MockRepository) Update(ctx context.Context, c Contact, version int) (*Contact, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, c, version)
+	ret0, _ := ret[0].(*Contact)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update
+func (mr *MockRepositoryMockRecorder) Update(ctx, c, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockRepository)(nil).Update), ctx, c, version)
 }
 
 // DeleteByID mocks base method
-func (m *MockRepository) DeleteByID(id int) error {
+func (m *MockRepository) DeleteByID(ctx context.Context, id, version int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteByID", id)
+	ret := m.ctrl.Call(m, "DeleteByID", ctx, id, version)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteByID indicates an expected call of DeleteByID
-func (mr *MockRepositoryMockRecorder) DeleteByID(id interface{}) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) DeleteByID(ctx, id, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByID", reflect.TypeOf((*MockRepository)(nil).DeleteByID), ctx, id, version)
+}
+
+// Restore mocks base method
+func (m *MockRepository) Restore(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore
+func (mr *MockRepositoryMockRecorder) Restore(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockRepository)(nil).Restore), ctx, id)
+}
+
+// Purge mocks base method
+func (m *MockRepository) Purge(ctx context.Context, retention time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Purge", ctx, retention)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Purge indicates an expected call of Purge
+func (mr *MockRepositoryMockRecorder) Purge(ctx, retention interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Purge", reflect.TypeOf((*MockRepository)(nil).Purge), ctx, retention)
+}
+
+// Organizations mocks base method
+func (m *MockRepository) Organizations(ctx context.Context) ([]Organization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Organizations", ctx)
+	ret0, _ := ret[0].([]Organization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Organizations indicates an expected call of Organizations
+func (mr *MockRepositoryMockRecorder) Organizations(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByID", reflect.TypeOf((*MockRepository)(nil).DeleteByID), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Organizations", reflect.TypeOf((*MockRepository)(nil).Organizations), ctx)
 }
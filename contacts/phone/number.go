@@ -0,0 +1,96 @@
+package phone
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/LucasFrezarini/go-contacts/config"
+	"github.com/ttacon/libphonenumber"
+)
+
+// ErrInvalidNumber is returned when a phone number isn't valid, wrapped with the number
+var ErrInvalidNumber = errors.New("invalid phone number")
+
+// ErrInvalidType is returned when a phone type isn't one of Types, wrapped with the type
+var ErrInvalidType = errors.New("invalid phone type")
+
+// ErrInvalidLabel is returned when a phone label is too long, wrapped with the label
+var ErrInvalidLabel = errors.New("invalid phone label")
+
+// The maximum lengths of the number as it was provided and of the label
+const (
+	maxNumberLength = 30
+	maxLabelLength  = 50
+)
+
+// A Parser validates the phone numbers and finds their E.164 form
+type Parser struct {
+	// DefaultRegion is the region of the numbers provided without a country code
+	DefaultRegion string
+}
+
+// ProvideParser creates a new Parser with the configured default region.
+// Created especially for the use of Wire, which will inject the dependencies via DI
+func ProvideParser(cfg *config.Config) *Parser {
+	return &Parser{DefaultRegion: cfg.Phone.DefaultRegion}
+}
+
+// Parse validates the phone, whose number must have the length and prefix of a number of its region. The numbers
+// without a country code are taken as from the default region. It returns the phone trimmed, with the E.164 form
+// of its number and DefaultType as its type when it has none
+func (p *Parser) Parse(data CreatePhoneData) (CreatePhoneData, error) {
+	data.Number = strings.TrimSpace(data.Number)
+	data.Label = strings.TrimSpace(data.Label)
+
+	if data.Type == "" {
+		data.Type = DefaultType
+	}
+
+	if !IsValidType(data.Type) {
+		return CreatePhoneData{}, fmt.Errorf("%w: %q must be one of %s", ErrInvalidType, data.Type, strings.Join(Types, ", "))
+	}
+
+	if utf8.RuneCountInString(data.Label) > maxLabelLength {
+		return CreatePhoneData{}, fmt.Errorf("%w: %q is longer than %d characters", ErrInvalidLabel, data.Label, maxLabelLength)
+	}
+
+	if len(data.Number) > maxNumberLength {
+		return CreatePhoneData{}, fmt.Errorf("%w: %q is longer than %d characters", ErrInvalidNumber, data.Number, maxNumberLength)
+	}
+
+	number, err := libphonenumber.Parse(data.Number, p.DefaultRegion)
+	if err != nil || !libphonenumber.IsValidNumber(number) {
+		return CreatePhoneData{}, fmt.Errorf("%w: %q", ErrInvalidNumber, data.Number)
+	}
+
+	data.E164 = libphonenumber.Format(number, libphonenumber.E164)
+	return data, nil
+}
+
+// ParseAll parses all the phones of a contact
+func (p *Parser) ParseAll(phones ...CreatePhoneData) ([]CreatePhoneData, error) {
+	parsed := make([]CreatePhoneData, 0, len(phones))
+
+	for _, data := range phones {
+		data, err := p.Parse(data)
+		if err != nil {
+			return nil, err
+		}
+
+		parsed = append(parsed, data)
+	}
+
+	return parsed, nil
+}
+
+// format returns the national and international forms of the E.164 number, or empty strings when there's none
+func format(e164 string) (national string, international string) {
+	number, err := libphonenumber.Parse(e164, "")
+	if err != nil {
+		return "", ""
+	}
+
+	return libphonenumber.Format(number, libphonenumber.NATIONAL), libphonenumber.Format(number, libphonenumber.INTERNATIONAL)
+}
@@ -0,0 +1,114 @@
+package phone
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParserParse(t *testing.T) {
+	var testCases = []struct {
+		testName string
+		number   string
+		e164     string
+	}{
+		{"national", "1122223333", "+551122223333"},
+		{"formatted_national", "(11) 95555-4444", "+5511955554444"},
+		{"international", "+5511944445555", "+5511944445555"},
+		{"other_region", "+1 650-253-0000", "+16502530000"},
+		{"trimmed", "  +55 11 2222-3333 ", "+551122223333"},
+	}
+
+	parser := &Parser{DefaultRegion: "BR"}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			data, err := parser.Parse(CreatePhoneData{Number: tc.number, Type: PhoneTypeHome})
+			if err != nil {
+				t.Fatalf("Parse(%q) returned an error %v, want nil", tc.number, err)
+			}
+
+			if data.E164 != tc.e164 || data.Number != strings.TrimSpace(tc.number) || data.Type != PhoneTypeHome {
+				t.Errorf("Parse(%q) = %+v, want the number as provided with the E.164 form %q", tc.number, data, tc.e164)
+			}
+		})
+	}
+}
+
+func TestParserParseInvalid(t *testing.T) {
+	var testCases = []struct {
+		testName string
+		number   string
+	}{
+		{"empty", ""},
+		{"garbage", "not a number"},
+		{"too_short", "123"},
+		{"invalid_prefix", "33444445555"},
+		{"unknown_country_code", "+999 1234 5678"},
+		{"too_long", "+55 11 2222-3333 ext. 1234567890"},
+	}
+
+	parser := &Parser{DefaultRegion: "BR"}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			if data, err := parser.Parse(CreatePhoneData{Number: tc.number}); !errors.Is(err, ErrInvalidNumber) {
+				t.Errorf("Parse(%q) = %+v, %v, want ErrInvalidNumber", tc.number, data, err)
+			}
+		})
+	}
+}
+
+func TestParserParseTypeAndLabel(t *testing.T) {
+	parser := &Parser{DefaultRegion: "BR"}
+
+	data, err := parser.Parse(CreatePhoneData{Number: "11955554444", Label: "  zenitsu's crow "})
+	if err != nil {
+		t.Fatalf("Parse() returned an error %v, want nil", err)
+	}
+
+	if data.Type != DefaultType || data.Label != "zenitsu's crow" {
+		t.Errorf("Parse() = %+v, want the default type and the trimmed label", data)
+	}
+
+	if _, err := parser.Parse(CreatePhoneData{Number: "11955554444", Type: "pager"}); !errors.Is(err, ErrInvalidType) {
+		t.Errorf("Parse() of an unknown type returned %v, want ErrInvalidType", err)
+	}
+
+	if _, err := parser.Parse(CreatePhoneData{Number: "11955554444", Label: strings.Repeat("é", 51)}); !errors.Is(err, ErrInvalidLabel) {
+		t.Errorf("Parse() of a long label returned %v, want ErrInvalidLabel", err)
+	}
+}
+
+func TestPhoneMarshalJSON(t *testing.T) {
+	var testCases = []struct {
+		testName string
+		phone    Phone
+		expected string
+	}{
+		{
+			"formatted",
+			Phone{ID: 1, ContactID: 2, Type: PhoneTypeMobile, Number: "11955554444", E164: "+5511955554444", IsPrimary: true},
+			`{"id":1,"contact_id":2,"type":"mobile","number":"11955554444","e164":"+5511955554444","is_primary":true,"national":"(11) 95555-4444","international":"+55 11 95555-4444"}`,
+		},
+		{
+			"unparsed",
+			Phone{ID: 1, ContactID: 2, Type: PhoneTypeMobile, Number: "11955554444"},
+			`{"id":1,"contact_id":2,"type":"mobile","number":"11955554444","e164":"","is_primary":false}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			b, err := json.Marshal(tc.phone)
+			if err != nil {
+				t.Fatalf("json.Marshal() returned an error %v, want nil", err)
+			}
+
+			if string(b) != tc.expected {
+				t.Errorf("json.Marshal() = %s, want %s", b, tc.expected)
+			}
+		})
+	}
+}
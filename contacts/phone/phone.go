@@ -1,6 +1,10 @@
 package phone
 
-import "github.com/google/wire"
+import (
+	"encoding/json"
+
+	"github.com/google/wire"
+)
 
 // Available phone types
 const (
@@ -10,13 +14,49 @@ const (
 	PhoneTypeFax    = "fax"
 )
 
+// DefaultType is the type of the phones created without one
+const DefaultType = PhoneTypeMobile
+
+// Types are all the available phone types
+var Types = []string{PhoneTypeMobile, PhoneTypeHome, PhoneTypeWork, PhoneTypeFax}
+
+// IsValidType reports whether t is one of the available phone types
+func IsValidType(t string) bool {
+	for _, valid := range Types {
+		if t == valid {
+			return true
+		}
+	}
+
+	return false
+}
+
 // Phone represents a contact's phone entry
 type Phone struct {
 	ID        int    `json:"id,omitempty"`
 	ContactID int    `json:"contact_id"`
 	Type      string `json:"type"`
-	Number    string `json:"number"`
+	// Number is the phone number as it was provided
+	Number string `json:"number"`
+	// E164 is the canonical form of the number, empty for the numbers stored before they were parsed
+	E164 string `json:"e164"`
+	// Label is a name given by the user to the phone, like "office reception", on top of its type
+	Label string `json:"label,omitempty"`
+	// IsPrimary tells whether it's the phone to use for the contact, which has at most one primary phone
+	IsPrimary bool `json:"is_primary"`
+}
+
+// MarshalJSON encodes the phone along with the national and international formats of its number
+func (p Phone) MarshalJSON() ([]byte, error) {
+	type phone Phone
+	national, international := format(p.E164)
+
+	return json.Marshal(struct {
+		phone
+		National      string `json:"national,omitempty"`
+		International string `json:"international,omitempty"`
+	}{phone(p), national, international})
 }
 
 // Set is a Wire set that contains all the providers for this package
-var Set = wire.NewSet(RepositorySet)
+var Set = wire.NewSet(RepositorySet, ProvideParser)
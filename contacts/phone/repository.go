@@ -1,26 +1,41 @@
 package phone
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 
+	"github.com/LucasFrezarini/go-contacts/db"
 	"github.com/google/wire"
 	"go.uber.org/zap"
 )
 
 // CreatePhoneData defines the fields that need to be provided in order to create a new
-// phone record in the database
+// phone record in the database. The type is DefaultType when empty
 type CreatePhoneData struct {
-	Number string `json:"number"`
-	Type   string `json:"type"`
+	Number string `json:"number" validate:"required"`
+	Type   string `json:"type" validate:"omitempty,phone_type"`
+	Label  string `json:"label" validate:"max=50"`
+	E164   string `json:"-"`
+	// IsPrimary is decided by the service, phones are promoted through their own endpoint
+	IsPrimary bool `json:"-"`
 }
 
+// ErrPhoneNotFound is returned when a phone doesn't exist or belongs to another contact
+var ErrPhoneNotFound = errors.New("phone not found")
+
 // GenericRepository defines the structure of this package's repository
 // Defined especially to allow mocking in unit testing
 type GenericRepository interface {
-	FindByContactID(id int) ([]Phone, error)
-	Create(contactID int, phones ...CreatePhoneData) ([]Phone, error)
+	FindByContactID(ctx context.Context, id int) ([]Phone, error)
+	FindByID(ctx context.Context, contactID int, id int) (*Phone, error)
+	Create(ctx context.Context, contactID int, phones ...CreatePhoneData) ([]Phone, error)
+	Update(ctx context.Context, contactID int, id int, data CreatePhoneData) (*Phone, error)
+	DeleteByID(ctx context.Context, contactID int, id int) error
+	DeleteByContactID(ctx context.Context, contactID int) error
+	Reassign(ctx context.Context, id int, from int, to int) error
+	SetPrimary(ctx context.Context, contactID int, id int) error
 }
 
 // Repository contains all the persistence related methods for the phone entity
@@ -35,10 +50,10 @@ func ProvideRepository(db *sql.DB, logger *zap.Logger) *Repository {
 	return &Repository{db, logger.Named("PhoneRepository")}
 }
 
-// FindByContactID returns all the phones registered for the provided contact id
-func (r *Repository) FindByContactID(id int) ([]Phone, error) {
-	raw := "SELECT id, contact_id, number, type FROM phone WHERE contact_id = ?"
-	rows, err := r.DB.Query(raw, id)
+// FindByContactID returns all the phones registered for the provided contact id, in the order they were created
+func (r *Repository) FindByContactID(ctx context.Context, id int) ([]Phone, error) {
+	raw := "SELECT id, contact_id, number, e164, type, label, is_primary FROM phone WHERE contact_id = ? ORDER BY id"
+	rows, err := db.Conn(ctx, r.DB).QueryContext(ctx, raw, id)
 
 	if err != nil {
 		msg := fmt.Sprintf("FindByContactID(%d): error while executing query: %v", id, err)
@@ -52,7 +67,7 @@ func (r *Repository) FindByContactID(id int) ([]Phone, error) {
 	for rows.Next() {
 		var phone Phone
 
-		if err := rows.Scan(&phone.ID, &phone.ContactID, &phone.Number, &phone.Type); err != nil {
+		if err := rows.Scan(&phone.ID, &phone.ContactID, &phone.Number, &phone.E164, &phone.Type, &phone.Label, &phone.IsPrimary); err != nil {
 			msg := fmt.Sprintf("FindByContactID(%d): error while scanning rows: %v", id, err)
 			r.Logger.Error(msg)
 			return nil, errors.New(msg)
@@ -64,12 +79,32 @@ func (r *Repository) FindByContactID(id int) ([]Phone, error) {
 	return phones, nil
 }
 
+// FindByID returns the phone with the provided id, as long as it belongs to the contact with the provided
+// contactID. Otherwise, ErrPhoneNotFound is returned
+func (r *Repository) FindByID(ctx context.Context, contactID int, id int) (*Phone, error) {
+	raw := "SELECT id, contact_id, number, e164, type, label, is_primary FROM phone WHERE id = ? AND contact_id = ?"
+
+	var phone Phone
+	err := db.Conn(ctx, r.DB).QueryRowContext(ctx, raw, id, contactID).
+		Scan(&phone.ID, &phone.ContactID, &phone.Number, &phone.E164, &phone.Type, &phone.Label, &phone.IsPrimary)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("FindByID(%d, %d): %w", contactID, id, ErrPhoneNotFound)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("FindByID(%d, %d): error while executing query: %w", contactID, id, err)
+	}
+
+	return &phone, nil
+}
+
 // Create creates new phones registred for the provided ContactID
-func (r *Repository) Create(contactID int, phones ...CreatePhoneData) ([]Phone, error) {
+func (r *Repository) Create(ctx context.Context, contactID int, phones ...CreatePhoneData) ([]Phone, error) {
 	insertedPhones := make([]Phone, 0, len(phones))
 
 	for _, data := range phones {
-		phone, err := r.createSinglePhone(contactID, data)
+		phone, err := r.createSinglePhone(ctx, contactID, data)
 		if err != nil {
 			msg := fmt.Sprintf("Create: error while creating phone: %v", err)
 			r.Logger.Error(msg)
@@ -82,9 +117,9 @@ func (r *Repository) Create(contactID int, phones ...CreatePhoneData) ([]Phone,
 	return insertedPhones, nil
 }
 
-func (r *Repository) createSinglePhone(contactID int, phone CreatePhoneData) (Phone, error) {
-	raw := "INSERT INTO phone (contact_id, type, number) VALUES (?, ?, ?)"
-	stmt, err := r.DB.Prepare(raw)
+func (r *Repository) createSinglePhone(ctx context.Context, contactID int, phone CreatePhoneData) (Phone, error) {
+	raw := "INSERT INTO phone (contact_id, type, number, e164, label, is_primary) VALUES (?, ?, ?, ?, ?, ?)"
+	stmt, err := db.Conn(ctx, r.DB).PrepareContext(ctx, raw)
 
 	if err != nil {
 		return Phone{}, fmt.Errorf("createSinglePhone: error while preparing statement: %w", err)
@@ -92,7 +127,7 @@ func (r *Repository) createSinglePhone(contactID int, phone CreatePhoneData) (Ph
 
 	defer stmt.Close()
 
-	result, err := stmt.Exec(contactID, phone.Type, phone.Number)
+	result, err := stmt.ExecContext(ctx, contactID, phone.Type, phone.Number, phone.E164, phone.Label, phone.IsPrimary)
 	if err != nil {
 		return Phone{}, fmt.Errorf("createSinglePhone: error while executing statement: %w", err)
 	}
@@ -107,10 +142,91 @@ func (r *Repository) createSinglePhone(contactID int, phone CreatePhoneData) (Ph
 		ContactID: contactID,
 		Type:      phone.Type,
 		Number:    phone.Number,
+		E164:      phone.E164,
+		Label:     phone.Label,
+		IsPrimary: phone.IsPrimary,
 	}, nil
 
 }
 
+// Update changes the phone with the provided id, as long as it belongs to the contact with the provided
+// contactID. The caller is responsible for checking that the phone exists, since MySQL doesn't report
+// the rows matched by an update that changes nothing. Whether the phone is primary doesn't change
+func (r *Repository) Update(ctx context.Context, contactID int, id int, data CreatePhoneData) (*Phone, error) {
+	raw := "UPDATE phone SET type = ?, number = ?, e164 = ?, label = ? WHERE id = ? AND contact_id = ?"
+
+	if _, err := db.Conn(ctx, r.DB).ExecContext(ctx, raw, data.Type, data.Number, data.E164, data.Label, id, contactID); err != nil {
+		return nil, fmt.Errorf("Update(%d, %d): error while executing the update query: %w", contactID, id, err)
+	}
+
+	return &Phone{ID: id, ContactID: contactID, Type: data.Type, Number: data.Number, E164: data.E164, Label: data.Label}, nil
+}
+
+// DeleteByID deletes the phone with the provided id, as long as it belongs to the contact with the provided
+// contactID. Otherwise, ErrPhoneNotFound is returned
+func (r *Repository) DeleteByID(ctx context.Context, contactID int, id int) error {
+	result, err := db.Conn(ctx, r.DB).ExecContext(ctx, "DELETE FROM phone WHERE id = ? AND contact_id = ?", id, contactID)
+	if err != nil {
+		return fmt.Errorf("DeleteByID(%d, %d): error while executing the delete query: %w", contactID, id, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("DeleteByID(%d, %d): error while fetching the affected rows: %w", contactID, id, err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("DeleteByID(%d, %d): %w", contactID, id, ErrPhoneNotFound)
+	}
+
+	return nil
+}
+
+// DeleteByContactID deletes all the phones of the contact with the provided id
+func (r *Repository) DeleteByContactID(ctx context.Context, contactID int) error {
+	if _, err := db.Conn(ctx, r.DB).ExecContext(ctx, "DELETE FROM phone WHERE contact_id = ?", contactID); err != nil {
+		return fmt.Errorf("DeleteByContactID(%d): error while executing the delete query: %w", contactID, err)
+	}
+
+	return nil
+}
+
+// Reassign moves the phone with the provided id from the contact from to the contact to. It's no longer primary,
+// since the other contact may already have a primary phone. When the phone doesn't belong to the contact from,
+// ErrPhoneNotFound is returned
+func (r *Repository) Reassign(ctx context.Context, id int, from int, to int) error {
+	raw := "UPDATE phone SET contact_id = ?, is_primary = FALSE WHERE id = ? AND contact_id = ?"
+
+	result, err := db.Conn(ctx, r.DB).ExecContext(ctx, raw, to, id, from)
+	if err != nil {
+		return fmt.Errorf("Reassign(%d, %d, %d): error while executing the update query: %w", id, from, to, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("Reassign(%d, %d, %d): error while fetching the affected rows: %w", id, from, to, err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("Reassign(%d, %d, %d): %w", id, from, to, ErrPhoneNotFound)
+	}
+
+	return nil
+}
+
+// SetPrimary makes the phone with the provided id the primary one of the contact with the provided contactID,
+// and the other phones of the contact no longer primary, all at once. The caller is responsible for checking
+// that the phone belongs to the contact, otherwise the contact is left without a primary phone
+func (r *Repository) SetPrimary(ctx context.Context, contactID int, id int) error {
+	raw := "UPDATE phone SET is_primary = (id = ?) WHERE contact_id = ?"
+
+	if _, err := db.Conn(ctx, r.DB).ExecContext(ctx, raw, id, contactID); err != nil {
+		return fmt.Errorf("SetPrimary(%d, %d): error while executing the update query: %w", contactID, id, err)
+	}
+
+	return nil
+}
+
 // RepositorySet is the wire set that contains all the provides for this repository
 var RepositorySet = wire.NewSet(
 	ProvideRepository,
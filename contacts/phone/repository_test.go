@@ -1,6 +1,8 @@
 package phone
 
 import (
+	"context"
+	"errors"
 	"reflect"
 	"testing"
 
@@ -18,22 +20,22 @@ func TestFindByContactID(t *testing.T) {
 
 	contactID := 2
 	expectedPhones := []Phone{
-		Phone{ID: 1, ContactID: contactID, Number: "1122223333", Type: PhoneTypeHome},
-		Phone{ID: 2, ContactID: contactID, Number: "33444445555", Type: PhoneTypeMobile},
-		Phone{ID: 3, ContactID: contactID, Number: "+5511911112222", Type: PhoneTypeWork},
-		Phone{ID: 4, ContactID: contactID, Number: "+5511933332222", Type: PhoneTypeFax},
+		Phone{ID: 1, ContactID: contactID, Number: "1122223333", E164: "+551122223333", Type: PhoneTypeHome, IsPrimary: true},
+		Phone{ID: 2, ContactID: contactID, Number: "33444445555", Type: PhoneTypeMobile, Label: "old pager"},
+		Phone{ID: 3, ContactID: contactID, Number: "+5511911112222", E164: "+5511911112222", Type: PhoneTypeWork},
+		Phone{ID: 4, ContactID: contactID, Number: "+5511933332222", E164: "+5511933332222", Type: PhoneTypeFax},
 	}
 
-	rows := sqlmock.NewRows([]string{"id", "contact_id", "number", "type"})
+	rows := sqlmock.NewRows([]string{"id", "contact_id", "number", "e164", "type", "label", "is_primary"})
 
 	for _, p := range expectedPhones {
-		rows.AddRow(p.ID, p.ContactID, p.Number, p.Type)
+		rows.AddRow(p.ID, p.ContactID, p.Number, p.E164, p.Type, p.Label, p.IsPrimary)
 	}
 
 	mock.ExpectQuery("SELECT (.+) FROM phone").WithArgs(contactID).WillReturnRows(rows).RowsWillBeClosed()
 
 	repository := ProvideRepository(db, zap.NewNop())
-	phones, err := repository.FindByContactID(contactID)
+	phones, err := repository.FindByContactID(context.Background(), contactID)
 
 	if err != nil {
 		t.Errorf("FindByContactID(%d) returned an error: '%v', want nil", contactID, err)
@@ -64,18 +66,18 @@ func TestPhoneCreate(t *testing.T) {
 
 	contactID := 2
 	phonesData := []CreatePhoneData{
-		CreatePhoneData{Number: "1122223333", Type: PhoneTypeHome},
-		CreatePhoneData{Number: "33444445555", Type: PhoneTypeMobile},
-		CreatePhoneData{Number: "+5511911112222", Type: PhoneTypeWork},
-		CreatePhoneData{Number: "+5511933332222", Type: PhoneTypeFax},
+		CreatePhoneData{Number: "1122223333", Type: PhoneTypeHome, E164: "+551122223333", IsPrimary: true},
+		CreatePhoneData{Number: "(11) 94444-5555", Type: PhoneTypeMobile, E164: "+5511944445555"},
+		CreatePhoneData{Number: "+5511911112222", Type: PhoneTypeWork, Label: "reception", E164: "+5511911112222"},
+		CreatePhoneData{Number: "+5511933332222", Type: PhoneTypeFax, E164: "+5511933332222"},
 	}
 
 	for i, phoneData := range phonesData {
-		mock.ExpectPrepare("INSERT INTO phone").ExpectExec().WithArgs(contactID, phoneData.Type, phoneData.Number).WillReturnResult(sqlmock.NewResult(int64(i+1), 1))
+		mock.ExpectPrepare("INSERT INTO phone").ExpectExec().WithArgs(contactID, phoneData.Type, phoneData.Number, phoneData.E164, phoneData.Label, phoneData.IsPrimary).WillReturnResult(sqlmock.NewResult(int64(i+1), 1))
 	}
 
 	repository := ProvideRepository(db, zap.NewNop())
-	insertedPhones, err := repository.Create(contactID, phonesData...)
+	insertedPhones, err := repository.Create(context.Background(), contactID, phonesData...)
 	if err != nil {
 		t.Errorf("Create(%d, %v) returned a non-nil error '%v', want nil", contactID, phonesData, err)
 	}
@@ -92,9 +94,168 @@ func TestPhoneCreate(t *testing.T) {
 		if expected, got := contactID, p.ContactID; expected != got {
 			t.Errorf("Create(%d, %v) phone[%d].ContactID == %d, want %d", contactID, phonesData, i, got, expected)
 		}
+
+		if expected, got := phonesData[i].IsPrimary, p.IsPrimary; expected != got {
+			t.Errorf("Create(%d, %v) phone[%d].IsPrimary == %t, want %t", contactID, phonesData, i, got, expected)
+		}
 	}
 
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("Create(%d, %v) mock expectations weren't met: %v", contactID, phonesData, err)
 	}
 }
+
+func TestDeleteByContactID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	contactID := 2
+
+	mock.ExpectExec("DELETE FROM phone WHERE contact_id = (.+)").WithArgs(contactID).WillReturnResult(sqlmock.NewResult(0, 2))
+
+	repository := ProvideRepository(db, zap.NewNop())
+
+	if err := repository.DeleteByContactID(context.Background(), contactID); err != nil {
+		t.Errorf("DeleteByContactID(%d) returned a non-nil error '%v', want nil", contactID, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("DeleteByContactID(%d): unfulfilled mock expectations: %v", contactID, err)
+	}
+}
+
+func TestReassign(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	id, from, to := 3, 2, 1
+
+	mock.ExpectExec("UPDATE phone SET contact_id = (.+), is_primary = FALSE WHERE id = (.+) AND contact_id = (.+)").WithArgs(to, id, from).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE phone").WithArgs(to, id, from).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repository := ProvideRepository(db, zap.NewNop())
+
+	if err := repository.Reassign(context.Background(), id, from, to); err != nil {
+		t.Errorf("Reassign(%d, %d, %d) returned a non-nil error '%v', want nil", id, from, to, err)
+	}
+
+	// the phone was moved to another contact since
+	if err := repository.Reassign(context.Background(), id, from, to); !errors.Is(err, ErrPhoneNotFound) {
+		t.Errorf("Reassign(%d, %d, %d) returned %v, want %v", id, from, to, err, ErrPhoneNotFound)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Reassign(%d, %d, %d): unfulfilled mock expectations: %v", id, from, to, err)
+	}
+}
+
+func TestPhoneFindByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	cols := []string{"id", "contact_id", "number", "e164", "type", "label", "is_primary"}
+	expected := &Phone{ID: 3, ContactID: 2, Number: "11 91111-2222", E164: "+5511911112222", Type: PhoneTypeWork, Label: "reception", IsPrimary: true}
+
+	mock.ExpectQuery("SELECT (.+) FROM phone WHERE id = (.+) AND contact_id = (.+)").WithArgs(3, 2).
+		WillReturnRows(sqlmock.NewRows(cols).AddRow(3, 2, "11 91111-2222", "+5511911112222", PhoneTypeWork, "reception", true))
+	mock.ExpectQuery("SELECT (.+) FROM phone").WithArgs(3, 1).WillReturnRows(sqlmock.NewRows(cols))
+
+	repository := ProvideRepository(db, zap.NewNop())
+
+	if p, err := repository.FindByID(context.Background(), 2, 3); err != nil || !reflect.DeepEqual(p, expected) {
+		t.Errorf("FindByID(2, 3) = %v, %v, want %v", p, err, expected)
+	}
+
+	if _, err := repository.FindByID(context.Background(), 1, 3); !errors.Is(err, ErrPhoneNotFound) {
+		t.Errorf("FindByID(1, 3) of a phone of another contact returned %v, want ErrPhoneNotFound", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("FindByID(): unfulfilled mock expectations: %v", err)
+	}
+}
+
+func TestPhoneUpdate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	data := CreatePhoneData{Number: "11 91111-2222", E164: "+5511911112222", Type: PhoneTypeWork, Label: "reception"}
+
+	mock.ExpectExec("UPDATE phone SET type = (.+), number = (.+), e164 = (.+), label = (.+) WHERE id = (.+) AND contact_id = (.+)").
+		WithArgs(data.Type, data.Number, data.E164, data.Label, 3, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	repository := ProvideRepository(db, zap.NewNop())
+	p, err := repository.Update(context.Background(), 2, 3, data)
+
+	expected := &Phone{ID: 3, ContactID: 2, Number: data.Number, E164: data.E164, Type: data.Type, Label: data.Label}
+	if err != nil || !reflect.DeepEqual(p, expected) {
+		t.Errorf("Update(2, 3) = %v, %v, want %v", p, err, expected)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Update(): unfulfilled mock expectations: %v", err)
+	}
+}
+
+func TestPhoneDeleteByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	mock.ExpectExec("DELETE FROM phone WHERE id = (.+) AND contact_id = (.+)").WithArgs(3, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM phone").WithArgs(3, 1).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repository := ProvideRepository(db, zap.NewNop())
+
+	if err := repository.DeleteByID(context.Background(), 2, 3); err != nil {
+		t.Errorf("DeleteByID(2, 3) returned %v, want nil", err)
+	}
+
+	if err := repository.DeleteByID(context.Background(), 1, 3); !errors.Is(err, ErrPhoneNotFound) {
+		t.Errorf("DeleteByID(1, 3) of a phone of another contact returned %v, want ErrPhoneNotFound", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("DeleteByID(): unfulfilled mock expectations: %v", err)
+	}
+}
+
+func TestPhoneSetPrimary(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	mock.ExpectExec("UPDATE phone SET is_primary = \\(id = (.+)\\) WHERE contact_id = (.+)").WithArgs(3, 2).WillReturnResult(sqlmock.NewResult(0, 2))
+
+	repository := ProvideRepository(db, zap.NewNop())
+
+	if err := repository.SetPrimary(context.Background(), 2, 3); err != nil {
+		t.Errorf("SetPrimary(2, 3) returned %v, want nil", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("SetPrimary(): unfulfilled mock expectations: %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package contacts
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/LucasFrezarini/go-contacts/config"
+	"go.uber.org/zap"
+)
+
+// A PurgeJob permanently removes the contacts that stayed in the trash for longer than the retention period
+type PurgeJob struct {
+	repository Repository
+	retention  time.Duration
+	interval   time.Duration
+	logger     *zap.Logger
+}
+
+// ProvidePurgeJob creates a new PurgeJob with the trash configuration.
+// Created especially for the use of Wire, who will inject the dependencies via DI
+func ProvidePurgeJob(cfg *config.Config, r Repository, logger *zap.Logger) *PurgeJob {
+	return &PurgeJob{r, cfg.Trash.Retention, cfg.Trash.PurgeInterval, logger.Named("PurgeJob")}
+}
+
+// Run purges the trash right away and then on every interval, until ctx is done.
+// Nothing is ever purged when the retention is 0
+func (j *PurgeJob) Run(ctx context.Context) {
+	if j.retention <= 0 {
+		j.logger.Info("trash retention is disabled, deleted contacts will be kept forever")
+		return
+	}
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		j.Purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge removes the expired contacts from the trash once
+func (j *PurgeJob) Purge(ctx context.Context) {
+	purged, err := j.repository.Purge(ctx, j.retention)
+	if err != nil {
+		j.logger.Error(fmt.Sprintf("error while purging the trash: %v", err))
+		return
+	}
+
+	if purged != 0 {
+		j.logger.Info(fmt.Sprintf("purged %d contacts deleted more than %v ago", purged, j.retention))
+	}
+}
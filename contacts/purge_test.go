@@ -0,0 +1,50 @@
+package contacts
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/LucasFrezarini/go-contacts/config"
+	"github.com/golang/mock/gomock"
+	"go.uber.org/zap"
+)
+
+func TestPurgeJobRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	retention := 72 * time.Hour
+	ctx, cancel := context.WithCancel(context.Background())
+
+	repository := NewMockRepository(ctrl)
+	repository.EXPECT().Purge(gomock.Any(), gomock.Eq(retention)).DoAndReturn(func(context.Context, time.Duration) (int64, error) {
+		cancel()
+		return 2, nil
+	})
+
+	cfg := &config.Config{Trash: config.Trash{Retention: retention, PurgeInterval: time.Hour}}
+	done := make(chan struct{})
+
+	go func() {
+		ProvidePurgeJob(cfg, repository, zap.NewNop()).Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("PurgeJob.Run() didn't return after its context was canceled")
+	}
+}
+
+func TestPurgeJobDisabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// with no retention, the repository must never be called
+	repository := NewMockRepository(ctrl)
+	cfg := &config.Config{Trash: config.Trash{Retention: 0, PurgeInterval: time.Hour}}
+
+	ProvidePurgeJob(cfg, repository, zap.NewNop()).Run(context.Background())
+}
@@ -1,19 +1,118 @@
 package contacts
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/LucasFrezarini/go-contacts/addressbook"
+	"github.com/LucasFrezarini/go-contacts/auth"
+	"github.com/LucasFrezarini/go-contacts/contacts/email"
+	"github.com/LucasFrezarini/go-contacts/db"
 	"github.com/google/wire"
 	"go.uber.org/zap"
 )
 
+// ErrContactNotFound is returned when a contact doesn't exist or belongs to another owner
+var ErrContactNotFound = errors.New("contact not found")
+
+// ErrVersionMismatch is returned when a contact was changed since the version the caller expected it to be
+var ErrVersionMismatch = errors.New("contact version mismatch")
+
+// ErrNoPrincipal is returned when a repository is used without an authenticated principal in the context
+var ErrNoPrincipal = errors.New("no authenticated principal in context")
+
 // Repository defines the structure of a generic contact repository
-// this interface was created to facilitate the mocking in the unit tests
+// this interface was created to facilitate the mocking in the unit tests.
+// Every method but Purge is scoped to the contacts visible in the context: the private contacts of the
+// principal or, when the context targets an address book, the contacts of that book
 type Repository interface {
-	FindAll() ([]*Contact, error)
-	Create(c Contact) (*Contact, error)
-	DeleteByID(id int) error
+	FindAll(ctx context.Context, f Filter) ([]*Contact, error)
+	FindDeleted(ctx context.Context) ([]*Contact, error)
+	FindByID(ctx context.Context, id int) (*Contact, error)
+	Create(ctx context.Context, c Contact) (*Contact, error)
+	Update(ctx context.Context, c Contact, version int) (*Contact, error)
+	DeleteByID(ctx context.Context, id int, version int) error
+	Restore(ctx context.Context, id int) error
+	Purge(ctx context.Context, retention time.Duration) (int64, error)
+	Organizations(ctx context.Context) ([]Organization, error)
+}
+
+// ErrInvalidSort is returned when the contacts are listed in an unknown order, wrapped with the order
+var ErrInvalidSort = errors.New("invalid sort")
+
+// A Sort is the order of the contacts listed by FindAll
+type Sort string
+
+// The orders of the contacts listed by FindAll. The contacts without an organization are always listed last,
+// and the ones of the same organization are sorted by their names
+const (
+	// SortNone lists the contacts in no particular order
+	SortNone             Sort = ""
+	SortOrganization     Sort = "organization"
+	SortOrganizationDesc Sort = "-organization"
+)
+
+// orderBy are the ORDER BY clauses of the known sorts
+var orderBy = map[Sort]string{
+	SortNone:             "",
+	SortOrganization:     " ORDER BY organization = '', organization, last_name, first_name, id",
+	SortOrganizationDesc: " ORDER BY organization = '', organization DESC, last_name, first_name, id",
+}
+
+// Filter narrows down and orders the contacts listed by FindAll. Its zero value lists them all
+type Filter struct {
+	// EmailLabel keeps the contacts that have an email with this label
+	EmailLabel string
+	// Organization keeps the contacts that work for this organization
+	Organization string
+	Sort         Sort
+}
+
+// normalize checks that the sort of the filter is a known one, returning an error wrapping ErrInvalidSort if it
+// isn't, and parses the email label like the labels of the emails are, so "Work" matches the "work" emails
+func (f Filter) normalize() (Filter, error) {
+	if _, ok := orderBy[f.Sort]; !ok {
+		return f, fmt.Errorf("%w: contacts can't be sorted by %q", ErrInvalidSort, f.Sort)
+	}
+
+	if strings.TrimSpace(f.EmailLabel) != "" {
+		label, err := email.ParseLabel(f.EmailLabel)
+		if err != nil {
+			return f, err
+		}
+
+		f.EmailLabel = label
+	}
+
+	return f, nil
+}
+
+// condition returns the condition restricting a query to the contacts that match the filter, along with its args
+func (f Filter) condition() (string, []interface{}) {
+	cond, args := "deleted_at IS NULL", make([]interface{}, 0)
+
+	if f.EmailLabel != "" {
+		cond += " AND EXISTS (SELECT 1 FROM email e WHERE e.contact_id = contact.id AND e.label = ?)"
+		args = append(args, f.EmailLabel)
+	}
+
+	if f.Organization != "" {
+		cond += " AND organization = ?"
+		args = append(args, f.Organization)
+	}
+
+	return cond, args
+}
+
+// An Organization is a company some of the contacts work for
+type Organization struct {
+	Name string `json:"name"`
+	// Contacts is how many contacts outside the trash work for the organization
+	Contacts int `json:"contacts"`
 }
 
 type ContactsRepository struct {
@@ -25,12 +124,87 @@ func ProvideContactsRepository(db *sql.DB, logger *zap.Logger) *ContactsReposito
 	return &ContactsRepository{DB: db, Logger: logger.Named("ContactsRepository")}
 }
 
-func (r *ContactsRepository) FindAll() ([]*Contact, error) {
-	stmt := `SELECT id, first_name, last_name FROM contact`
-	rows, err := r.DB.Query(stmt)
+// ownerFromContext returns the subject of the principal in ctx, which owns the contacts
+func ownerFromContext(ctx context.Context) (string, error) {
+	p, ok := auth.FromContext(ctx)
+	if !ok || p.Subject == "" {
+		return "", ErrNoPrincipal
+	}
+
+	return p.Subject, nil
+}
+
+// scope returns the condition restricting a query to the contacts visible in ctx. Access to a book is
+// checked again here, so a query can never reach a book the principal isn't a member of
+func scope(ctx context.Context) (string, []interface{}, error) {
+	owner, err := ownerFromContext(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if id, ok := addressbook.BookIDFromContext(ctx); ok {
+		cond := "address_book_id = ? AND EXISTS (SELECT 1 FROM address_book_member m WHERE m.address_book_id = contact.address_book_id AND m.subject = ?)"
+		return cond, []interface{}{id, owner}, nil
+	}
+
+	return "owner = ? AND address_book_id IS NULL", []interface{}{owner}, nil
+}
+
+// FindAll returns the contacts visible in the context that match the filter, except the ones in the trash,
+// in the order of the filter
+func (r *ContactsRepository) FindAll(ctx context.Context, f Filter) ([]*Contact, error) {
+	f, err := f.normalize()
+	if err != nil {
+		return nil, fmt.Errorf("FindAll(): %w", err)
+	}
+
+	cond, args := f.condition()
+
+	contacts, err := r.find(ctx, cond, orderBy[f.Sort], args...)
+	if err != nil {
+		return nil, fmt.Errorf("FindAll(): %w", err)
+	}
+
+	return contacts, nil
+}
+
+// FindDeleted returns the contacts visible in the context that are in the trash
+func (r *ContactsRepository) FindDeleted(ctx context.Context) ([]*Contact, error) {
+	contacts, err := r.find(ctx, "deleted_at IS NOT NULL", "")
+	if err != nil {
+		return nil, fmt.Errorf("FindDeleted(): %w", err)
+	}
+
+	return contacts, nil
+}
+
+// FindByID returns the contact visible in the context with the provided ID, even if it's in the trash.
+// If there's no such contact, ErrContactNotFound is returned
+func (r *ContactsRepository) FindByID(ctx context.Context, id int) (*Contact, error) {
+	contacts, err := r.find(ctx, "id = ?", "", id)
+	if err != nil {
+		return nil, fmt.Errorf("FindByID(%d): %w", id, err)
+	}
+
+	if len(contacts) == 0 {
+		return nil, fmt.Errorf("FindByID(%d): %w", id, ErrContactNotFound)
+	}
+
+	return contacts[0], nil
+}
+
+func (r *ContactsRepository) find(ctx context.Context, filter string, order string, filterArgs ...interface{}) ([]*Contact, error) {
+	cond, args, err := scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt := `SELECT id, address_book_id, first_name, last_name, organization, department, title, deleted_at, version
+		FROM contact WHERE ` + filter + " AND " + cond + order
+	rows, err := db.Conn(ctx, r.DB).QueryContext(ctx, stmt, append(filterArgs, args...)...)
 
 	if err != nil {
-		return nil, fmt.Errorf("FindAll(): error while fetching contacts: %w", err)
+		return nil, fmt.Errorf("error while fetching contacts: %w", err)
 	}
 
 	defer rows.Close()
@@ -38,9 +212,21 @@ func (r *ContactsRepository) FindAll() ([]*Contact, error) {
 
 	for rows.Next() {
 		var contact Contact
+		var bookID sql.NullInt64
+		var deletedAt sql.NullTime
+
+		if err := rows.Scan(&contact.ID, &bookID, &contact.FirstName, &contact.LastName, &contact.Organization,
+			&contact.Department, &contact.Title, &deletedAt, &contact.Version); err != nil {
+			return nil, fmt.Errorf("error while scanning rows: %w", err)
+		}
+
+		if bookID.Valid {
+			id := int(bookID.Int64)
+			contact.AddressBookID = &id
+		}
 
-		if err := rows.Scan(&contact.ID, &contact.FirstName, &contact.LastName); err != nil {
-			return nil, fmt.Errorf("FindAll(): error while scanning rows: %w", err)
+		if deletedAt.Valid {
+			contact.DeletedAt = &deletedAt.Time
 		}
 
 		contacts = append(contacts, &contact)
@@ -49,16 +235,27 @@ func (r *ContactsRepository) FindAll() ([]*Contact, error) {
 	return contacts, nil
 }
 
-func (r *ContactsRepository) Create(c Contact) (*Contact, error) {
-	raw := "INSERT INTO contact (first_name, last_name) VALUES (?, ?)"
+func (r *ContactsRepository) Create(ctx context.Context, c Contact) (*Contact, error) {
+	owner, err := ownerFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("create: %w", err)
+	}
+
+	var bookID sql.NullInt64
+	if id, ok := addressbook.BookIDFromContext(ctx); ok {
+		bookID = sql.NullInt64{Int64: int64(id), Valid: true}
+		c.AddressBookID = &id
+	}
+
+	raw := "INSERT INTO contact (owner, address_book_id, first_name, last_name, organization, department, title) VALUES (?, ?, ?, ?, ?, ?, ?)"
 
-	stmt, err := r.DB.Prepare(raw)
+	stmt, err := db.Conn(ctx, r.DB).PrepareContext(ctx, raw)
 	if err != nil {
 		return nil, fmt.Errorf("create: error while preparing statement: %w", err)
 	}
 	defer stmt.Close()
 
-	result, err := stmt.Exec(c.FirstName, c.LastName)
+	result, err := stmt.ExecContext(ctx, owner, bookID, c.FirstName, c.LastName, c.Organization, c.Department, c.Title)
 	if err != nil {
 		return nil, fmt.Errorf("create: error while executing insert query: %w", err)
 	}
@@ -69,27 +266,139 @@ func (r *ContactsRepository) Create(c Contact) (*Contact, error) {
 	}
 
 	c.ID = int(id)
+	c.Version = 1
+	return &c, nil
+}
+
+// Update changes the names and the organization of the contact, as long as it's not in the trash and is still
+// at the provided version. The caller is expected to have checked that the contact exists, so when no contact is
+// changed ErrVersionMismatch is returned
+func (r *ContactsRepository) Update(ctx context.Context, c Contact, version int) (*Contact, error) {
+	set := "first_name = ?, last_name = ?, organization = ?, department = ?, title = ?"
+	setArgs := []interface{}{c.FirstName, c.LastName, c.Organization, c.Department, c.Title}
+
+	err := r.update(ctx, set, setArgs, c.ID, "deleted_at IS NULL", version)
+	if err != nil {
+		return nil, fmt.Errorf("update: %w", err)
+	}
+
+	c.Version = version + 1
 	return &c, nil
 }
 
-func (r *ContactsRepository) DeleteByID(id int) error {
-	raw := "DELETE FROM contact WHERE id = ?"
+// DeleteByID moves the contact to the trash. Its emails, phones and addresses are kept, so it can be restored.
+// When version isn't 0, the contact is only deleted if it's still at this version
+func (r *ContactsRepository) DeleteByID(ctx context.Context, id int, version int) error {
+	if err := r.update(ctx, "deleted_at = UTC_TIMESTAMP()", nil, id, "deleted_at IS NULL", version); err != nil {
+		return fmt.Errorf("deleteByID: %w", err)
+	}
+
+	return nil
+}
+
+// Restore moves the contact back from the trash
+func (r *ContactsRepository) Restore(ctx context.Context, id int) error {
+	if err := r.update(ctx, "deleted_at = NULL", nil, id, "deleted_at IS NOT NULL", 0); err != nil {
+		return fmt.Errorf("restore: %w", err)
+	}
+
+	return nil
+}
+
+// update applies the assignments to the contact visible in the context that matches the filter, incrementing
+// its version. When version isn't 0, the contact must also be at this version, otherwise ErrVersionMismatch is
+// returned instead of ErrContactNotFound
+func (r *ContactsRepository) update(ctx context.Context, set string, setArgs []interface{}, id int, filter string, version int) error {
+	cond, args, err := scope(ctx)
+	if err != nil {
+		return err
+	}
+
+	raw := "UPDATE contact SET " + set + ", version = version + 1 WHERE id = ? AND " + filter + " AND " + cond
+	stmtArgs := append(append(setArgs, id), args...)
+
+	if version != 0 {
+		raw += " AND version = ?"
+		stmtArgs = append(stmtArgs, version)
+	}
 
-	stmt, err := r.DB.Prepare(raw)
+	stmt, err := db.Conn(ctx, r.DB).PrepareContext(ctx, raw)
 	if err != nil {
-		return fmt.Errorf("deleteByID: error while preparing statement: %w", err)
+		return fmt.Errorf("error while preparing statement: %w", err)
 	}
 
 	defer stmt.Close()
 
-	_, err = stmt.Exec(id)
+	result, err := stmt.ExecContext(ctx, stmtArgs...)
 	if err != nil {
-		return fmt.Errorf("deleteByID: error while executing the delete query: %w", err)
+		return fmt.Errorf("error while executing the update query: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error while fetching the affected rows: %w", err)
+	}
+
+	if affected == 0 && version != 0 {
+		return ErrVersionMismatch
+	}
+
+	if affected == 0 {
+		return ErrContactNotFound
 	}
 
 	return nil
 }
 
+// Purge permanently deletes the contacts of every owner that stayed in the trash for longer than
+// the retention period, along with their emails, phones and addresses. It returns how many contacts were deleted
+func (r *ContactsRepository) Purge(ctx context.Context, retention time.Duration) (int64, error) {
+	raw := "DELETE FROM contact WHERE deleted_at IS NOT NULL AND deleted_at < UTC_TIMESTAMP() - INTERVAL ? SECOND"
+
+	result, err := db.Conn(ctx, r.DB).ExecContext(ctx, raw, int64(retention.Seconds()))
+	if err != nil {
+		return 0, fmt.Errorf("purge: error while executing the delete query: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("purge: error while fetching the affected rows: %w", err)
+	}
+
+	return affected, nil
+}
+
+// Organizations returns the organizations of the contacts visible in the context, except the ones in the trash,
+// along with how many contacts work for each of them
+func (r *ContactsRepository) Organizations(ctx context.Context) ([]Organization, error) {
+	cond, args, err := scope(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Organizations(): %w", err)
+	}
+
+	stmt := "SELECT organization, COUNT(*) FROM contact WHERE deleted_at IS NULL AND organization <> '' AND " + cond +
+		" GROUP BY organization ORDER BY organization"
+
+	rows, err := db.Conn(ctx, r.DB).QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Organizations(): error while executing query: %w", err)
+	}
+
+	defer rows.Close()
+	organizations := make([]Organization, 0)
+
+	for rows.Next() {
+		var o Organization
+		if err := rows.Scan(&o.Name, &o.Contacts); err != nil {
+			return nil, fmt.Errorf("Organizations(): error while scanning rows: %w", err)
+		}
+
+		organizations = append(organizations, o)
+	}
+
+	return organizations, nil
+}
+
 var RepositorySet = wire.NewSet(
 	ProvideContactsRepository,
 	wire.Bind(new(Repository), new(*ContactsRepository)),
@@ -1,13 +1,117 @@
 package contacts
 
 import (
+	"context"
+	"errors"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/LucasFrezarini/go-contacts/addressbook"
+	"github.com/LucasFrezarini/go-contacts/auth"
 	"go.uber.org/zap"
 )
 
+const owner = "tanjiro"
+
+// contactColumns are the columns of the contacts selected by the repository
+var contactColumns = []string{"id", "address_book_id", "first_name", "last_name", "organization", "department", "title", "deleted_at", "version"}
+
+// principalContext returns a context authenticated as the provided subject
+func principalContext(subject string) context.Context {
+	return auth.WithPrincipal(context.Background(), &auth.Principal{Subject: subject, Method: auth.MethodJWT})
+}
+
+func TestRepositoryFindAllFiltered(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	rows := sqlmock.NewRows(contactColumns).
+		AddRow(1, nil, "Inosuke", "Hashibira", "", "", "", nil, 1)
+
+	mock.ExpectQuery("SELECT (.+) FROM contact WHERE deleted_at IS NULL AND EXISTS \\(SELECT 1 FROM email e WHERE e.contact_id = contact.id AND e.label = (.+)\\) AND owner = (.+)").
+		WithArgs("work", owner).WillReturnRows(rows).RowsWillBeClosed()
+
+	repository := ProvideContactsRepository(db, zap.NewNop())
+
+	if contacts, err := repository.FindAll(principalContext(owner), Filter{EmailLabel: "work"}); err != nil || len(contacts) != 1 {
+		t.Errorf("FindAll() with an email label = %v, %v, want 1 contact", contacts, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("FindAll(): unfulfilled mock expectations: %v", err)
+	}
+}
+
+func TestRepositoryFindAllByOrganization(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	rows := sqlmock.NewRows(contactColumns).
+		AddRow(1, nil, "Inosuke", "Hashibira", "Demon Slayer Corps", "Beast Breathing", "Demon Slayer", nil, 1)
+
+	mock.ExpectQuery("SELECT (.+) FROM contact WHERE deleted_at IS NULL AND organization = (.+) AND owner = (.+) "+
+		"ORDER BY organization = '', organization DESC, last_name, first_name, id").
+		WithArgs("Demon Slayer Corps", owner).WillReturnRows(rows).RowsWillBeClosed()
+
+	repository := ProvideContactsRepository(db, zap.NewNop())
+	f := Filter{Organization: "Demon Slayer Corps", Sort: SortOrganizationDesc}
+
+	contacts, err := repository.FindAll(principalContext(owner), f)
+	if err != nil || len(contacts) != 1 {
+		t.Fatalf("FindAll(%+v) = %v, %v, want 1 contact", f, contacts, err)
+	}
+
+	if c := contacts[0]; c.Organization != "Demon Slayer Corps" || c.Department != "Beast Breathing" || c.Title != "Demon Slayer" {
+		t.Errorf("FindAll(%+v) = %+v, want the organization, department and title scanned", f, c)
+	}
+
+	if _, err := repository.FindAll(principalContext(owner), Filter{Sort: "first_name; DROP TABLE contact"}); !errors.Is(err, ErrInvalidSort) {
+		t.Errorf("FindAll() with an unknown sort returned %v, want ErrInvalidSort", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("FindAll(): unfulfilled mock expectations: %v", err)
+	}
+}
+
+func TestRepositoryOrganizations(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"organization", "COUNT(*)"}).
+		AddRow("Demon Slayer Corps", 3).
+		AddRow("Twelve Kizuki", 1)
+
+	mock.ExpectQuery("SELECT organization, COUNT\\(\\*\\) FROM contact WHERE deleted_at IS NULL AND organization <> '' AND owner = (.+) " +
+		"GROUP BY organization ORDER BY organization").WithArgs(owner).WillReturnRows(rows).RowsWillBeClosed()
+
+	repository := ProvideContactsRepository(db, zap.NewNop())
+	organizations, err := repository.Organizations(principalContext(owner))
+
+	expected := []Organization{{Name: "Demon Slayer Corps", Contacts: 3}, {Name: "Twelve Kizuki", Contacts: 1}}
+	if err != nil || !reflect.DeepEqual(organizations, expected) {
+		t.Errorf("Organizations() = %v, %v, want %v", organizations, err, expected)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Organizations(): unfulfilled mock expectations: %v", err)
+	}
+}
+
 func TestRepositoryFindAll(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -17,20 +121,20 @@ func TestRepositoryFindAll(t *testing.T) {
 	defer db.Close()
 
 	expectedContacts := []*Contact{
-		{ID: 1, FirstName: "Inosuke", LastName: "Hashibira"},
-		{ID: 2, FirstName: "Gonpachiro", LastName: "Kamaboko"},
+		{ID: 1, FirstName: "Inosuke", LastName: "Hashibira", Version: 1},
+		{ID: 2, FirstName: "Gonpachiro", LastName: "Kamaboko", Version: 3},
 	}
 
-	rows := sqlmock.NewRows([]string{"id", "first_name", "last_name"})
+	rows := sqlmock.NewRows(contactColumns)
 
 	for _, c := range expectedContacts {
-		rows.AddRow(c.ID, c.FirstName, c.LastName)
+		rows.AddRow(c.ID, nil, c.FirstName, c.LastName, c.Organization, c.Department, c.Title, nil, c.Version)
 	}
 
-	mock.ExpectQuery("SELECT (.+) FROM contact").WillReturnRows(rows).RowsWillBeClosed()
+	mock.ExpectQuery("SELECT (.+) FROM contact WHERE deleted_at IS NULL AND owner = (.+) AND address_book_id IS NULL").WithArgs(owner).WillReturnRows(rows).RowsWillBeClosed()
 
 	repository := ProvideContactsRepository(db, zap.NewNop())
-	contacts, err := repository.FindAll()
+	contacts, err := repository.FindAll(principalContext(owner), Filter{})
 
 	if err != nil {
 		t.Errorf("FindAll() returned an error %v, want nil", err)
@@ -60,14 +164,17 @@ func TestRepositoryCreate(t *testing.T) {
 	defer db.Close()
 
 	data := Contact{
-		FirstName: "Zenitsu",
-		LastName:  "Agatsuma",
+		FirstName:    "Zenitsu",
+		LastName:     "Agatsuma",
+		Organization: "Demon Slayer Corps",
+		Title:        "Demon Slayer",
 	}
 
-	mock.ExpectPrepare("INSERT INTO contact").ExpectExec().WithArgs(data.FirstName, data.LastName).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectPrepare("INSERT INTO contact").ExpectExec().
+		WithArgs(owner, nil, data.FirstName, data.LastName, data.Organization, data.Department, data.Title).WillReturnResult(sqlmock.NewResult(1, 1))
 
 	repository := ProvideContactsRepository(db, zap.NewNop())
-	contact, err := repository.Create(data)
+	contact, err := repository.Create(principalContext(owner), data)
 	if err != nil {
 		t.Errorf("repository.Create(%T): returned an error while creating a new contact: %v", data, err)
 	}
@@ -84,6 +191,10 @@ func TestRepositoryCreate(t *testing.T) {
 		t.Errorf("repository.Create(%T): contact.LastName == %s, want %s", data, contact.LastName, expected)
 	}
 
+	if expected := data.Organization; contact.Organization != expected {
+		t.Errorf("repository.Create(%T): contact.Organization == %s, want %s", data, contact.Organization, expected)
+	}
+
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("repository.Create(%T): unfulfilled mock expectations: %v", data, err)
 	}
@@ -100,10 +211,10 @@ func TestRepositoryDeleteByID(t *testing.T) {
 
 	contactID := 2
 
-	mock.ExpectPrepare("DELETE FROM contact WHERE id = (.+)").ExpectExec().WithArgs(contactID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare("UPDATE contact SET deleted_at = UTC_TIMESTAMP\\(\\), version = version \\+ 1 WHERE id = (.+) AND deleted_at IS NULL AND owner = (.+) AND address_book_id IS NULL").ExpectExec().WithArgs(contactID, owner).WillReturnResult(sqlmock.NewResult(0, 1))
 
 	repository := ProvideContactsRepository(db, zap.NewNop())
-	err = repository.DeleteByID(contactID)
+	err = repository.DeleteByID(principalContext(owner), contactID, 0)
 
 	if err != nil {
 		t.Errorf("repository.DeleteByID(%d): returned an error while creating a new contact: %v", contactID, err)
@@ -113,3 +224,298 @@ func TestRepositoryDeleteByID(t *testing.T) {
 		t.Errorf("repository.DeleteByID(%d): unfulfilled mock expectations: %v", contactID, err)
 	}
 }
+
+func TestRepositoryFindAllInAddressBook(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	bookID := 7
+	rows := sqlmock.NewRows(contactColumns).
+		AddRow(3, bookID, "Kanao", "Tsuyuri", "", "", "", nil, 1)
+
+	// the contacts of a book are visible to all of its members, regardless of who created them
+	mock.ExpectQuery("SELECT (.+) FROM contact WHERE deleted_at IS NULL AND address_book_id = (.+) AND EXISTS \\(SELECT 1 FROM address_book_member (.+)\\)").
+		WithArgs(bookID, owner).WillReturnRows(rows).RowsWillBeClosed()
+
+	repository := ProvideContactsRepository(db, zap.NewNop())
+	contacts, err := repository.FindAll(addressbook.WithBookID(principalContext(owner), bookID), Filter{})
+
+	if err != nil {
+		t.Errorf("FindAll() returned an error %v, want nil", err)
+	}
+
+	expected := []*Contact{{ID: 3, AddressBookID: &bookID, FirstName: "Kanao", LastName: "Tsuyuri", Version: 1}}
+	if !reflect.DeepEqual(contacts, expected) {
+		t.Errorf("FindAll() = %v, want %v", contacts, expected)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled mock expectations: %v", err)
+	}
+}
+
+func TestRepositoryCreateInAddressBook(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	bookID := 7
+	data := Contact{FirstName: "Kanao", LastName: "Tsuyuri"}
+
+	mock.ExpectPrepare("INSERT INTO contact").ExpectExec().WithArgs(owner, bookID, data.FirstName, data.LastName, "", "", "").WillReturnResult(sqlmock.NewResult(3, 1))
+
+	repository := ProvideContactsRepository(db, zap.NewNop())
+	contact, err := repository.Create(addressbook.WithBookID(principalContext(owner), bookID), data)
+	if err != nil {
+		t.Fatalf("repository.Create(%T): returned an error while creating a new contact: %v", data, err)
+	}
+
+	if contact.AddressBookID == nil || *contact.AddressBookID != bookID {
+		t.Errorf("repository.Create(%T): contact.AddressBookID == %v, want %d", data, contact.AddressBookID, bookID)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("repository.Create(%T): unfulfilled mock expectations: %v", data, err)
+	}
+}
+
+func TestRepositoryUpdate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	data := Contact{ID: 4, FirstName: "Sabito", LastName: "Urokodaki", Organization: "Demon Slayer Corps", Department: "Water Breathing"}
+
+	mock.ExpectPrepare("UPDATE contact SET first_name = \\?, last_name = \\?, organization = \\?, department = \\?, title = \\?, version = version \\+ 1 WHERE id = (.+) AND deleted_at IS NULL AND owner = (.+) AND version = \\?").
+		ExpectExec().WithArgs(data.FirstName, data.LastName, data.Organization, data.Department, data.Title, data.ID, owner, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare("UPDATE contact SET first_name").
+		ExpectExec().WithArgs(data.FirstName, data.LastName, data.Organization, data.Department, data.Title, data.ID, owner, 2).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repository := ProvideContactsRepository(db, zap.NewNop())
+	contact, err := repository.Update(principalContext(owner), data, 2)
+
+	if err != nil {
+		t.Fatalf("repository.Update() returned an error %v, want nil", err)
+	}
+
+	if expected := 3; contact.Version != expected {
+		t.Errorf("repository.Update() contact.Version == %d, want %d", contact.Version, expected)
+	}
+
+	if _, err := repository.Update(principalContext(owner), data, 2); !errors.Is(err, ErrVersionMismatch) {
+		t.Errorf("repository.Update() of a changed contact returned %v, want ErrVersionMismatch", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled mock expectations: %v", err)
+	}
+}
+
+func TestRepositoryDeleteByIDVersionMismatch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	mock.ExpectPrepare("UPDATE contact SET deleted_at = (.+) AND version = \\?").ExpectExec().WithArgs(4, owner, 1).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repository := ProvideContactsRepository(db, zap.NewNop())
+
+	if err := repository.DeleteByID(principalContext(owner), 4, 1); !errors.Is(err, ErrVersionMismatch) {
+		t.Errorf("repository.DeleteByID() of a changed contact returned %v, want ErrVersionMismatch", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled mock expectations: %v", err)
+	}
+}
+
+func TestRepositoryDeleteByIDOtherOwner(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	contactID := 2
+
+	// the contact exists, but it belongs to another owner, so no row matches the scoped query
+	mock.ExpectPrepare("UPDATE contact SET deleted_at = (.+), version = version \\+ 1 WHERE id = (.+) AND owner = (.+)").ExpectExec().WithArgs(contactID, "zenitsu").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repository := ProvideContactsRepository(db, zap.NewNop())
+	err = repository.DeleteByID(principalContext("zenitsu"), contactID, 0)
+
+	if !errors.Is(err, ErrContactNotFound) {
+		t.Errorf("repository.DeleteByID(%d) of another owner's contact returned %v, want ErrContactNotFound", contactID, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("repository.DeleteByID(%d): unfulfilled mock expectations: %v", contactID, err)
+	}
+}
+
+func TestRepositoryFindDeleted(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	deletedAt := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows(contactColumns).
+		AddRow(4, nil, "Sabito", "Urokodaki", "", "", "", deletedAt, 2)
+
+	mock.ExpectQuery("SELECT (.+) FROM contact WHERE deleted_at IS NOT NULL AND owner = (.+)").WithArgs(owner).WillReturnRows(rows).RowsWillBeClosed()
+
+	repository := ProvideContactsRepository(db, zap.NewNop())
+	contacts, err := repository.FindDeleted(principalContext(owner))
+
+	if err != nil {
+		t.Errorf("FindDeleted() returned an error %v, want nil", err)
+	}
+
+	expected := []*Contact{{ID: 4, FirstName: "Sabito", LastName: "Urokodaki", DeletedAt: &deletedAt, Version: 2}}
+	if !reflect.DeepEqual(contacts, expected) {
+		t.Errorf("FindDeleted() = %v, want %v", contacts, expected)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled mock expectations: %v", err)
+	}
+}
+
+func TestRepositoryFindByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	rows := sqlmock.NewRows(contactColumns).
+		AddRow(4, nil, "Sabito", "Urokodaki", "", "", "", nil, 2)
+
+	mock.ExpectQuery("SELECT (.+) FROM contact WHERE id = (.+) AND owner = (.+)").WithArgs(4, owner).WillReturnRows(rows).RowsWillBeClosed()
+	mock.ExpectQuery("SELECT (.+) FROM contact WHERE id = (.+) AND owner = (.+)").WithArgs(5, owner).
+		WillReturnRows(sqlmock.NewRows(contactColumns))
+
+	repository := ProvideContactsRepository(db, zap.NewNop())
+	contact, err := repository.FindByID(principalContext(owner), 4)
+
+	if err != nil {
+		t.Errorf("FindByID(4) returned an error %v, want nil", err)
+	}
+
+	expected := &Contact{ID: 4, FirstName: "Sabito", LastName: "Urokodaki", Version: 2}
+	if !reflect.DeepEqual(contact, expected) {
+		t.Errorf("FindByID(4) = %v, want %v", contact, expected)
+	}
+
+	if _, err := repository.FindByID(principalContext(owner), 5); !errors.Is(err, ErrContactNotFound) {
+		t.Errorf("FindByID(5) returned error %v, want %v", err, ErrContactNotFound)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled mock expectations: %v", err)
+	}
+}
+
+func TestRepositoryRestore(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	contactID := 4
+
+	mock.ExpectPrepare("UPDATE contact SET deleted_at = NULL, version = version \\+ 1 WHERE id = (.+) AND deleted_at IS NOT NULL AND owner = (.+)").ExpectExec().WithArgs(contactID, owner).WillReturnResult(sqlmock.NewResult(0, 1))
+	// contacts outside of the trash can't be restored
+	mock.ExpectPrepare("UPDATE contact SET deleted_at = NULL").ExpectExec().WithArgs(2, owner).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repository := ProvideContactsRepository(db, zap.NewNop())
+
+	if err := repository.Restore(principalContext(owner), contactID); err != nil {
+		t.Errorf("repository.Restore(%d) returned an error: %v", contactID, err)
+	}
+
+	if err := repository.Restore(principalContext(owner), 2); !errors.Is(err, ErrContactNotFound) {
+		t.Errorf("repository.Restore(2) of a contact outside of the trash returned %v, want ErrContactNotFound", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled mock expectations: %v", err)
+	}
+}
+
+func TestRepositoryPurge(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	mock.ExpectExec("DELETE FROM contact WHERE deleted_at IS NOT NULL AND deleted_at < UTC_TIMESTAMP\\(\\) - INTERVAL (.+) SECOND").
+		WithArgs(int64(86400)).WillReturnResult(sqlmock.NewResult(0, 3))
+
+	repository := ProvideContactsRepository(db, zap.NewNop())
+
+	// the purge runs for every owner, so it doesn't need a principal
+	purged, err := repository.Purge(context.Background(), 24*time.Hour)
+	if err != nil {
+		t.Errorf("repository.Purge() returned an error: %v", err)
+	}
+
+	if expected := int64(3); purged != expected {
+		t.Errorf("repository.Purge() = %d, want %d", purged, expected)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled mock expectations: %v", err)
+	}
+}
+
+func TestRepositoryWithoutPrincipal(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	repository := ProvideContactsRepository(db, zap.NewNop())
+	ctx := context.Background()
+
+	if _, err := repository.FindAll(ctx, Filter{}); !errors.Is(err, ErrNoPrincipal) {
+		t.Errorf("repository.FindAll() without principal returned %v, want ErrNoPrincipal", err)
+	}
+
+	if _, err := repository.Create(ctx, Contact{FirstName: "Zenitsu", LastName: "Agatsuma"}); !errors.Is(err, ErrNoPrincipal) {
+		t.Errorf("repository.Create() without principal returned %v, want ErrNoPrincipal", err)
+	}
+
+	if err := repository.DeleteByID(ctx, 1, 0); !errors.Is(err, ErrNoPrincipal) {
+		t.Errorf("repository.DeleteByID() without principal returned %v, want ErrNoPrincipal", err)
+	}
+
+	// no query may reach the database without an owner to scope it
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unexpected database interaction: %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+package contacts
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/LucasFrezarini/go-contacts/db"
+	"github.com/google/wire"
+	"go.uber.org/zap"
+)
+
+// ErrRevisionNotFound is returned when a contact doesn't have the requested revision
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// A Revision is the full state of a contact, including its emails, phones and addresses, as it was after a
+// change. Revisions are numbered after the version of the contact they capture
+type Revision struct {
+	ContactID int       `json:"contact_id"`
+	Number    int       `json:"number"`
+	Actor     string    `json:"actor"`
+	Contact   *Contact  `json:"contact"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RevisionRepository defines the structure of a contact revisions repository,
+// created to facilitate the mocking in unit testing. The caller is responsible for checking
+// that the contact is visible by the principal
+type RevisionRepository interface {
+	Create(ctx context.Context, r Revision) error
+	FindByContactID(ctx context.Context, contactID int) ([]*Revision, error)
+	Find(ctx context.Context, contactID int, number int) (*Revision, error)
+}
+
+// A RevisionsRepository persists the revisions of the contacts
+type RevisionsRepository struct {
+	DB     *sql.DB
+	Logger *zap.Logger
+}
+
+// ProvideRevisionsRepository creates a new RevisionsRepository with the dependencies provided.
+// Created especially for the use of Wire, which will inject the dependencies via DI
+func ProvideRevisionsRepository(db *sql.DB, logger *zap.Logger) *RevisionsRepository {
+	return &RevisionsRepository{DB: db, Logger: logger.Named("RevisionsRepository")}
+}
+
+// Create stores the revision
+func (r *RevisionsRepository) Create(ctx context.Context, rev Revision) error {
+	state, err := json.Marshal(rev.Contact)
+	if err != nil {
+		return fmt.Errorf("create: error while encoding the contact: %w", err)
+	}
+
+	raw := "INSERT INTO contact_revision (contact_id, number, actor, state, created_at) VALUES (?, ?, ?, ?, ?)"
+
+	if _, err := db.Conn(ctx, r.DB).ExecContext(ctx, raw, rev.ContactID, rev.Number, rev.Actor, state, rev.CreatedAt); err != nil {
+		return fmt.Errorf("create: error while executing insert query: %w", err)
+	}
+
+	return nil
+}
+
+// FindByContactID returns all the revisions of the contact, from the oldest to the newest
+func (r *RevisionsRepository) FindByContactID(ctx context.Context, contactID int) ([]*Revision, error) {
+	raw := "SELECT contact_id, number, actor, state, created_at FROM contact_revision WHERE contact_id = ? ORDER BY number"
+
+	revisions, err := r.query(ctx, raw, contactID)
+	if err != nil {
+		return nil, fmt.Errorf("FindByContactID(%d): %w", contactID, err)
+	}
+
+	return revisions, nil
+}
+
+// Find returns the revision of the contact with the provided number. If there's no
+// such revision, ErrRevisionNotFound is returned
+func (r *RevisionsRepository) Find(ctx context.Context, contactID int, number int) (*Revision, error) {
+	raw := "SELECT contact_id, number, actor, state, created_at FROM contact_revision WHERE contact_id = ? AND number = ?"
+
+	revisions, err := r.query(ctx, raw, contactID, number)
+	if err != nil {
+		return nil, fmt.Errorf("Find(%d, %d): %w", contactID, number, err)
+	}
+
+	if len(revisions) == 0 {
+		return nil, fmt.Errorf("Find(%d, %d): %w", contactID, number, ErrRevisionNotFound)
+	}
+
+	return revisions[0], nil
+}
+
+func (r *RevisionsRepository) query(ctx context.Context, raw string, args ...interface{}) ([]*Revision, error) {
+	rows, err := db.Conn(ctx, r.DB).QueryContext(ctx, raw, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error while executing query: %w", err)
+	}
+
+	defer rows.Close()
+	revisions := make([]*Revision, 0)
+
+	for rows.Next() {
+		var rev Revision
+		var state []byte
+
+		if err := rows.Scan(&rev.ContactID, &rev.Number, &rev.Actor, &state, &rev.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error while scanning rows: %w", err)
+		}
+
+		if err := json.Unmarshal(state, &rev.Contact); err != nil {
+			return nil, fmt.Errorf("error while decoding revision %d: %w", rev.Number, err)
+		}
+
+		revisions = append(revisions, &rev)
+	}
+
+	return revisions, nil
+}
+
+// RevisionRepositorySet is the wire set that contains all the providers for the revisions repository
+var RevisionRepositorySet = wire.NewSet(
+	ProvideRevisionsRepository,
+	wire.Bind(new(RevisionRepository), new(*RevisionsRepository)),
+)
@@ -0,0 +1,121 @@
+package contacts
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/LucasFrezarini/go-contacts/contacts/email"
+	"github.com/LucasFrezarini/go-contacts/contacts/phone"
+	"go.uber.org/zap"
+)
+
+func TestRevisionsRepositoryCreate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	rev := Revision{ContactID: 4, Number: 2, Actor: owner, Contact: &Contact{ID: 4, FirstName: "Sabito", LastName: "Urokodaki", Version: 2}, CreatedAt: now}
+
+	mock.ExpectExec("INSERT INTO contact_revision").WithArgs(4, 2, owner, sqlmock.AnyArg(), now).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	repository := ProvideRevisionsRepository(db, zap.NewNop())
+
+	if err := repository.Create(context.Background(), rev); err != nil {
+		t.Errorf("Create() returned an error %v, want nil", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled mock expectations: %v", err)
+	}
+}
+
+func TestRevisionsRepositoryFind(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	state := `{"id":4,"first_name":"Sabito","last_name":"Urokodaki","emails":[{"id":1,"contact_id":4,"address":"sabito@gmail.com"}],"phones":[],"version":2,"etag":"\"2\""}`
+	cols := []string{"contact_id", "number", "actor", "state", "created_at"}
+
+	mock.ExpectQuery("SELECT (.+) FROM contact_revision WHERE contact_id = (.+) AND number = (.+)").WithArgs(4, 2).
+		WillReturnRows(sqlmock.NewRows(cols).AddRow(4, 2, owner, state, now)).RowsWillBeClosed()
+	mock.ExpectQuery("SELECT (.+) FROM contact_revision").WithArgs(4, 3).WillReturnRows(sqlmock.NewRows(cols))
+
+	repository := ProvideRevisionsRepository(db, zap.NewNop())
+	rev, err := repository.Find(context.Background(), 4, 2)
+
+	if err != nil {
+		t.Fatalf("Find() returned an error %v, want nil", err)
+	}
+
+	expected := &Revision{ContactID: 4, Number: 2, Actor: owner, CreatedAt: now, Contact: &Contact{
+		ID: 4, FirstName: "Sabito", LastName: "Urokodaki", Version: 2,
+		Emails: []email.Email{{ID: 1, ContactID: 4, Address: "sabito@gmail.com"}}, Phones: []phone.Phone{},
+	}}
+
+	if !reflect.DeepEqual(rev, expected) {
+		t.Errorf("Find() = %+v, want %+v", rev.Contact, expected.Contact)
+	}
+
+	if _, err := repository.Find(context.Background(), 4, 3); !errors.Is(err, ErrRevisionNotFound) {
+		t.Errorf("Find() of a missing revision returned %v, want ErrRevisionNotFound", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled mock expectations: %v", err)
+	}
+}
+
+func TestServiceRevertContact(t *testing.T) {
+	revisions := &MockedRevisionRepository{}
+	revisions.revisions = []*Revision{{ContactID: 1, Number: 1, Contact: &Contact{
+		ID: 1, FirstName: "Inosuke", LastName: "Pig", Organization: "Mount Natagumo", Version: 1,
+		Emails: []email.Email{{ID: 9, ContactID: 1, Address: "boar@gmail.com"}},
+		Phones: []phone.Phone{{ID: 8, ContactID: 1, Number: "11955554444", Type: phone.PhoneTypeMobile}},
+	}}}
+
+	auditor := &MockedAuditor{}
+	service := ProvideContactsService(zap.NewNop(), &MockedContactsRepository{}, &MockedEmailRepository{}, &MockedPhoneRepository{},
+		revisions, &MockedAuthorizer{}, auditor, &MockedTransactor{}, testEngine, &MockedMergeRepository{}, testParser, &MockedAddressRepository{})
+
+	reverted, err := service.RevertContact(context.Background(), 1, 1, 1)
+	if err != nil {
+		t.Fatalf("RevertContact() returned an error %v, want nil", err)
+	}
+
+	if reverted.LastName != "Pig" || reverted.Organization != "Mount Natagumo" || reverted.Department != "" || reverted.Version != 2 {
+		t.Errorf("RevertContact() = %+v, want the names and organization of revision 1 at version 2", reverted)
+	}
+
+	if len(reverted.Emails) != 1 || reverted.Emails[0].Address != "boar@gmail.com" {
+		t.Errorf("RevertContact() emails = %v, want the emails of revision 1", reverted.Emails)
+	}
+
+	if len(reverted.Phones) != 1 || reverted.Phones[0].Number != "11955554444" {
+		t.Errorf("RevertContact() phones = %v, want the phones of revision 1", reverted.Phones)
+	}
+
+	if rev, err := revisions.Find(context.Background(), 1, 2); err != nil || rev.Contact.LastName != "Pig" {
+		t.Errorf("RevertContact() didn't save the reverted contact as revision 2")
+	}
+
+	if _, err := service.RevertContact(context.Background(), 1, 7, 0); !errors.Is(err, ErrRevisionNotFound) {
+		t.Errorf("RevertContact() to a missing revision returned %v, want ErrRevisionNotFound", err)
+	}
+
+	if _, err := service.RevertContact(context.Background(), 1, 1, 5); !errors.Is(err, ErrVersionMismatch) {
+		t.Errorf("RevertContact() at an old version returned %v, want ErrVersionMismatch", err)
+	}
+}
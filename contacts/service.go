@@ -1,11 +1,20 @@
 package contacts
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/LucasFrezarini/go-contacts/addressbook"
+	"github.com/LucasFrezarini/go-contacts/audit"
+	"github.com/LucasFrezarini/go-contacts/auth"
+	"github.com/LucasFrezarini/go-contacts/contacts/address"
+	"github.com/LucasFrezarini/go-contacts/contacts/dedupe"
 	"github.com/LucasFrezarini/go-contacts/contacts/email"
 	"github.com/LucasFrezarini/go-contacts/contacts/phone"
+	"github.com/LucasFrezarini/go-contacts/db"
 	"github.com/google/wire"
 	"go.uber.org/zap"
 )
@@ -16,105 +25,1597 @@ type Service struct {
 	ContactsRepository Repository
 	EmailRepository    email.GenericRepository
 	PhoneRepository    phone.GenericRepository
+	RevisionRepository RevisionRepository
+	Authorizer         addressbook.Authorizer
+	Auditor            audit.Auditor
+	Transactor         db.Transactor
+	Dedupe             *dedupe.Engine
+	MergeRepository    MergeRepository
+	PhoneParser        *phone.Parser
+	AddressRepository  address.GenericRepository
 }
 
 // ProvideContactsService creates a new Service with the provided dependencies.
 // Created especially for the use of Wire, who will inject the dependencies via DI
-func ProvideContactsService(logger *zap.Logger, cr Repository, er email.GenericRepository, pr phone.GenericRepository) *Service {
-	return &Service{logger.Named("ContactsService"), cr, er, pr}
+func ProvideContactsService(logger *zap.Logger, cr Repository, er email.GenericRepository, pr phone.GenericRepository,
+	rr RevisionRepository, a addressbook.Authorizer, au audit.Auditor, tx db.Transactor, d *dedupe.Engine,
+	mr MergeRepository, pp *phone.Parser, ar address.GenericRepository) *Service {
+	return &Service{logger.Named("ContactsService"), cr, er, pr, rr, a, au, tx, d, mr, pp, ar}
 }
 
-// FindAllContacts fetches all the contacts registered in the application, as well as its emails and phones
-func (s *Service) FindAllContacts() ([]*Contact, error) {
-	contacts, err := s.ContactsRepository.FindAll()
+// record records a change in the audit log. It must be called within the transaction of the change, so the
+// change is undone when it can't be recorded
+func (s *Service) record(ctx context.Context, action audit.Action, entity audit.Entity, entityID int, contactID int, before, after interface{}) error {
+	if err := s.Auditor.Record(ctx, action, entity, entityID, contactID, before, after); err != nil {
+		s.Logger.Error(fmt.Sprintf("error while recording the %s of %s %d in the audit log: %v", action, entity, entityID, err))
+		return fmt.Errorf("error while recording the %s of %s %d in the audit log: %w", action, entity, entityID, err)
+	}
+
+	return nil
+}
+
+// findContact fetches the contact visible in the context with the provided ID, as well as its emails, phones
+// and addresses
+func (s *Service) findContact(ctx context.Context, caller string, id int) (*Contact, error) {
+	contact, err := s.ContactsRepository.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrContactNotFound) {
+			return nil, ErrContactNotFound
+		}
+
+		msg := fmt.Sprintf("%s error while trying to fetch contact: %v", caller, err)
+		s.Logger.Error(msg)
+		return nil, errors.New(msg)
+	}
+
+	if err := s.fetchDetails(ctx, caller, []*Contact{contact}); err != nil {
+		return nil, err
+	}
+
+	return contact, nil
+}
+
+// FindAllContacts fetches all the contacts visible in the context that match the filter, as well as its emails,
+// phones and addresses
+func (s *Service) FindAllContacts(ctx context.Context, f Filter) ([]*Contact, error) {
+	if err := s.Authorizer.Authorize(ctx, addressbook.PermissionRead); err != nil {
+		return nil, err
+	}
+
+	f, err := f.normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	contacts, err := s.ContactsRepository.FindAll(ctx, f)
 	if err != nil {
 		msg := fmt.Sprintf("FindAllContacts() error while trying to fetch contacts: %v", err)
 		s.Logger.Error(msg)
 		return nil, errors.New(msg)
 	}
 
+	if err := s.fetchDetails(ctx, "FindAllContacts()", contacts); err != nil {
+		return nil, err
+	}
+
+	return contacts, nil
+}
+
+// FindOrganizations fetches the organizations of the contacts visible in the context, except the ones in the
+// trash, along with how many contacts work for each of them
+func (s *Service) FindOrganizations(ctx context.Context) ([]Organization, error) {
+	if err := s.Authorizer.Authorize(ctx, addressbook.PermissionRead); err != nil {
+		return nil, err
+	}
+
+	organizations, err := s.ContactsRepository.Organizations(ctx)
+	if err != nil {
+		s.Logger.Error(fmt.Sprintf("FindOrganizations() error while trying to fetch the organizations: %v", err))
+		return nil, fmt.Errorf("error while fetching the organizations: %w", err)
+	}
+
+	return organizations, nil
+}
+
+// FindDeletedContacts fetches all the contacts in the trash visible in the context, as well as its emails, phones
+// and addresses
+func (s *Service) FindDeletedContacts(ctx context.Context) ([]*Contact, error) {
+	if err := s.Authorizer.Authorize(ctx, addressbook.PermissionRead); err != nil {
+		return nil, err
+	}
+
+	contacts, err := s.ContactsRepository.FindDeleted(ctx)
+	if err != nil {
+		msg := fmt.Sprintf("FindDeletedContacts() error while trying to fetch contacts: %v", err)
+		s.Logger.Error(msg)
+		return nil, errors.New(msg)
+	}
+
+	if err := s.fetchDetails(ctx, "FindDeletedContacts()", contacts); err != nil {
+		return nil, err
+	}
+
+	return contacts, nil
+}
+
+// fetchDetails fetches the emails, phones and addresses of the contacts
+func (s *Service) fetchDetails(ctx context.Context, caller string, contacts []*Contact) error {
 	for _, c := range contacts {
-		emails, err := s.EmailRepository.FindByContactID(c.ID)
+		emails, err := s.EmailRepository.FindByContactID(ctx, c.ID)
 		if err != nil {
-			msg := fmt.Sprintf("FindAllContacts() error while trying to fetch contact's emails: %v", err)
+			msg := fmt.Sprintf("%s error while trying to fetch contact's emails: %v", caller, err)
 			s.Logger.Error(msg)
-			return nil, errors.New(msg)
+			return errors.New(msg)
 		}
 
 		c.Emails = emails
 
-		phones, err := s.PhoneRepository.FindByContactID(c.ID)
+		phones, err := s.PhoneRepository.FindByContactID(ctx, c.ID)
 		if err != nil {
-			msg := fmt.Sprintf("FindAllContacts() error while trying to fetch contact's phones: %v", err)
+			msg := fmt.Sprintf("%s error while trying to fetch contact's phones: %v", caller, err)
 			s.Logger.Error(msg)
-			return nil, errors.New(msg)
+			return errors.New(msg)
 		}
 
 		c.Phones = phones
+
+		addresses, err := s.AddressRepository.FindByContactID(ctx, c.ID)
+		if err != nil {
+			msg := fmt.Sprintf("%s error while trying to fetch contact's addresses: %v", caller, err)
+			s.Logger.Error(msg)
+			return errors.New(msg)
+		}
+
+		c.Addresses = addresses
 	}
 
-	return contacts, nil
+	return nil
 }
 
 // CreateContactData is the structure of a contact data that will be created
 type CreateContactData struct {
-	FirstName string
-	LastName  string
-	Emails    []string
-	Phones    []phone.CreatePhoneData
+	FirstName    string
+	LastName     string
+	Organization string
+	Department   string
+	Title        string
+	Emails       []email.Input
+	Phones       []phone.CreatePhoneData
+	Addresses    []address.CreateAddressData
 }
 
-// Create creates a new contact with the data provided as parameter.
+// Create creates a new contact owned by the current principal with the data provided as parameter,
+// in the address book targeted by the context, if any.
 // If the contact is created successfully, it will return a formated Contact object
-func (s *Service) Create(c CreateContactData) (*Contact, error) {
-	contact, err := s.ContactsRepository.Create(Contact{
-		FirstName: c.FirstName,
-		LastName:  c.LastName,
-	})
+func (s *Service) Create(ctx context.Context, c CreateContactData) (*Contact, error) {
+	if err := s.Authorizer.Authorize(ctx, addressbook.PermissionWrite); err != nil {
+		return nil, err
+	}
 
+	emailsData, err := email.ParseAll(c.Emails...)
 	if err != nil {
-		msg := fmt.Sprintf("error while creating a new contact: %v", err)
-		s.Logger.Error(msg)
-		return nil, errors.New(msg)
+		return nil, err
+	}
+
+	phonesData, err := s.PhoneParser.ParseAll(c.Phones...)
+	if err != nil {
+		return nil, err
+	}
+
+	addressesData, err := address.ParseAll(c.Addresses...)
+	if err != nil {
+		return nil, err
+	}
+
+	// the first email and the first phone are the primary ones
+	if len(emailsData) != 0 {
+		emailsData[0].IsPrimary = true
+	}
+
+	if len(phonesData) != 0 {
+		phonesData[0].IsPrimary = true
 	}
 
-	if len(c.Emails) != 0 {
-		emails, err := s.EmailRepository.Create(contact.ID, c.Emails...)
+	var contact *Contact
+
+	err = s.Transactor.InTx(ctx, func(ctx context.Context) error {
+		created, err := s.ContactsRepository.Create(ctx, Contact{
+			FirstName:    c.FirstName,
+			LastName:     c.LastName,
+			Organization: strings.TrimSpace(c.Organization),
+			Department:   strings.TrimSpace(c.Department),
+			Title:        strings.TrimSpace(c.Title),
+		})
+
 		if err != nil {
-			msg := fmt.Sprintf("error while inserting contact's emails: %v", err)
+			msg := fmt.Sprintf("error while creating a new contact: %v", err)
 			s.Logger.Error(msg)
-			return nil, errors.New(msg)
+			return errors.New(msg)
+		}
+
+		if len(emailsData) != 0 {
+			emails, err := s.EmailRepository.Create(ctx, created.ID, emailsData...)
+			if err != nil {
+				msg := fmt.Sprintf("error while inserting contact's emails: %v", err)
+				s.Logger.Error(msg)
+				return errors.New(msg)
+			}
+
+			created.Emails = emails
+		}
+
+		if len(phonesData) != 0 {
+			phones, err := s.PhoneRepository.Create(ctx, created.ID, phonesData...)
+			if err != nil {
+				msg := fmt.Sprintf("error while inserting contact's phone: %v", err)
+				s.Logger.Error(msg)
+				return errors.New(msg)
+			}
+
+			created.Phones = phones
+		}
+
+		if len(addressesData) != 0 {
+			addresses, err := s.AddressRepository.Create(ctx, created.ID, addressesData...)
+			if err != nil {
+				msg := fmt.Sprintf("error while inserting contact's addresses: %v", err)
+				s.Logger.Error(msg)
+				return errors.New(msg)
+			}
+
+			created.Addresses = addresses
+		}
+
+		if err := s.saveRevision(ctx, created); err != nil {
+			return err
 		}
 
-		contact.Emails = emails
+		if err := s.record(ctx, audit.ActionCreate, audit.EntityContact, created.ID, created.ID, nil, created); err != nil {
+			return err
+		}
+
+		for _, e := range created.Emails {
+			if err := s.record(ctx, audit.ActionCreate, audit.EntityEmail, e.ID, created.ID, nil, e); err != nil {
+				return err
+			}
+		}
+
+		for _, p := range created.Phones {
+			if err := s.record(ctx, audit.ActionCreate, audit.EntityPhone, p.ID, created.ID, nil, p); err != nil {
+				return err
+			}
+		}
+
+		for _, a := range created.Addresses {
+			if err := s.record(ctx, audit.ActionCreate, audit.EntityAddress, a.ID, created.ID, nil, a); err != nil {
+				return err
+			}
+		}
+
+		contact = created
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return contact, nil
+}
+
+// FindContactByID fetches the contact with the provided ID, as well as its emails, phones and addresses, as long
+// as it is visible in the context. Otherwise, ErrContactNotFound is returned
+func (s *Service) FindContactByID(ctx context.Context, id int) (*Contact, error) {
+	if err := s.Authorizer.Authorize(ctx, addressbook.PermissionRead); err != nil {
+		return nil, err
 	}
 
-	if len(c.Phones) != 0 {
-		phones, err := s.PhoneRepository.Create(contact.ID, c.Phones...)
+	return s.findContact(ctx, "FindContactByID()", id)
+}
+
+// UpdateContactData is the structure of the changes made to a contact. Nil fields are left unchanged
+type UpdateContactData struct {
+	FirstName    *string
+	LastName     *string
+	Organization *string
+	Department   *string
+	Title        *string
+}
+
+// findChangeable fetches the contact that is about to be changed, checking that it isn't in the trash and,
+// when version isn't 0, that it's still at this version
+func (s *Service) findChangeable(ctx context.Context, caller string, id int, version int) (*Contact, error) {
+	contact, err := s.findContact(ctx, caller, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if contact.DeletedAt != nil {
+		return nil, ErrContactNotFound
+	}
+
+	if version != 0 && version != contact.Version {
+		return nil, ErrVersionMismatch
+	}
+
+	return contact, nil
+}
+
+// UpdateContact changes the contact with the provided ID, as long as it is visible in the context and isn't
+// in the trash. Otherwise, ErrContactNotFound is returned. When version isn't 0, the contact is only changed
+// if it's still at this version, otherwise ErrVersionMismatch is returned
+func (s *Service) UpdateContact(ctx context.Context, id int, version int, d UpdateContactData) (*Contact, error) {
+	if err := s.Authorizer.Authorize(ctx, addressbook.PermissionWrite); err != nil {
+		return nil, err
+	}
+
+	var before, after *Contact
+
+	err := s.Transactor.InTx(ctx, func(ctx context.Context) (err error) {
+		before, err = s.findChangeable(ctx, "UpdateContact()", id, version)
 		if err != nil {
-			msg := fmt.Sprintf("error while inserting contact's phone: %v", err)
-			s.Logger.Error(msg)
-			return nil, errors.New(msg)
+			return err
+		}
+
+		changed := *before
+		if d.FirstName != nil {
+			changed.FirstName = *d.FirstName
+		}
+
+		if d.LastName != nil {
+			changed.LastName = *d.LastName
+		}
+
+		work := map[*string]*string{
+			&changed.Organization: d.Organization,
+			&changed.Department:   d.Department,
+			&changed.Title:        d.Title,
+		}
+
+		// the organization is trimmed so the contacts of the same one are listed together
+		for field, value := range work {
+			if value != nil {
+				*field = strings.TrimSpace(*value)
+			}
+		}
+
+		if after, err = s.updateContact(ctx, changed, before.Version); err != nil {
+			return err
+		}
+
+		if err := s.saveRevision(ctx, after); err != nil {
+			return err
+		}
+
+		return s.record(ctx, audit.ActionUpdate, audit.EntityContact, id, id, before, after)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return after, nil
+}
+
+// updateContact changes the names and the organization of the contact, as long as it's still at the version
+// it was read, guarding against the changes made since then
+func (s *Service) updateContact(ctx context.Context, c Contact, version int) (*Contact, error) {
+	updated, err := s.ContactsRepository.Update(ctx, c, version)
+	if err != nil {
+		if errors.Is(err, ErrVersionMismatch) {
+			return nil, ErrVersionMismatch
 		}
 
-		contact.Phones = phones
+		s.Logger.Error(fmt.Sprintf("error while updating contact of ID %d: %v", c.ID, err))
+		return nil, fmt.Errorf("error while updating contact of ID %d: %w", c.ID, err)
 	}
 
-	return contact, nil
+	return updated, nil
 }
 
-// DeleteContactByID deletes the contact with the provided ID in the database
-func (s *Service) DeleteContactByID(id int) error {
-	err := s.ContactsRepository.DeleteByID(id)
+// changeDetails applies the change to the emails, phones or addresses of the contact with the provided ID, as long
+// as it is visible in the context and isn't in the trash. Otherwise, ErrContactNotFound is returned. The contact
+// moves to a new version, saved as a revision, along with its details. When version isn't 0, the contact is only
+// changed if it's still at this version, otherwise ErrVersionMismatch is returned
+func (s *Service) changeDetails(ctx context.Context, caller string, id int, version int,
+	change func(ctx context.Context, c *Contact) error) (*Contact, error) {
+	if err := s.Authorizer.Authorize(ctx, addressbook.PermissionWrite); err != nil {
+		return nil, err
+	}
+
+	var after *Contact
+
+	err := s.Transactor.InTx(ctx, func(ctx context.Context) error {
+		c, err := s.findChangeable(ctx, caller, id, version)
+		if err != nil {
+			return err
+		}
+
+		if err := change(ctx, c); err != nil {
+			return err
+		}
+
+		if after, err = s.updateContact(ctx, *c, c.Version); err != nil {
+			return err
+		}
+
+		if err := s.fetchDetails(ctx, caller, []*Contact{after}); err != nil {
+			return err
+		}
+
+		if err := s.ensurePrimaries(ctx, after); err != nil {
+			return err
+		}
+
+		return s.saveRevision(ctx, after)
+	})
 
 	if err != nil {
-		msg := fmt.Sprintf("error while deleting contact of ID %d: %v", id, err)
-		s.Logger.Error(msg)
-		return errors.New(msg)
+		return nil, err
+	}
+
+	return after, nil
+}
+
+// ensurePrimaries promotes the first email and the first phone of the contact, whose details must be fetched,
+// when it has no primary one, like after its primary one was deleted or moved to another contact
+func (s *Service) ensurePrimaries(ctx context.Context, c *Contact) error {
+	if len(c.Emails) != 0 && c.PrimaryEmail() == nil {
+		if err := s.EmailRepository.SetPrimary(ctx, c.ID, c.Emails[0].ID); err != nil {
+			s.Logger.Error(fmt.Sprintf("error while promoting the email %d of contact %d: %v", c.Emails[0].ID, c.ID, err))
+			return fmt.Errorf("error while promoting the email %d of contact %d: %w", c.Emails[0].ID, c.ID, err)
+		}
+
+		c.Emails[0].IsPrimary = true
+	}
+
+	if len(c.Phones) != 0 && c.PrimaryPhone() == nil {
+		if err := s.PhoneRepository.SetPrimary(ctx, c.ID, c.Phones[0].ID); err != nil {
+			s.Logger.Error(fmt.Sprintf("error while promoting the phone %d of contact %d: %v", c.Phones[0].ID, c.ID, err))
+			return fmt.Errorf("error while promoting the phone %d of contact %d: %w", c.Phones[0].ID, c.ID, err)
+		}
+
+		c.Phones[0].IsPrimary = true
+	}
+
+	return nil
+}
+
+// findEmail fetches the email with the provided ID of the contact with the provided contactID
+func (s *Service) findEmail(ctx context.Context, contactID int, id int) (*email.Email, error) {
+	e, err := s.EmailRepository.FindByID(ctx, contactID, id)
+	if err != nil {
+		if errors.Is(err, email.ErrEmailNotFound) {
+			return nil, email.ErrEmailNotFound
+		}
+
+		s.Logger.Error(fmt.Sprintf("error while fetching the email %d of contact %d: %v", id, contactID, err))
+		return nil, fmt.Errorf("error while fetching the email %d of contact %d: %w", id, contactID, err)
+	}
+
+	return e, nil
+}
+
+// checkDuplicateEmail returns ErrDuplicateAddress when the contact has another email with the same normalized address
+func checkDuplicateEmail(c *Contact, data email.CreateEmailData, id int) error {
+	for _, e := range c.Emails {
+		if e.ID != id && e.Normalized == data.Normalized {
+			return fmt.Errorf("%w: %q", email.ErrDuplicateAddress, data.Address)
+		}
 	}
 
 	return nil
 }
 
+// FindEmail returns the email with the provided ID of the contact with the provided contactID, along with the
+// contact, as long as it's visible in the context. Otherwise, ErrContactNotFound is returned. When the email
+// belongs to another contact, ErrEmailNotFound is returned
+func (s *Service) FindEmail(ctx context.Context, contactID int, id int) (*email.Email, *Contact, error) {
+	contact, err := s.FindContactByID(ctx, contactID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	e, err := s.findEmail(ctx, contactID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return e, contact, nil
+}
+
+// AddEmail adds the email to the contact with the provided ID, as long as it's visible in the context and isn't
+// in the trash. Otherwise, ErrContactNotFound is returned. The address and the label are validated, and the
+// address can't be one the contact already has. It returns the created email along with the contact at its new version.
+// When version isn't 0, the contact is only changed if it's still at this version, otherwise ErrVersionMismatch
+// is returned
+func (s *Service) AddEmail(ctx context.Context, contactID int, in email.Input, version int) (*email.Email, *Contact, error) {
+	data, err := email.ParseInput(in)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var created *email.Email
+
+	after, err := s.changeDetails(ctx, "AddEmail()", contactID, version, func(ctx context.Context, c *Contact) error {
+		if err := checkDuplicateEmail(c, data, 0); err != nil {
+			return err
+		}
+
+		// the first email of the contact is its primary one
+		data.IsPrimary = len(c.Emails) == 0
+
+		emails, err := s.EmailRepository.Create(ctx, c.ID, data)
+		if err != nil {
+			s.Logger.Error(fmt.Sprintf("error while inserting an email of contact %d: %v", c.ID, err))
+			return fmt.Errorf("error while inserting an email of contact %d: %w", c.ID, err)
+		}
+
+		created = &emails[0]
+		return s.record(ctx, audit.ActionCreate, audit.EntityEmail, created.ID, c.ID, nil, created)
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return created, after, nil
+}
+
+// UpdateEmail changes the address and the label of the email with the provided ID of the contact with the provided
+// contactID, under the same conditions as AddEmail. When the email belongs to another contact, ErrEmailNotFound
+// is returned
+func (s *Service) UpdateEmail(ctx context.Context, contactID int, id int, in email.Input, version int) (*email.Email, *Contact, error) {
+	data, err := email.ParseInput(in)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var before, updated *email.Email
+
+	after, err := s.changeDetails(ctx, "UpdateEmail()", contactID, version, func(ctx context.Context, c *Contact) (err error) {
+		if before, err = s.findEmail(ctx, c.ID, id); err != nil {
+			return err
+		}
+
+		if err := checkDuplicateEmail(c, data, id); err != nil {
+			return err
+		}
+
+		if updated, err = s.EmailRepository.Update(ctx, c.ID, id, data); err != nil {
+			s.Logger.Error(fmt.Sprintf("error while updating the email %d of contact %d: %v", id, c.ID, err))
+			return fmt.Errorf("error while updating the email %d of contact %d: %w", id, c.ID, err)
+		}
+
+		updated.IsPrimary = before.IsPrimary
+		return s.record(ctx, audit.ActionUpdate, audit.EntityEmail, id, c.ID, before, updated)
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return updated, after, nil
+}
+
+// DeleteEmail deletes the email with the provided ID of the contact with the provided contactID, under the
+// same conditions as UpdateEmail. When it was the primary email, the first remaining one is promoted. It returns
+// the contact at its new version
+func (s *Service) DeleteEmail(ctx context.Context, contactID int, id int, version int) (*Contact, error) {
+	var before *email.Email
+
+	after, err := s.changeDetails(ctx, "DeleteEmail()", contactID, version, func(ctx context.Context, c *Contact) (err error) {
+		if before, err = s.findEmail(ctx, c.ID, id); err != nil {
+			return err
+		}
+
+		if err := s.EmailRepository.DeleteByID(ctx, c.ID, id); err != nil {
+			s.Logger.Error(fmt.Sprintf("error while deleting the email %d of contact %d: %v", id, c.ID, err))
+			return fmt.Errorf("error while deleting the email %d of contact %d: %w", id, c.ID, err)
+		}
+
+		return s.record(ctx, audit.ActionDelete, audit.EntityEmail, id, c.ID, before, nil)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return after, nil
+}
+
+// PromoteEmail makes the email with the provided ID the primary one of the contact with the provided contactID,
+// under the same conditions as UpdateEmail. The email that was primary no longer is
+func (s *Service) PromoteEmail(ctx context.Context, contactID int, id int, version int) (*email.Email, *Contact, error) {
+	var before, promoted *email.Email
+
+	after, err := s.changeDetails(ctx, "PromoteEmail()", contactID, version, func(ctx context.Context, c *Contact) (err error) {
+		if before, err = s.findEmail(ctx, c.ID, id); err != nil {
+			return err
+		}
+
+		if err := s.EmailRepository.SetPrimary(ctx, c.ID, id); err != nil {
+			s.Logger.Error(fmt.Sprintf("error while promoting the email %d of contact %d: %v", id, c.ID, err))
+			return fmt.Errorf("error while promoting the email %d of contact %d: %w", id, c.ID, err)
+		}
+
+		p := *before
+		p.IsPrimary = true
+		promoted = &p
+
+		return s.record(ctx, audit.ActionUpdate, audit.EntityEmail, id, c.ID, before, promoted)
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return promoted, after, nil
+}
+
+// findPhone fetches the phone with the provided ID of the contact with the provided contactID
+func (s *Service) findPhone(ctx context.Context, contactID int, id int) (*phone.Phone, error) {
+	p, err := s.PhoneRepository.FindByID(ctx, contactID, id)
+	if err != nil {
+		if errors.Is(err, phone.ErrPhoneNotFound) {
+			return nil, phone.ErrPhoneNotFound
+		}
+
+		s.Logger.Error(fmt.Sprintf("error while fetching the phone %d of contact %d: %v", id, contactID, err))
+		return nil, fmt.Errorf("error while fetching the phone %d of contact %d: %w", id, contactID, err)
+	}
+
+	return p, nil
+}
+
+// FindPhone returns the phone with the provided ID of the contact with the provided contactID, along with the
+// contact, as long as it's visible in the context. Otherwise, ErrContactNotFound is returned. When the phone
+// belongs to another contact, ErrPhoneNotFound is returned
+func (s *Service) FindPhone(ctx context.Context, contactID int, id int) (*phone.Phone, *Contact, error) {
+	contact, err := s.FindContactByID(ctx, contactID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p, err := s.findPhone(ctx, contactID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return p, contact, nil
+}
+
+// AddPhone adds the phone to the contact with the provided ID, as long as it's visible in the context and isn't
+// in the trash. Otherwise, ErrContactNotFound is returned. The number and the type of the phone are validated.
+// It returns the created phone along with the contact at its new version. When version isn't 0, the contact is
+// only changed if it's still at this version, otherwise ErrVersionMismatch is returned
+func (s *Service) AddPhone(ctx context.Context, contactID int, d phone.CreatePhoneData, version int) (*phone.Phone, *Contact, error) {
+	data, err := s.PhoneParser.Parse(d)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var created *phone.Phone
+
+	after, err := s.changeDetails(ctx, "AddPhone()", contactID, version, func(ctx context.Context, c *Contact) error {
+		// the first phone of the contact is its primary one
+		data.IsPrimary = len(c.Phones) == 0
+
+		phones, err := s.PhoneRepository.Create(ctx, c.ID, data)
+		if err != nil {
+			s.Logger.Error(fmt.Sprintf("error while inserting a phone of contact %d: %v", c.ID, err))
+			return fmt.Errorf("error while inserting a phone of contact %d: %w", c.ID, err)
+		}
+
+		created = &phones[0]
+		return s.record(ctx, audit.ActionCreate, audit.EntityPhone, created.ID, c.ID, nil, created)
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return created, after, nil
+}
+
+// UpdatePhone replaces the phone with the provided ID of the contact with the provided contactID, under the
+// same conditions as AddPhone. When the phone belongs to another contact, ErrPhoneNotFound is returned
+func (s *Service) UpdatePhone(ctx context.Context, contactID int, id int, d phone.CreatePhoneData, version int) (*phone.Phone, *Contact, error) {
+	data, err := s.PhoneParser.Parse(d)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var before, updated *phone.Phone
+
+	after, err := s.changeDetails(ctx, "UpdatePhone()", contactID, version, func(ctx context.Context, c *Contact) (err error) {
+		if before, err = s.findPhone(ctx, c.ID, id); err != nil {
+			return err
+		}
+
+		if updated, err = s.PhoneRepository.Update(ctx, c.ID, id, data); err != nil {
+			s.Logger.Error(fmt.Sprintf("error while updating the phone %d of contact %d: %v", id, c.ID, err))
+			return fmt.Errorf("error while updating the phone %d of contact %d: %w", id, c.ID, err)
+		}
+
+		updated.IsPrimary = before.IsPrimary
+		return s.record(ctx, audit.ActionUpdate, audit.EntityPhone, id, c.ID, before, updated)
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return updated, after, nil
+}
+
+// DeletePhone deletes the phone with the provided ID of the contact with the provided contactID, under the
+// same conditions as UpdatePhone. When it was the primary phone, the first remaining one is promoted. It returns
+// the contact at its new version
+func (s *Service) DeletePhone(ctx context.Context, contactID int, id int, version int) (*Contact, error) {
+	var before *phone.Phone
+
+	after, err := s.changeDetails(ctx, "DeletePhone()", contactID, version, func(ctx context.Context, c *Contact) (err error) {
+		if before, err = s.findPhone(ctx, c.ID, id); err != nil {
+			return err
+		}
+
+		if err := s.PhoneRepository.DeleteByID(ctx, c.ID, id); err != nil {
+			s.Logger.Error(fmt.Sprintf("error while deleting the phone %d of contact %d: %v", id, c.ID, err))
+			return fmt.Errorf("error while deleting the phone %d of contact %d: %w", id, c.ID, err)
+		}
+
+		return s.record(ctx, audit.ActionDelete, audit.EntityPhone, id, c.ID, before, nil)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return after, nil
+}
+
+// PromotePhone makes the phone with the provided ID the primary one of the contact with the provided contactID,
+// under the same conditions as UpdatePhone. The phone that was primary no longer is
+func (s *Service) PromotePhone(ctx context.Context, contactID int, id int, version int) (*phone.Phone, *Contact, error) {
+	var before, promoted *phone.Phone
+
+	after, err := s.changeDetails(ctx, "PromotePhone()", contactID, version, func(ctx context.Context, c *Contact) (err error) {
+		if before, err = s.findPhone(ctx, c.ID, id); err != nil {
+			return err
+		}
+
+		if err := s.PhoneRepository.SetPrimary(ctx, c.ID, id); err != nil {
+			s.Logger.Error(fmt.Sprintf("error while promoting the phone %d of contact %d: %v", id, c.ID, err))
+			return fmt.Errorf("error while promoting the phone %d of contact %d: %w", id, c.ID, err)
+		}
+
+		p := *before
+		p.IsPrimary = true
+		promoted = &p
+
+		return s.record(ctx, audit.ActionUpdate, audit.EntityPhone, id, c.ID, before, promoted)
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return promoted, after, nil
+}
+
+// findAddress fetches the address with the provided ID of the contact with the provided contactID
+func (s *Service) findAddress(ctx context.Context, contactID int, id int) (*address.Address, error) {
+	a, err := s.AddressRepository.FindByID(ctx, contactID, id)
+	if err != nil {
+		if errors.Is(err, address.ErrAddressNotFound) {
+			return nil, address.ErrAddressNotFound
+		}
+
+		s.Logger.Error(fmt.Sprintf("error while fetching the address %d of contact %d: %v", id, contactID, err))
+		return nil, fmt.Errorf("error while fetching the address %d of contact %d: %w", id, contactID, err)
+	}
+
+	return a, nil
+}
+
+// FindAddress returns the address with the provided ID of the contact with the provided contactID, along with the
+// contact, as long as it's visible in the context. Otherwise, ErrContactNotFound is returned. When the address
+// belongs to another contact, ErrAddressNotFound is returned
+func (s *Service) FindAddress(ctx context.Context, contactID int, id int) (*address.Address, *Contact, error) {
+	contact, err := s.FindContactByID(ctx, contactID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	a, err := s.findAddress(ctx, contactID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return a, contact, nil
+}
+
+// AddAddress adds the address to the contact with the provided ID, as long as it's visible in the context and
+// isn't in the trash. Otherwise, ErrContactNotFound is returned. The address is validated. It returns the created
+// address along with the contact at its new version. When version isn't 0, the contact is only changed if it's
+// still at this version, otherwise ErrVersionMismatch is returned
+func (s *Service) AddAddress(ctx context.Context, contactID int, d address.CreateAddressData, version int) (*address.Address, *Contact, error) {
+	data, err := address.Parse(d)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var created *address.Address
+
+	after, err := s.changeDetails(ctx, "AddAddress()", contactID, version, func(ctx context.Context, c *Contact) error {
+		addresses, err := s.AddressRepository.Create(ctx, c.ID, data)
+		if err != nil {
+			s.Logger.Error(fmt.Sprintf("error while inserting an address of contact %d: %v", c.ID, err))
+			return fmt.Errorf("error while inserting an address of contact %d: %w", c.ID, err)
+		}
+
+		created = &addresses[0]
+		return s.record(ctx, audit.ActionCreate, audit.EntityAddress, created.ID, c.ID, nil, created)
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return created, after, nil
+}
+
+// UpdateAddress replaces the address with the provided ID of the contact with the provided contactID, under the
+// same conditions as AddAddress. When the address belongs to another contact, ErrAddressNotFound is returned
+func (s *Service) UpdateAddress(ctx context.Context, contactID int, id int, d address.CreateAddressData, version int) (*address.Address, *Contact, error) {
+	data, err := address.Parse(d)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var before, updated *address.Address
+
+	after, err := s.changeDetails(ctx, "UpdateAddress()", contactID, version, func(ctx context.Context, c *Contact) (err error) {
+		if before, err = s.findAddress(ctx, c.ID, id); err != nil {
+			return err
+		}
+
+		if updated, err = s.AddressRepository.Update(ctx, c.ID, id, data); err != nil {
+			s.Logger.Error(fmt.Sprintf("error while updating the address %d of contact %d: %v", id, c.ID, err))
+			return fmt.Errorf("error while updating the address %d of contact %d: %w", id, c.ID, err)
+		}
+
+		return s.record(ctx, audit.ActionUpdate, audit.EntityAddress, id, c.ID, before, updated)
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return updated, after, nil
+}
+
+// DeleteAddress deletes the address with the provided ID of the contact with the provided contactID, under the
+// same conditions as UpdateAddress. It returns the contact at its new version
+func (s *Service) DeleteAddress(ctx context.Context, contactID int, id int, version int) (*Contact, error) {
+	var before *address.Address
+
+	after, err := s.changeDetails(ctx, "DeleteAddress()", contactID, version, func(ctx context.Context, c *Contact) (err error) {
+		if before, err = s.findAddress(ctx, c.ID, id); err != nil {
+			return err
+		}
+
+		if err := s.AddressRepository.DeleteByID(ctx, c.ID, id); err != nil {
+			s.Logger.Error(fmt.Sprintf("error while deleting the address %d of contact %d: %v", id, c.ID, err))
+			return fmt.Errorf("error while deleting the address %d of contact %d: %w", id, c.ID, err)
+		}
+
+		return s.record(ctx, audit.ActionDelete, audit.EntityAddress, id, c.ID, before, nil)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return after, nil
+}
+
+// DeleteContactByID moves the contact with the provided ID to the trash, as long as
+// it is visible in the context. Otherwise, ErrContactNotFound is returned. When version isn't 0,
+// the contact is only deleted if it's still at this version, otherwise ErrVersionMismatch is returned
+func (s *Service) DeleteContactByID(ctx context.Context, id int, version int) error {
+	if err := s.Authorizer.Authorize(ctx, addressbook.PermissionWrite); err != nil {
+		return err
+	}
+
+	var before *Contact
+
+	err := s.Transactor.InTx(ctx, func(ctx context.Context) (err error) {
+		before, err = s.findChangeable(ctx, "DeleteContactByID()", id, version)
+		if err != nil {
+			return err
+		}
+
+		if err := s.ContactsRepository.DeleteByID(ctx, id, before.Version); err != nil {
+			s.Logger.Error(fmt.Sprintf("error while deleting contact of ID %d: %v", id, err))
+			return fmt.Errorf("error while deleting contact of ID %d: %w", id, err)
+		}
+
+		after, err := s.findContact(ctx, "DeleteContactByID()", id)
+		if err != nil {
+			return err
+		}
+
+		if err := s.saveRevision(ctx, after); err != nil {
+			return err
+		}
+
+		return s.record(ctx, audit.ActionDelete, audit.EntityContact, id, id, before, nil)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RestoreContactByID moves the contact with the provided ID back from the trash, as long as
+// it is visible in the context. Otherwise, ErrContactNotFound is returned
+func (s *Service) RestoreContactByID(ctx context.Context, id int) error {
+	if err := s.Authorizer.Authorize(ctx, addressbook.PermissionWrite); err != nil {
+		return err
+	}
+
+	var after *Contact
+
+	err := s.Transactor.InTx(ctx, func(ctx context.Context) (err error) {
+		if err := s.ContactsRepository.Restore(ctx, id); err != nil {
+			s.Logger.Error(fmt.Sprintf("error while restoring contact of ID %d: %v", id, err))
+			return fmt.Errorf("error while restoring contact of ID %d: %w", id, err)
+		}
+
+		if after, err = s.findContact(ctx, "RestoreContactByID()", id); err != nil {
+			return err
+		}
+
+		if err := s.saveRevision(ctx, after); err != nil {
+			return err
+		}
+
+		return s.record(ctx, audit.ActionRestore, audit.EntityContact, id, id, nil, after)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// saveRevision stores the current state of the contact as the revision of its version
+func (s *Service) saveRevision(ctx context.Context, c *Contact) error {
+	rev := Revision{ContactID: c.ID, Number: c.Version, Contact: c, CreatedAt: time.Now().UTC()}
+	if p, ok := auth.FromContext(ctx); ok {
+		rev.Actor = p.Subject
+	}
+
+	if err := s.RevisionRepository.Create(ctx, rev); err != nil {
+		s.Logger.Error(fmt.Sprintf("error while saving revision %d of contact %d: %v", c.Version, c.ID, err))
+		return fmt.Errorf("error while saving revision %d of contact %d: %w", c.Version, c.ID, err)
+	}
+
+	return nil
+}
+
+// Revisions returns all the revisions of the contact with the provided ID, from the oldest to the newest,
+// as long as the contact is visible in the context
+func (s *Service) Revisions(ctx context.Context, id int) ([]*Revision, error) {
+	if err := s.Authorizer.Authorize(ctx, addressbook.PermissionRead); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.findContact(ctx, "Revisions()", id); err != nil {
+		return nil, err
+	}
+
+	revisions, err := s.RevisionRepository.FindByContactID(ctx, id)
+	if err != nil {
+		s.Logger.Error(fmt.Sprintf("Revisions() error while trying to fetch the revisions of contact %d: %v", id, err))
+		return nil, fmt.Errorf("error while fetching the revisions of contact %d: %w", id, err)
+	}
+
+	return revisions, nil
+}
+
+// Revision returns the revision number n of the contact with the provided ID, as long as the contact
+// is visible in the context. If there's no such revision, ErrRevisionNotFound is returned
+func (s *Service) Revision(ctx context.Context, id int, n int) (*Revision, error) {
+	if err := s.Authorizer.Authorize(ctx, addressbook.PermissionRead); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.findContact(ctx, "Revision()", id); err != nil {
+		return nil, err
+	}
+
+	return s.findRevision(ctx, id, n)
+}
+
+func (s *Service) findRevision(ctx context.Context, id int, n int) (*Revision, error) {
+	rev, err := s.RevisionRepository.Find(ctx, id, n)
+	if err != nil {
+		if errors.Is(err, ErrRevisionNotFound) {
+			return nil, ErrRevisionNotFound
+		}
+
+		s.Logger.Error(fmt.Sprintf("error while fetching revision %d of contact %d: %v", n, id, err))
+		return nil, fmt.Errorf("error while fetching revision %d of contact %d: %w", n, id, err)
+	}
+
+	return rev, nil
+}
+
+// RevertContact restores the names, organization, emails, phones and addresses the contact with the provided ID
+// had at the revision number n, which is saved as a new revision. The contact must be visible in the context
+// and not be in the trash, otherwise ErrContactNotFound is returned. When version isn't 0, the contact
+// is only reverted if it's still at this version, otherwise ErrVersionMismatch is returned
+func (s *Service) RevertContact(ctx context.Context, id int, n int, version int) (*Contact, error) {
+	if err := s.Authorizer.Authorize(ctx, addressbook.PermissionWrite); err != nil {
+		return nil, err
+	}
+
+	var before, after *Contact
+
+	err := s.Transactor.InTx(ctx, func(ctx context.Context) (err error) {
+		before, err = s.findChangeable(ctx, "RevertContact()", id, version)
+		if err != nil {
+			return err
+		}
+
+		rev, err := s.findRevision(ctx, id, n)
+		if err != nil {
+			return err
+		}
+
+		changed := *before
+		changed.FirstName, changed.LastName = rev.Contact.FirstName, rev.Contact.LastName
+		changed.Organization, changed.Department, changed.Title = rev.Contact.Organization, rev.Contact.Department, rev.Contact.Title
+
+		if after, err = s.updateContact(ctx, changed, before.Version); err != nil {
+			return err
+		}
+
+		if after.Emails, after.Phones, err = s.replaceDetails(ctx, id, rev.Contact); err != nil {
+			return err
+		}
+
+		// the revisions saved before contacts had addresses don't have them, so the current ones are kept
+		if rev.Contact.Addresses != nil {
+			if after.Addresses, err = s.replaceAddresses(ctx, id, rev.Contact.Addresses); err != nil {
+				return err
+			}
+		}
+
+		if err := s.ensurePrimaries(ctx, after); err != nil {
+			return err
+		}
+
+		if err := s.saveRevision(ctx, after); err != nil {
+			return err
+		}
+
+		return s.record(ctx, audit.ActionUpdate, audit.EntityContact, id, id, before, after)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return after, nil
+}
+
+// replaceDetails replaces the emails and phones of the contact with the ones of the provided state
+func (s *Service) replaceDetails(ctx context.Context, id int, state *Contact) ([]email.Email, []phone.Phone, error) {
+	if err := s.EmailRepository.DeleteByContactID(ctx, id); err != nil {
+		s.Logger.Error(fmt.Sprintf("error while deleting the emails of contact %d: %v", id, err))
+		return nil, nil, fmt.Errorf("error while deleting the emails of contact %d: %w", id, err)
+	}
+
+	if err := s.PhoneRepository.DeleteByContactID(ctx, id); err != nil {
+		s.Logger.Error(fmt.Sprintf("error while deleting the phones of contact %d: %v", id, err))
+		return nil, nil, fmt.Errorf("error while deleting the phones of contact %d: %w", id, err)
+	}
+
+	emailsData := make([]email.CreateEmailData, 0, len(state.Emails))
+	for _, e := range state.Emails {
+		normalized := e.Normalized
+		if normalized == "" {
+			// revisions saved before the addresses were normalized are restored as the existing ones were backfilled
+			normalized = strings.ToLower(strings.TrimSpace(e.Address))
+		}
+
+		label := e.Label
+		if label == "" {
+			// revisions saved before the emails were labeled are restored as the existing ones were backfilled
+			label = email.DefaultLabel
+		}
+
+		emailsData = append(emailsData, email.CreateEmailData{
+			Address: e.Address, Normalized: normalized, Label: label, IsPrimary: e.IsPrimary,
+		})
+	}
+
+	phonesData := make([]phone.CreatePhoneData, 0, len(state.Phones))
+	for _, p := range state.Phones {
+		phonesData = append(phonesData, phone.CreatePhoneData{
+			Number: p.Number, Type: p.Type, Label: p.Label, E164: p.E164, IsPrimary: p.IsPrimary,
+		})
+	}
+
+	emails, err := s.EmailRepository.Create(ctx, id, emailsData...)
+	if err != nil {
+		s.Logger.Error(fmt.Sprintf("error while inserting the emails of contact %d: %v", id, err))
+		return nil, nil, fmt.Errorf("error while inserting the emails of contact %d: %w", id, err)
+	}
+
+	phones, err := s.PhoneRepository.Create(ctx, id, phonesData...)
+	if err != nil {
+		s.Logger.Error(fmt.Sprintf("error while inserting the phones of contact %d: %v", id, err))
+		return nil, nil, fmt.Errorf("error while inserting the phones of contact %d: %w", id, err)
+	}
+
+	return emails, phones, nil
+}
+
+// replaceAddresses replaces the addresses of the contact with the provided ones
+func (s *Service) replaceAddresses(ctx context.Context, id int, addresses []address.Address) ([]address.Address, error) {
+	if err := s.AddressRepository.DeleteByContactID(ctx, id); err != nil {
+		s.Logger.Error(fmt.Sprintf("error while deleting the addresses of contact %d: %v", id, err))
+		return nil, fmt.Errorf("error while deleting the addresses of contact %d: %w", id, err)
+	}
+
+	data := make([]address.CreateAddressData, 0, len(addresses))
+	for _, a := range addresses {
+		data = append(data, address.CreateAddressData{
+			Street: a.Street, Locality: a.Locality, Region: a.Region, PostalCode: a.PostalCode, Country: a.Country, Label: a.Label,
+		})
+	}
+
+	created, err := s.AddressRepository.Create(ctx, id, data...)
+	if err != nil {
+		s.Logger.Error(fmt.Sprintf("error while inserting the addresses of contact %d: %v", id, err))
+		return nil, fmt.Errorf("error while inserting the addresses of contact %d: %w", id, err)
+	}
+
+	return created, nil
+}
+
+// History returns every change made to the contact with the provided ID and to its emails, phones and addresses,
+// from the oldest to the newest, as long as the contact is visible in the context
+func (s *Service) History(ctx context.Context, id int) ([]*audit.Entry, error) {
+	if err := s.Authorizer.Authorize(ctx, addressbook.PermissionRead); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.ContactsRepository.FindByID(ctx, id); err != nil {
+		if errors.Is(err, ErrContactNotFound) {
+			return nil, ErrContactNotFound
+		}
+
+		s.Logger.Error(fmt.Sprintf("History() error while trying to fetch contact %d: %v", id, err))
+		return nil, fmt.Errorf("error while fetching contact of ID %d: %w", id, err)
+	}
+
+	entries, err := s.Auditor.History(ctx, id)
+	if err != nil {
+		s.Logger.Error(fmt.Sprintf("History() error while trying to fetch the history of contact %d: %v", id, err))
+		return nil, fmt.Errorf("error while fetching the history of contact %d: %w", id, err)
+	}
+
+	return entries, nil
+}
+
+// MergeContactsData is the structure of a merge of contacts
+type MergeContactsData struct {
+	TargetID  int
+	SourceIDs []int
+	// Strategy resolves the fields without a rule of their own. It's ResolutionKeepTarget when empty
+	Strategy Resolution
+	// Fields are the resolution rules of each field, by their JSON name
+	Fields map[string]Resolution
+	// Overrides are the explicit values of the fields, by their JSON name, taking precedence over any rule
+	Overrides map[string]string
+}
+
+// MergeContacts merges the source contacts into the target one, as long as they're all visible in the context
+// and none of them is in the trash. Otherwise, ErrContactNotFound is returned. The fields of the target are
+// resolved by the rules of the merge, the emails, phones and addresses of the sources it doesn't have yet are
+// moved to it, and the sources are moved to the trash, all at once. The merge is recorded, so it can be undone.
+// When version isn't 0, the target is only changed if it's still at this version, otherwise ErrVersionMismatch
+// is returned
+func (s *Service) MergeContacts(ctx context.Context, d MergeContactsData, version int) (*Merge, *Contact, error) {
+	if err := s.Authorizer.Authorize(ctx, addressbook.PermissionWrite); err != nil {
+		return nil, nil, err
+	}
+
+	if err := d.validate(); err != nil {
+		return nil, nil, err
+	}
+
+	var target, after *Contact
+	var merge *Merge
+	sources := make([]*Contact, 0, len(d.SourceIDs))
+
+	err := s.Transactor.InTx(ctx, func(ctx context.Context) (err error) {
+		if target, err = s.findChangeable(ctx, "MergeContacts()", d.TargetID, version); err != nil {
+			return err
+		}
+
+		for _, id := range d.SourceIDs {
+			src, err := s.findChangeable(ctx, "MergeContacts()", id, 0)
+			if err != nil {
+				return err
+			}
+
+			sources = append(sources, src)
+		}
+
+		if after, err = s.updateContact(ctx, d.resolve(target, sources), target.Version); err != nil {
+			return err
+		}
+
+		moved, err := s.moveDetails(ctx, target, sources)
+		if err != nil {
+			return err
+		}
+
+		for _, src := range sources {
+			if err := s.ContactsRepository.DeleteByID(ctx, src.ID, src.Version); err != nil {
+				s.Logger.Error(fmt.Sprintf("error while deleting the merged contact of ID %d: %v", src.ID, err))
+				return fmt.Errorf("error while deleting the merged contact of ID %d: %w", src.ID, err)
+			}
+
+			if err := s.saveCurrentRevision(ctx, "MergeContacts()", src.ID); err != nil {
+				return err
+			}
+		}
+
+		if err := s.fetchDetails(ctx, "MergeContacts()", []*Contact{after}); err != nil {
+			return err
+		}
+
+		if err := s.ensurePrimaries(ctx, after); err != nil {
+			return err
+		}
+
+		if err := s.saveRevision(ctx, after); err != nil {
+			return err
+		}
+
+		m := Merge{TargetID: target.ID, SourceIDs: d.SourceIDs, Target: target, TargetVersion: after.Version, Moved: moved,
+			CreatedAt: time.Now().UTC()}
+		if p, ok := auth.FromContext(ctx); ok {
+			m.Actor = p.Subject
+		}
+
+		if merge, err = s.MergeRepository.Create(ctx, m); err != nil {
+			s.Logger.Error(fmt.Sprintf("error while recording the merge into contact %d: %v", target.ID, err))
+			return fmt.Errorf("error while recording the merge into contact %d: %w", target.ID, err)
+		}
+
+		if err := s.record(ctx, audit.ActionUpdate, audit.EntityContact, target.ID, target.ID, target, after); err != nil {
+			return err
+		}
+
+		if err := s.recordMoves(ctx, target.ID, merge.Moved, false); err != nil {
+			return err
+		}
+
+		for _, src := range sources {
+			if err := s.record(ctx, audit.ActionDelete, audit.EntityContact, src.ID, src.ID, src, nil); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return merge, after, nil
+}
+
+// moveDetails moves to the target the emails, phones and addresses of the sources it doesn't have yet,
+// comparing their normalized values
+func (s *Service) moveDetails(ctx context.Context, target *Contact, sources []*Contact) ([]MovedDetail, error) {
+	emails := make(map[string]bool)
+	for _, e := range target.Emails {
+		emails[e.Normalized] = true
+	}
+
+	phones := make(map[string]bool)
+	for _, p := range target.Phones {
+		phones[phoneKey(p)] = true
+	}
+
+	addresses := make(map[string]bool)
+	for _, a := range target.Addresses {
+		addresses[addressKey(a)] = true
+	}
+
+	moved := make([]MovedDetail, 0)
+
+	for _, src := range sources {
+		for _, e := range src.Emails {
+			if !emails[e.Normalized] {
+				if err := s.EmailRepository.Reassign(ctx, e.ID, src.ID, target.ID); err != nil {
+					s.Logger.Error(fmt.Sprintf("error while moving email %d to contact %d: %v", e.ID, target.ID, err))
+					return nil, fmt.Errorf("error while moving email %d to contact %d: %w", e.ID, target.ID, err)
+				}
+
+				emails[e.Normalized] = true
+				moved = append(moved, MovedDetail{Entity: string(audit.EntityEmail), ID: e.ID, From: src.ID})
+			}
+		}
+
+		for _, p := range src.Phones {
+			if key := phoneKey(p); !phones[key] {
+				if err := s.PhoneRepository.Reassign(ctx, p.ID, src.ID, target.ID); err != nil {
+					s.Logger.Error(fmt.Sprintf("error while moving phone %d to contact %d: %v", p.ID, target.ID, err))
+					return nil, fmt.Errorf("error while moving phone %d to contact %d: %w", p.ID, target.ID, err)
+				}
+
+				phones[key] = true
+				moved = append(moved, MovedDetail{Entity: string(audit.EntityPhone), ID: p.ID, From: src.ID})
+			}
+		}
+
+		for _, a := range src.Addresses {
+			if key := addressKey(a); !addresses[key] {
+				if err := s.AddressRepository.Reassign(ctx, a.ID, src.ID, target.ID); err != nil {
+					s.Logger.Error(fmt.Sprintf("error while moving address %d to contact %d: %v", a.ID, target.ID, err))
+					return nil, fmt.Errorf("error while moving address %d to contact %d: %w", a.ID, target.ID, err)
+				}
+
+				addresses[key] = true
+				moved = append(moved, MovedDetail{Entity: string(audit.EntityAddress), ID: a.ID, From: src.ID})
+			}
+		}
+	}
+
+	return moved, nil
+}
+
+// phoneKey returns the digits phones are compared by, taken from the E.164 form of the number when there's one
+func phoneKey(p phone.Phone) string {
+	if p.E164 != "" {
+		return dedupe.NormalizePhone(p.E164)
+	}
+
+	return dedupe.NormalizePhone(p.Number)
+}
+
+// addressKey returns what addresses are compared by: all their fields but the label, lowercased
+func addressKey(a address.Address) string {
+	return strings.ToLower(strings.Join([]string{a.Street, a.Locality, a.Region, a.PostalCode, a.Country}, "\x00"))
+}
+
+// recordMoves records the emails, phones and addresses moved between the contacts by a merge, or back by its undo
+func (s *Service) recordMoves(ctx context.Context, targetID int, moved []MovedDetail, undo bool) error {
+	for _, m := range moved {
+		from, to := m.From, targetID
+		if undo {
+			from, to = to, from
+		}
+
+		before := map[string]interface{}{"id": m.ID, "contact_id": from}
+		after := map[string]interface{}{"id": m.ID, "contact_id": to}
+		if err := s.record(ctx, audit.ActionUpdate, audit.Entity(m.Entity), m.ID, to, before, after); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// saveCurrentRevision saves the current state of the contact with the provided ID as a revision, after promoting
+// the primary email and phone it may have lost to a merge or to its undo
+func (s *Service) saveCurrentRevision(ctx context.Context, caller string, id int) error {
+	c, err := s.findContact(ctx, caller, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.ensurePrimaries(ctx, c); err != nil {
+		return err
+	}
+
+	return s.saveRevision(ctx, c)
+}
+
+// UndoMerge undoes the merge with the provided ID, as long as its target is visible in the context and isn't in
+// the trash. The moved emails, phones and addresses go back to the sources, the sources are restored from the
+// trash and the merged fields of the target are reverted. Otherwise, ErrMergeNotFound is returned. When the
+// merge was already undone, ErrMergeUndone is returned. When version isn't 0, the merge is only undone if the
+// target is still at this version, otherwise ErrVersionMismatch is returned. When the target or the moved
+// details were changed since the merge, ErrMergeOutdated is returned
+func (s *Service) UndoMerge(ctx context.Context, id int, version int) (*Contact, error) {
+	if err := s.Authorizer.Authorize(ctx, addressbook.PermissionWrite); err != nil {
+		return nil, err
+	}
+
+	var merge *Merge
+	var before, after *Contact
+
+	err := s.Transactor.InTx(ctx, func(ctx context.Context) (err error) {
+		if merge, err = s.MergeRepository.FindByID(ctx, id); err != nil {
+			if errors.Is(err, ErrMergeNotFound) {
+				return ErrMergeNotFound
+			}
+
+			s.Logger.Error(fmt.Sprintf("error while fetching merge %d: %v", id, err))
+			return fmt.Errorf("error while fetching merge %d: %w", id, err)
+		}
+
+		if before, err = s.findChangeable(ctx, "UndoMerge()", merge.TargetID, version); err != nil {
+			if errors.Is(err, ErrContactNotFound) {
+				return ErrMergeNotFound
+			}
+
+			return err
+		}
+
+		// the changes made since the merge would be lost by reverting the target
+		if before.Version != merge.TargetVersion {
+			return fmt.Errorf("%w: the target %d was changed since the merge", ErrMergeOutdated, merge.TargetID)
+		}
+
+		if err := s.MergeRepository.MarkUndone(ctx, id, time.Now().UTC()); err != nil {
+			if errors.Is(err, ErrMergeUndone) {
+				return ErrMergeUndone
+			}
+
+			s.Logger.Error(fmt.Sprintf("error while undoing merge %d: %v", id, err))
+			return fmt.Errorf("error while undoing merge %d: %w", id, err)
+		}
+
+		for _, src := range merge.SourceIDs {
+			if err := s.ContactsRepository.Restore(ctx, src); err != nil {
+				if errors.Is(err, ErrContactNotFound) {
+					return fmt.Errorf("%w: the source %d is no longer in the trash", ErrMergeUndone, src)
+				}
+
+				s.Logger.Error(fmt.Sprintf("error while restoring the merged contact of ID %d: %v", src, err))
+				return fmt.Errorf("error while restoring the merged contact of ID %d: %w", src, err)
+			}
+		}
+
+		for _, m := range merge.Moved {
+			reassign := s.EmailRepository.Reassign
+			switch audit.Entity(m.Entity) {
+			case audit.EntityPhone:
+				reassign = s.PhoneRepository.Reassign
+			case audit.EntityAddress:
+				reassign = s.AddressRepository.Reassign
+			}
+
+			if err := reassign(ctx, m.ID, merge.TargetID, m.From); err != nil {
+				if errors.Is(err, email.ErrEmailNotFound) || errors.Is(err, phone.ErrPhoneNotFound) ||
+					errors.Is(err, address.ErrAddressNotFound) {
+					return fmt.Errorf("%w: the %s %d is no longer in contact %d", ErrMergeOutdated, m.Entity, m.ID, merge.TargetID)
+				}
+
+				s.Logger.Error(fmt.Sprintf("error while moving %s %d back to contact %d: %v", m.Entity, m.ID, m.From, err))
+				return fmt.Errorf("error while moving %s %d back to contact %d: %w", m.Entity, m.ID, m.From, err)
+			}
+		}
+
+		reverted := *before
+		for field, value := range mergeableFields(&reverted) {
+			*value = *mergeableFields(merge.Target)[field]
+		}
+
+		if after, err = s.updateContact(ctx, reverted, before.Version); err != nil {
+			return err
+		}
+
+		for _, src := range merge.SourceIDs {
+			if err := s.saveCurrentRevision(ctx, "UndoMerge()", src); err != nil {
+				return err
+			}
+		}
+
+		if err := s.fetchDetails(ctx, "UndoMerge()", []*Contact{after}); err != nil {
+			return err
+		}
+
+		if err := s.ensurePrimaries(ctx, after); err != nil {
+			return err
+		}
+
+		if err := s.saveRevision(ctx, after); err != nil {
+			return err
+		}
+
+		if err := s.record(ctx, audit.ActionUpdate, audit.EntityContact, after.ID, after.ID, before, after); err != nil {
+			return err
+		}
+
+		for _, src := range merge.SourceIDs {
+			restored := map[string]interface{}{"id": src}
+			if err := s.record(ctx, audit.ActionRestore, audit.EntityContact, src, src, nil, restored); err != nil {
+				return err
+			}
+		}
+
+		return s.recordMoves(ctx, merge.TargetID, merge.Moved, true)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return after, nil
+}
+
+// A DuplicateCluster is a group of contacts that are likely to be the same person
+type DuplicateCluster struct {
+	// Score is the score of the strongest pair of duplicates in the cluster, from 0 to 1
+	Score    float64       `json:"score"`
+	Contacts []*Contact    `json:"contacts"`
+	Pairs    []dedupe.Pair `json:"pairs"`
+}
+
+// FindDuplicates groups the contacts visible in the context that are likely to be duplicates, comparing
+// their names, emails and phones. Only the pairs of contacts scoring at least threshold are reported, the
+// configured threshold being used when it's 0. The strongest clusters come first
+func (s *Service) FindDuplicates(ctx context.Context, threshold float64) ([]*DuplicateCluster, error) {
+	contacts, err := s.FindAllContacts(ctx, Filter{})
+	if err != nil {
+		return nil, err
+	}
+
+	if threshold == 0 {
+		threshold = s.Dedupe.Threshold
+	}
+
+	records := make([]dedupe.Record, 0, len(contacts))
+	byID := make(map[int]*Contact, len(contacts))
+
+	for _, c := range contacts {
+		r := dedupe.Record{ID: c.ID, FirstName: c.FirstName, LastName: c.LastName}
+
+		for _, e := range c.Emails {
+			r.Emails = append(r.Emails, e.Normalized)
+		}
+
+		for _, p := range c.Phones {
+			r.Phones = append(r.Phones, phoneKey(p))
+		}
+
+		records = append(records, r)
+		byID[c.ID] = c
+	}
+
+	clusters := s.Dedupe.Clusters(records, threshold)
+	duplicates := make([]*DuplicateCluster, 0, len(clusters))
+
+	for _, cl := range clusters {
+		d := &DuplicateCluster{Score: cl.Score, Pairs: cl.Pairs, Contacts: make([]*Contact, 0, len(cl.IDs))}
+
+		for _, id := range cl.IDs {
+			d.Contacts = append(d.Contacts, byID[id])
+		}
+
+		duplicates = append(duplicates, d)
+	}
+
+	return duplicates, nil
+}
+
 // ServiceSet is a wire set which contains all the bindings needed for creating a new service
 var ServiceSet = wire.NewSet(ProvideContactsService)
@@ -1,9 +1,15 @@
 package contacts
 
 import (
+	"context"
+	"errors"
 	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/LucasFrezarini/go-contacts/audit"
+	"github.com/LucasFrezarini/go-contacts/contacts/address"
+	"github.com/LucasFrezarini/go-contacts/contacts/email"
 	"github.com/LucasFrezarini/go-contacts/contacts/phone"
 	"github.com/golang/mock/gomock"
 	"go.uber.org/zap"
@@ -15,9 +21,17 @@ func TestServiceFindAllContacts(t *testing.T) {
 		&MockedContactsRepository{},
 		&MockedEmailRepository{},
 		&MockedPhoneRepository{},
+		&MockedRevisionRepository{},
+		&MockedAuthorizer{},
+		&MockedAuditor{},
+		&MockedTransactor{},
+		testEngine,
+		&MockedMergeRepository{},
+		testParser,
+		&MockedAddressRepository{},
 	)
 
-	contacts, err := service.FindAllContacts()
+	contacts, err := service.FindAllContacts(context.Background(), Filter{})
 
 	if err != nil {
 		t.Errorf("FindAllContacts() returned a non-nil error '%v', want nil", err)
@@ -58,7 +72,7 @@ func TestServiceCreate(t *testing.T) {
 	c := CreateContactData{
 		FirstName: "Zenitsu",
 		LastName:  "Agatsuma",
-		Emails:    []string{"zenitsu01@gmail.com", "zenitsu02@gmail.com"},
+		Emails:    []email.Input{{Address: "zenitsu01@gmail.com"}, {Address: "zenitsu02@gmail.com"}},
 		Phones: []phone.CreatePhoneData{
 			{
 				Type:   "home",
@@ -70,11 +84,11 @@ func TestServiceCreate(t *testing.T) {
 			},
 			{
 				Type:   "fax",
-				Number: "5511666677777",
+				Number: "551133337777",
 			},
 			{
 				Type:   "work",
-				Number: "551188889999",
+				Number: "551144448888",
 			},
 		},
 	}
@@ -84,9 +98,17 @@ func TestServiceCreate(t *testing.T) {
 		&MockedContactsRepository{},
 		&MockedEmailRepository{},
 		&MockedPhoneRepository{},
+		&MockedRevisionRepository{},
+		&MockedAuthorizer{},
+		&MockedAuditor{},
+		&MockedTransactor{},
+		testEngine,
+		&MockedMergeRepository{},
+		testParser,
+		&MockedAddressRepository{},
 	)
 
-	contact, err := service.Create(c)
+	contact, err := service.Create(context.Background(), c)
 	if err != nil {
 		t.Errorf("Create(%v) returned an non-nil error: '%v', want nil", c, err)
 	}
@@ -112,6 +134,170 @@ func TestServiceCreate(t *testing.T) {
 	}
 }
 
+func TestServiceCreateRecordsAuditEntries(t *testing.T) {
+	auditor := &MockedAuditor{}
+
+	service := ProvideContactsService(
+		zap.NewNop(),
+		&MockedContactsRepository{},
+		&MockedEmailRepository{},
+		&MockedPhoneRepository{},
+		&MockedRevisionRepository{},
+		&MockedAuthorizer{},
+		auditor,
+		&MockedTransactor{},
+		testEngine,
+		&MockedMergeRepository{},
+		testParser,
+		&MockedAddressRepository{},
+	)
+
+	contact, err := service.Create(context.Background(), CreateContactData{
+		FirstName: "Kanao",
+		LastName:  "Tsuyuri",
+		Emails:    []email.Input{{Address: "kanao@gmail.com"}},
+		Phones:    []phone.CreatePhoneData{{Type: "mobile", Number: "5511944445555"}},
+	})
+	if err != nil {
+		t.Fatalf("Create() returned an non-nil error: '%v', want nil", err)
+	}
+
+	expected := []audit.Entity{audit.EntityContact, audit.EntityEmail, audit.EntityPhone}
+	if got := len(auditor.entries); got != len(expected) {
+		t.Fatalf("Create() recorded %d audit entries, want %d", got, len(expected))
+	}
+
+	for i, e := range auditor.entries {
+		if e.Action != audit.ActionCreate || e.Entity != expected[i] || e.ContactID != contact.ID {
+			t.Errorf("Create() audit entry[%d] = %+v, want a creation of %s for contact %d", i, e, expected[i], contact.ID)
+		}
+	}
+}
+
+func TestServiceAuditFailure(t *testing.T) {
+	failure := errors.New("audit log unavailable")
+	service := ProvideContactsService(
+		zap.NewNop(),
+		&MockedContactsRepository{},
+		&MockedEmailRepository{},
+		&MockedPhoneRepository{},
+		&MockedRevisionRepository{},
+		&MockedAuthorizer{},
+		&MockedAuditor{err: failure},
+		&MockedTransactor{},
+		testEngine,
+		&MockedMergeRepository{},
+		testParser,
+		&MockedAddressRepository{},
+	)
+
+	name := "Zenitsu"
+	ctx := context.Background()
+
+	tests := []struct {
+		testName string
+		change   func() error
+	}{
+		{"create", func() error {
+			_, err := service.Create(ctx, CreateContactData{FirstName: "Zenitsu", LastName: "Agatsuma"})
+			return err
+		}},
+		{"update", func() error {
+			_, err := service.UpdateContact(ctx, 1, 0, UpdateContactData{FirstName: &name})
+			return err
+		}},
+		{"add_email", func() error {
+			_, _, err := service.AddEmail(ctx, 1, email.Input{Address: "zenitsu@gmail.com"}, 0)
+			return err
+		}},
+		{"delete", func() error { return service.DeleteContactByID(ctx, 1, 0) }},
+	}
+
+	// the change fails along with its audit entry, so its transaction is rolled back
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			if err := tt.change(); !errors.Is(err, failure) {
+				t.Errorf("change returned error %v, want %v", err, failure)
+			}
+		})
+	}
+}
+
+func TestServiceHistory(t *testing.T) {
+	auditor := &MockedAuditor{}
+	service := ProvideContactsService(
+		zap.NewNop(),
+		&MockedContactsRepository{},
+		&MockedEmailRepository{},
+		&MockedPhoneRepository{},
+		&MockedRevisionRepository{},
+		&MockedAuthorizer{},
+		auditor,
+		&MockedTransactor{},
+		testEngine,
+		&MockedMergeRepository{},
+		testParser,
+		&MockedAddressRepository{},
+	)
+
+	if err := service.DeleteContactByID(context.Background(), 1, 0); err != nil {
+		t.Fatalf("DeleteContactByID(1) returned an non-nil error: '%v', want nil", err)
+	}
+
+	history, err := service.History(context.Background(), 1)
+	if err != nil {
+		t.Errorf("History(1) returned an non-nil error: '%v', want nil", err)
+	}
+
+	if len(history) != 1 || history[0].Action != audit.ActionDelete {
+		t.Errorf("History(1) = %v, want a single deletion", history)
+	}
+
+	if _, err := service.History(context.Background(), 99); !errors.Is(err, ErrContactNotFound) {
+		t.Errorf("History(99) returned error %v, want %v", err, ErrContactNotFound)
+	}
+}
+
+func TestServiceUpdateContact(t *testing.T) {
+	auditor := &MockedAuditor{}
+	service := ProvideContactsService(
+		zap.NewNop(),
+		&MockedContactsRepository{},
+		&MockedEmailRepository{},
+		&MockedPhoneRepository{},
+		&MockedRevisionRepository{},
+		&MockedAuthorizer{},
+		auditor,
+		&MockedTransactor{},
+		testEngine,
+		&MockedMergeRepository{},
+		testParser,
+		&MockedAddressRepository{},
+	)
+
+	lastName := "Boar"
+	contact, err := service.UpdateContact(context.Background(), 1, 1, UpdateContactData{LastName: &lastName})
+	if err != nil {
+		t.Fatalf("UpdateContact() returned an non-nil error: '%v', want nil", err)
+	}
+
+	if contact.FirstName != "Inosuke" || contact.LastName != lastName || contact.Version != 2 {
+		t.Errorf("UpdateContact() = %+v, want only the last name changed and the version incremented", contact)
+	}
+
+	if len(contact.Emails) != len(filterEmailsByContactID(1)) {
+		t.Errorf("UpdateContact() contact has %d emails, want its emails kept", len(contact.Emails))
+	}
+
+	if len(auditor.entries) != 1 || auditor.entries[0].Action != audit.ActionUpdate {
+		t.Errorf("UpdateContact() recorded %v, want a single update", auditor.entries)
+	}
+
+	if _, err := service.UpdateContact(context.Background(), 1, 5, UpdateContactData{LastName: &lastName}); !errors.Is(err, ErrVersionMismatch) {
+		t.Errorf("UpdateContact() at an old version returned error %v, want %v", err, ErrVersionMismatch)
+	}
+}
+
 func TestServiceDeleteContactByID(t *testing.T) {
 	contactID := 2
 
@@ -119,18 +305,374 @@ func TestServiceDeleteContactByID(t *testing.T) {
 	defer ctrl.Finish()
 
 	repository := NewMockRepository(ctrl)
-	repository.EXPECT().DeleteByID(gomock.Eq(contactID)).Return(nil)
+	repository.EXPECT().FindByID(gomock.Any(), gomock.Eq(contactID)).Return(&Contact{ID: contactID, Version: 2}, nil).Times(2)
+	repository.EXPECT().DeleteByID(gomock.Any(), gomock.Eq(contactID), gomock.Eq(2)).Return(nil)
 
 	service := ProvideContactsService(
 		zap.NewNop(),
 		repository,
 		&MockedEmailRepository{},
 		&MockedPhoneRepository{},
+		&MockedRevisionRepository{},
+		&MockedAuthorizer{},
+		&MockedAuditor{},
+		&MockedTransactor{},
+		testEngine,
+		&MockedMergeRepository{},
+		testParser,
+		&MockedAddressRepository{},
 	)
 
-	err := service.DeleteContactByID(contactID)
+	err := service.DeleteContactByID(context.Background(), contactID, 0)
 
 	if err != nil {
 		t.Errorf("DeleteContactByID(%d) returned an non nil error: '%v', want nil", contactID, err)
 	}
 }
+
+func TestServiceFindDuplicates(t *testing.T) {
+	service := ProvideContactMockedService()
+
+	// both contacts have the home phone 1122223333
+	clusters, err := service.FindDuplicates(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("FindDuplicates() returned an error %v, want nil", err)
+	}
+
+	if len(clusters) != 1 || len(clusters[0].Contacts) != 2 || clusters[0].Score != testEngine.PhoneWeight {
+		t.Fatalf("FindDuplicates() = %+v, want a single cluster of both contacts sharing a phone", clusters)
+	}
+
+	if clusters[0].Contacts[0].ID != 1 || len(clusters[0].Contacts[0].Emails) == 0 {
+		t.Errorf("FindDuplicates() cluster contacts = %v, want the contacts with their details", clusters[0].Contacts)
+	}
+
+	if clusters, _ := service.FindDuplicates(context.Background(), 0.9); len(clusters) != 0 {
+		t.Errorf("FindDuplicates() with a 0.9 threshold = %+v, want no cluster", clusters)
+	}
+}
+
+func TestServiceEmails(t *testing.T) {
+	revisions, auditor := &MockedRevisionRepository{}, &MockedAuditor{}
+	service := ProvideContactsService(zap.NewNop(), &MockedContactsRepository{}, &MockedEmailRepository{}, &MockedPhoneRepository{},
+		revisions, &MockedAuthorizer{}, auditor, &MockedTransactor{}, testEngine, &MockedMergeRepository{}, testParser, &MockedAddressRepository{})
+
+	created, contact, err := service.AddEmail(context.Background(), 1, email.Input{Address: " Inosuke@Boar.COM "}, 1)
+	if err != nil {
+		t.Fatalf("AddEmail() returned an error %v, want nil", err)
+	}
+
+	if created.Address != "Inosuke@boar.com" || created.Normalized != "inosuke@boar.com" || created.ContactID != 1 {
+		t.Errorf("AddEmail() = %+v, want the normalized address of contact 1", created)
+	}
+
+	if contact.Version != 2 || len(revisions.revisions) != 1 || len(auditor.entries) != 1 {
+		t.Errorf("AddEmail() didn't move the contact to version 2 with a revision and an audit entry")
+	}
+
+	if created.Label != email.DefaultLabel {
+		t.Errorf("AddEmail() without a label = %+v, want the default label", created)
+	}
+
+	updated, _, err := service.UpdateEmail(context.Background(), 1, 2, email.Input{Address: "boar@gmail.com", Label: " Mountain "}, 0)
+	if err != nil || updated.Address != "boar@gmail.com" || updated.Label != "Mountain" {
+		t.Errorf("UpdateEmail() = %+v, %v, want the new address and label", updated, err)
+	}
+
+	if _, _, err := service.UpdateEmail(context.Background(), 1, 1, email.Input{Address: "INOSUKE@gmail.com"}, 0); err != nil {
+		t.Errorf("UpdateEmail() to the same address returned %v, want nil", err)
+	}
+
+	if _, err := service.DeleteEmail(context.Background(), 1, 2, 0); err != nil {
+		t.Errorf("DeleteEmail() returned %v, want nil", err)
+	}
+
+	if e, _, err := service.FindEmail(context.Background(), 2, 3); err != nil || e.Address != "tanjirou@gmail.com" {
+		t.Errorf("FindEmail() = %+v, %v, want the email 3 of contact 2", e, err)
+	}
+}
+
+func TestServiceEmailsErrors(t *testing.T) {
+	service := ProvideContactMockedService()
+	ctx := context.Background()
+
+	var testCases = []struct {
+		testName string
+		call     func() error
+		err      error
+	}{
+		{"add_invalid", func() error { _, _, err := service.AddEmail(ctx, 1, email.Input{Address: "inosuke"}, 0); return err }, email.ErrInvalidAddress},
+		{"add_long_label", func() error {
+			_, _, err := service.AddEmail(ctx, 1, email.Input{Address: "boar@gmail.com", Label: strings.Repeat("a", 51)}, 0)
+			return err
+		}, email.ErrInvalidLabel},
+		{"add_duplicate", func() error {
+			_, _, err := service.AddEmail(ctx, 1, email.Input{Address: "INOSUKE@gmail.com"}, 0)
+			return err
+		}, email.ErrDuplicateAddress},
+		{"add_stale", func() error {
+			_, _, err := service.AddEmail(ctx, 1, email.Input{Address: "boar@gmail.com"}, 5)
+			return err
+		}, ErrVersionMismatch},
+		{"add_missing_contact", func() error {
+			_, _, err := service.AddEmail(ctx, 42, email.Input{Address: "boar@gmail.com"}, 0)
+			return err
+		}, ErrContactNotFound},
+		{"update_other_contact", func() error {
+			_, _, err := service.UpdateEmail(ctx, 1, 3, email.Input{Address: "boar@gmail.com"}, 0)
+			return err
+		}, email.ErrEmailNotFound},
+		{"update_duplicate", func() error {
+			_, _, err := service.UpdateEmail(ctx, 1, 2, email.Input{Address: "inosuke@gmail.com"}, 0)
+			return err
+		}, email.ErrDuplicateAddress},
+		{"delete_other_contact", func() error { _, err := service.DeleteEmail(ctx, 1, 3, 0); return err }, email.ErrEmailNotFound},
+		{"find_other_contact", func() error { _, _, err := service.FindEmail(ctx, 1, 3); return err }, email.ErrEmailNotFound},
+		{"find_missing_contact", func() error { _, _, err := service.FindEmail(ctx, 42, 1); return err }, ErrContactNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			if err := tc.call(); !errors.Is(err, tc.err) {
+				t.Errorf("returned %v, want %v", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestServicePhones(t *testing.T) {
+	revisions, auditor := &MockedRevisionRepository{}, &MockedAuditor{}
+	service := ProvideContactsService(zap.NewNop(), &MockedContactsRepository{}, &MockedEmailRepository{}, &MockedPhoneRepository{},
+		revisions, &MockedAuthorizer{}, auditor, &MockedTransactor{}, testEngine, &MockedMergeRepository{}, testParser, &MockedAddressRepository{})
+
+	created, contact, err := service.AddPhone(context.Background(), 1, phone.CreatePhoneData{Number: " 11 98888-7777 ", Label: " desk "}, 1)
+	if err != nil {
+		t.Fatalf("AddPhone() returned an error %v, want nil", err)
+	}
+
+	if created.E164 != "+5511988887777" || created.Type != phone.DefaultType || created.Label != "desk" || created.ContactID != 1 {
+		t.Errorf("AddPhone() = %+v, want the parsed phone of contact 1 with the default type", created)
+	}
+
+	if contact.Version != 2 || len(revisions.revisions) != 1 || len(auditor.entries) != 1 {
+		t.Errorf("AddPhone() didn't move the contact to version 2 with a revision and an audit entry")
+	}
+
+	updated, _, err := service.UpdatePhone(context.Background(), 1, 2, phone.CreatePhoneData{Number: "+5511933332222", Type: phone.PhoneTypeWork}, 0)
+	if err != nil || updated.E164 != "+5511933332222" || updated.Type != phone.PhoneTypeWork {
+		t.Errorf("UpdatePhone() = %+v, %v, want the new number and type", updated, err)
+	}
+
+	if _, err := service.DeletePhone(context.Background(), 1, 2, 0); err != nil {
+		t.Errorf("DeletePhone() returned %v, want nil", err)
+	}
+
+	if p, _, err := service.FindPhone(context.Background(), 2, 5); err != nil || p.Number != "11955554444" {
+		t.Errorf("FindPhone() = %+v, %v, want the phone 5 of contact 2", p, err)
+	}
+}
+
+func TestServicePhonesErrors(t *testing.T) {
+	service := ProvideContactMockedService()
+	ctx := context.Background()
+	valid := phone.CreatePhoneData{Number: "11988887777"}
+
+	var testCases = []struct {
+		testName string
+		call     func() error
+		err      error
+	}{
+		{"add_invalid_number", func() error {
+			_, _, err := service.AddPhone(ctx, 1, phone.CreatePhoneData{Number: "123"}, 0)
+			return err
+		}, phone.ErrInvalidNumber},
+		{"add_invalid_type", func() error {
+			_, _, err := service.AddPhone(ctx, 1, phone.CreatePhoneData{Number: "11988887777", Type: "pager"}, 0)
+			return err
+		}, phone.ErrInvalidType},
+		{"add_stale", func() error { _, _, err := service.AddPhone(ctx, 1, valid, 5); return err }, ErrVersionMismatch},
+		{"add_missing_contact", func() error { _, _, err := service.AddPhone(ctx, 42, valid, 0); return err }, ErrContactNotFound},
+		{"update_other_contact", func() error { _, _, err := service.UpdatePhone(ctx, 1, 5, valid, 0); return err }, phone.ErrPhoneNotFound},
+		{"update_invalid_type", func() error {
+			_, _, err := service.UpdatePhone(ctx, 1, 2, phone.CreatePhoneData{Number: "11988887777", Type: "pager"}, 0)
+			return err
+		}, phone.ErrInvalidType},
+		{"delete_other_contact", func() error { _, err := service.DeletePhone(ctx, 1, 5, 0); return err }, phone.ErrPhoneNotFound},
+		{"find_other_contact", func() error { _, _, err := service.FindPhone(ctx, 1, 5); return err }, phone.ErrPhoneNotFound},
+		{"find_missing_contact", func() error { _, _, err := service.FindPhone(ctx, 42, 1); return err }, ErrContactNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			if err := tc.call(); !errors.Is(err, tc.err) {
+				t.Errorf("returned %v, want %v", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestServiceAddresses(t *testing.T) {
+	revisions, auditor := &MockedRevisionRepository{}, &MockedAuditor{}
+	service := ProvideContactsService(zap.NewNop(), &MockedContactsRepository{}, &MockedEmailRepository{}, &MockedPhoneRepository{},
+		revisions, &MockedAuthorizer{}, auditor, &MockedTransactor{}, testEngine, &MockedMergeRepository{}, testParser, &MockedAddressRepository{})
+	ctx := context.Background()
+
+	created, contact, err := service.AddAddress(ctx, 1, address.CreateAddressData{Locality: " Tokyo ", Country: "jp", Label: "WORK"}, 1)
+	if err != nil {
+		t.Fatalf("AddAddress() returned an error %v, want nil", err)
+	}
+
+	expected := &address.Address{ID: created.ID, ContactID: 1, Locality: "Tokyo", Country: "JP", Label: address.LabelWork}
+	if !reflect.DeepEqual(created, expected) {
+		t.Errorf("AddAddress() = %+v, want %+v", created, expected)
+	}
+
+	if contact.Version != 2 || len(revisions.revisions) != 1 || len(auditor.entries) != 1 {
+		t.Errorf("AddAddress() didn't move the contact to version 2 with a revision and an audit entry")
+	}
+
+	updated, _, err := service.UpdateAddress(ctx, 1, 1, address.CreateAddressData{PostalCode: "01310-100", Country: "BR"}, 0)
+	if err != nil || updated.PostalCode != "01310-100" || updated.Street != "" || updated.Label != address.DefaultLabel {
+		t.Errorf("UpdateAddress() = %+v, %v, want the address replaced with the default label", updated, err)
+	}
+
+	if _, err := service.DeleteAddress(ctx, 1, 1, 0); err != nil {
+		t.Errorf("DeleteAddress() returned %v, want nil", err)
+	}
+
+	if a, _, err := service.FindAddress(ctx, 2, 2); err != nil || a.Locality != "Tokyo" {
+		t.Errorf("FindAddress() = %+v, %v, want the address 2 of contact 2", a, err)
+	}
+
+	withAddresses, err := service.Create(ctx, CreateContactData{
+		FirstName: "Kanao",
+		LastName:  "Tsuyuri",
+		Addresses: []address.CreateAddressData{{Region: "Tokyo", Country: "JP"}},
+	})
+
+	if err != nil || len(withAddresses.Addresses) != 1 || withAddresses.Addresses[0].Label != address.DefaultLabel {
+		t.Errorf("Create() = %+v, %v, want the contact with its parsed address", withAddresses, err)
+	}
+}
+
+func TestServiceAddressesErrors(t *testing.T) {
+	service := ProvideContactMockedService()
+	ctx := context.Background()
+	valid := address.CreateAddressData{Locality: "Tokyo"}
+
+	var testCases = []struct {
+		testName string
+		call     func() error
+		err      error
+	}{
+		{"add_empty", func() error {
+			_, _, err := service.AddAddress(ctx, 1, address.CreateAddressData{Country: "JP"}, 0)
+			return err
+		}, address.ErrInvalidAddress},
+		{"add_invalid_country", func() error {
+			_, _, err := service.AddAddress(ctx, 1, address.CreateAddressData{Locality: "Tokyo", Country: "JPN"}, 0)
+			return err
+		}, address.ErrInvalidCountry},
+		{"add_stale", func() error { _, _, err := service.AddAddress(ctx, 1, valid, 5); return err }, ErrVersionMismatch},
+		{"add_missing_contact", func() error { _, _, err := service.AddAddress(ctx, 42, valid, 0); return err }, ErrContactNotFound},
+		{"create_invalid_address", func() error {
+			_, err := service.Create(ctx, CreateContactData{FirstName: "Kanao", LastName: "Tsuyuri", Addresses: []address.CreateAddressData{{}}})
+			return err
+		}, address.ErrInvalidAddress},
+		{"update_other_contact", func() error { _, _, err := service.UpdateAddress(ctx, 1, 2, valid, 0); return err }, address.ErrAddressNotFound},
+		{"delete_other_contact", func() error { _, err := service.DeleteAddress(ctx, 1, 2, 0); return err }, address.ErrAddressNotFound},
+		{"find_other_contact", func() error { _, _, err := service.FindAddress(ctx, 1, 2); return err }, address.ErrAddressNotFound},
+		{"find_missing_contact", func() error { _, _, err := service.FindAddress(ctx, 42, 1); return err }, ErrContactNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			if err := tc.call(); !errors.Is(err, tc.err) {
+				t.Errorf("returned %v, want %v", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestServiceOrganizations(t *testing.T) {
+	service := ProvideContactMockedService()
+	ctx := context.Background()
+
+	created, err := service.Create(ctx, CreateContactData{FirstName: "Kyojuro", LastName: "Rengoku", Organization: " Demon Slayer Corps ", Title: "Hashira"})
+	if err != nil || created.Organization != "Demon Slayer Corps" || created.Title != "Hashira" {
+		t.Errorf("Create() = %+v, %v, want the organization trimmed", created, err)
+	}
+
+	cleared := ""
+	updated, err := service.UpdateContact(ctx, 1, 0, UpdateContactData{Organization: &cleared})
+	if err != nil || updated.Organization != "" || updated.Department != "Beast Breathing" || updated.Title != "Demon Slayer" {
+		t.Errorf("UpdateContact() = %+v, %v, want only the organization cleared", updated, err)
+	}
+
+	contacts, err := service.FindAllContacts(ctx, Filter{Organization: "Demon Slayer Corps", Sort: SortOrganization})
+	if err != nil || len(contacts) != 1 || contacts[0].ID != 1 {
+		t.Errorf("FindAllContacts() by organization = %v, %v, want only the contact 1", contacts, err)
+	}
+
+	if _, err := service.FindAllContacts(ctx, Filter{Sort: "title"}); !errors.Is(err, ErrInvalidSort) {
+		t.Errorf("FindAllContacts() with an unknown sort returned %v, want ErrInvalidSort", err)
+	}
+
+	organizations, err := service.FindOrganizations(ctx)
+	expected := []Organization{{Name: "Demon Slayer Corps", Contacts: 1}, {Name: "Kamaboko Squad", Contacts: 1}}
+	if err != nil || !reflect.DeepEqual(organizations, expected) {
+		t.Errorf("FindOrganizations() = %v, %v, want %v", organizations, err, expected)
+	}
+}
+
+func TestServicePrimaries(t *testing.T) {
+	service := ProvideContactsService(zap.NewNop(), &MockedContactsRepository{}, &MockedEmailRepository{}, &MockedPhoneRepository{},
+		&MockedRevisionRepository{}, &MockedAuthorizer{}, &MockedAuditor{}, &MockedTransactor{}, testEngine, &MockedMergeRepository{}, testParser, &MockedAddressRepository{})
+	ctx := context.Background()
+
+	created, err := service.Create(ctx, CreateContactData{
+		FirstName: "Kanao",
+		LastName:  "Tsuyuri",
+		Emails:    []email.Input{{Address: "kanao@gmail.com"}, {Address: "tsuyuri@gmail.com"}},
+		Phones:    []phone.CreatePhoneData{{Number: "11988887777"}, {Number: "11977776666"}},
+	})
+
+	if err != nil {
+		t.Fatalf("Create() returned an error %v, want nil", err)
+	}
+
+	if e, p := created.PrimaryEmail(), created.PrimaryPhone(); e != &created.Emails[0] || p != &created.Phones[0] ||
+		created.Emails[1].IsPrimary || created.Phones[1].IsPrimary {
+		t.Errorf("Create() = %+v, want only the first email and the first phone primary", created)
+	}
+
+	if e, _, err := service.AddEmail(ctx, 1, email.Input{Address: "boar@gmail.com"}, 0); err != nil || e.IsPrimary {
+		t.Errorf("AddEmail() to a contact with emails = %+v, %v, want an email that isn't primary", e, err)
+	}
+
+	e, contact, err := service.PromoteEmail(ctx, 1, 2, 0)
+	if err != nil || !e.IsPrimary || contact.PrimaryEmail().ID != 2 || contact.Emails[0].IsPrimary {
+		t.Errorf("PromoteEmail(1, 2) = %+v, %+v, %v, want the email 2 as the only primary one", e, contact, err)
+	}
+
+	if contact, err := service.DeleteEmail(ctx, 1, 2, 0); err != nil || contact.PrimaryEmail() == nil || contact.PrimaryEmail().ID != 1 {
+		t.Errorf("DeleteEmail() of the primary email = %+v, %v, want the email 1 promoted", contact, err)
+	}
+
+	p, contact, err := service.PromotePhone(ctx, 1, 3, 0)
+	if err != nil || !p.IsPrimary || contact.PrimaryPhone().ID != 3 || contact.Phones[0].IsPrimary {
+		t.Errorf("PromotePhone(1, 3) = %+v, %+v, %v, want the phone 3 as the only primary one", p, contact, err)
+	}
+
+	if contact, err := service.DeletePhone(ctx, 1, 3, 0); err != nil || contact.PrimaryPhone() == nil || contact.PrimaryPhone().ID != 1 {
+		t.Errorf("DeletePhone() of the primary phone = %+v, %v, want the phone 1 promoted", contact, err)
+	}
+
+	if _, _, err := service.PromoteEmail(ctx, 1, 3, 0); !errors.Is(err, email.ErrEmailNotFound) {
+		t.Errorf("PromoteEmail() of an email of another contact returned %v, want ErrEmailNotFound", err)
+	}
+
+	if _, _, err := service.PromotePhone(ctx, 1, 5, 0); !errors.Is(err, phone.ErrPhoneNotFound) {
+		t.Errorf("PromotePhone() of a phone of another contact returned %v, want ErrPhoneNotFound", err)
+	}
+}
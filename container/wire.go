@@ -3,12 +3,13 @@
 package container
 
 import (
+	"github.com/LucasFrezarini/go-contacts/config"
 	"github.com/LucasFrezarini/go-contacts/server"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/google/wire"
 )
 
-func InitializeServer() (*server.Server, error) {
+func InitializeServer(args config.Args) (*server.Server, func(), error) {
 	wire.Build(server.ServerSet)
-	return &server.Server{}, nil
+	return &server.Server{}, nil, nil
 }
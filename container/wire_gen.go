@@ -6,11 +6,20 @@
 package container
 
 import (
+	"github.com/LucasFrezarini/go-contacts/addressbook"
+	"github.com/LucasFrezarini/go-contacts/admin"
+	"github.com/LucasFrezarini/go-contacts/apikey"
+	"github.com/LucasFrezarini/go-contacts/audit"
+	"github.com/LucasFrezarini/go-contacts/auth"
+	"github.com/LucasFrezarini/go-contacts/config"
 	"github.com/LucasFrezarini/go-contacts/contacts"
+	"github.com/LucasFrezarini/go-contacts/contacts/address"
+	"github.com/LucasFrezarini/go-contacts/contacts/dedupe"
 	"github.com/LucasFrezarini/go-contacts/contacts/email"
 	"github.com/LucasFrezarini/go-contacts/contacts/phone"
 	"github.com/LucasFrezarini/go-contacts/db"
 	"github.com/LucasFrezarini/go-contacts/logger"
+	"github.com/LucasFrezarini/go-contacts/ratelimit"
 	"github.com/LucasFrezarini/go-contacts/server"
 	"github.com/LucasFrezarini/go-contacts/server/middlewares"
 	"github.com/LucasFrezarini/go-contacts/server/routes"
@@ -22,23 +31,62 @@ import (
 
 // Injectors from wire.go:
 
-func InitializeServer() (*server.Server, error) {
-	zapLogger, err := logger.ProvideLogger()
+func InitializeServer(args config.Args) (*server.Server, func(), error) {
+	configConfig, err := config.ProvideConfig(args)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	sqlDB, err := db.ProvideDB(zapLogger)
+	atomicLevel, err := logger.ProvideLevel(configConfig)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	zapLogger, cleanup, err := logger.ProvideLogger(configConfig, atomicLevel)
+	if err != nil {
+		return nil, nil, err
+	}
+	sqlDB, err := db.ProvideDB(configConfig, zapLogger)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
 	}
 	contactsRepository := contacts.ProvideContactsRepository(sqlDB, zapLogger)
 	repository := email.ProvideEmailRepository(sqlDB, zapLogger)
 	phoneRepository := phone.ProvideRepository(sqlDB, zapLogger)
-	service := contacts.ProvideContactsService(zapLogger, contactsRepository, repository, phoneRepository)
-	container := middlewares.ProvideMiddlewaresContainer(zapLogger)
-	echo := server.ProvideEcho(container)
-	controller := contacts.ProvideContactsController(service, contactsRepository, zapLogger, echo)
-	router := routes.ProvideRouter(controller, zapLogger, echo)
-	serverServer := server.ProvideServer(router, zapLogger, echo)
-	return serverServer, nil
+	revisionsRepository := contacts.ProvideRevisionsRepository(sqlDB, zapLogger)
+	addressbookRepository := addressbook.ProvideRepository(sqlDB, zapLogger)
+	txManager := db.ProvideTxManager(sqlDB)
+	service := addressbook.ProvideService(zapLogger, addressbookRepository, txManager)
+	auditRepository := audit.ProvideRepository(sqlDB, zapLogger)
+	auditService := audit.ProvideService(zapLogger, auditRepository)
+	engine := dedupe.ProvideEngine(configConfig)
+	mergesRepository := contacts.ProvideMergesRepository(sqlDB, zapLogger)
+	parser := phone.ProvideParser(configConfig)
+	addressRepository := address.ProvideRepository(sqlDB, zapLogger)
+	contactsService := contacts.ProvideContactsService(zapLogger, contactsRepository, repository, phoneRepository, revisionsRepository, service, auditService, txManager, engine, mergesRepository, parser, addressRepository)
+	jwtAuthenticator, err := auth.ProvideJWTAuthenticator(configConfig)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	apikeyRepository := apikey.ProvideRepository(sqlDB, zapLogger)
+	apikeyService := apikey.ProvideService(zapLogger, apikeyRepository)
+	memoryStore := ratelimit.ProvideMemoryStore()
+	limiter, err := ratelimit.ProvideLimiter(configConfig, memoryStore)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	container := middlewares.ProvideMiddlewaresContainer(configConfig, zapLogger, jwtAuthenticator, apikeyService, limiter)
+	echo := server.ProvideEcho(configConfig, container)
+	controller := contacts.ProvideContactsController(contactsService, contactsRepository, zapLogger, echo)
+	adminController := admin.ProvideController(atomicLevel, zapLogger, echo)
+	addressbookController := addressbook.ProvideController(service, zapLogger, echo)
+	apikeyController := apikey.ProvideController(apikeyService, zapLogger, echo)
+	auditController := audit.ProvideController(auditService, zapLogger, echo)
+	router := routes.ProvideRouter(controller, adminController, addressbookController, apikeyController, auditController, zapLogger, echo)
+	purgeJob := contacts.ProvidePurgeJob(configConfig, contactsRepository, zapLogger)
+	serverServer := server.ProvideServer(configConfig, router, purgeJob, zapLogger, echo)
+	return serverServer, func() {
+		cleanup()
+	}, nil
 }
@@ -1,30 +1,135 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"net"
+	"time"
 
-	"github.com/LucasFrezarini/go-contacts/env"
+	"github.com/LucasFrezarini/go-contacts/config"
+	"github.com/go-sql-driver/mysql"
 	"github.com/google/wire"
 	"go.uber.org/zap"
 )
 
-// ProvideDB opens a sql.DB connection that will be used in the whole project
-func ProvideDB(logger *zap.Logger) (*sql.DB, error) {
+// A Pinger is anything that can check if the database is reachable, like *sql.DB.
+// It exists to allow testing the startup retry logic without a real database
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// after is replaced in tests to avoid actually waiting between the attempts
+var after = time.After
+
+// DSN builds the MySQL driver DSN from the provided configuration
+func DSN(m config.MySQL) string {
+	c := mysql.NewConfig()
+	c.User = m.User
+	c.Passwd = m.Password
+	c.Net = "tcp"
+	c.Addr = net.JoinHostPort(m.Host, m.Port)
+	c.DBName = m.Database
+	c.ParseTime = m.ParseTime
+	c.Timeout = m.Timeout
+	c.ReadTimeout = m.ReadTimeout
+	c.WriteTimeout = m.WriteTimeout
+	c.TLSConfig = m.TLS
+
+	return c.FormatDSN()
+}
+
+// ProvideDB opens a sql.DB connection that will be used in the whole project. It only returns once
+// the database answers a ping, retrying with exponential backoff, so the server never starts
+// without a reachable database. The pending migrations are then applied, unless disabled
+func ProvideDB(cfg *config.Config, logger *zap.Logger) (*sql.DB, error) {
 	l := logger.Named("ProvideDB")
-	e := env.GetEnvironment()
+	m := cfg.MySQL
 
-	uri := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s", e.MySQL.User, e.MySQL.Password, e.MySQL.Host, e.MySQL.Port, e.MySQL.Database)
-	l.Info("opening connection to MySQL with URI " + uri)
+	l.Info("opening connection to MySQL",
+		zap.String("host", m.Host),
+		zap.String("port", m.Port),
+		zap.String("user", m.User),
+		zap.String("database", m.Database),
+	)
 
-	db, err := sql.Open("mysql", uri)
+	db, err := sql.Open("mysql", DSN(m))
 	if err != nil {
 		return nil, fmt.Errorf("ProvideDB: error while creating sql.Conn: %w", err)
 	}
 
+	db.SetMaxOpenConns(m.Pool.MaxOpenConns)
+	db.SetMaxIdleConns(m.Pool.MaxIdleConns)
+	db.SetConnMaxLifetime(m.Pool.ConnMaxLifetime)
+
+	if err := WaitForDB(context.Background(), db, m.Retry, m.Timeout, l); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("ProvideDB: %w", err)
+	}
+
+	if m.Migrate {
+		migrations, err := LoadMigrations(m.MigrationsDir)
+		if err == nil {
+			err = Migrate(context.Background(), db, migrations, l)
+		}
+
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("ProvideDB: %w", err)
+		}
+	}
+
 	l.Info("ProvideDB: connection openned successfully")
 	return db, nil
 }
 
+// WaitForDB pings the database until it answers, waiting retry.InitialInterval before the first retry
+// and doubling the wait on each attempt, up to retry.MaxInterval. It gives up after retry.MaxAttempts
+// attempts, returning the last ping error. Each ping is given up after timeout, unless it's 0
+func WaitForDB(ctx context.Context, p Pinger, retry config.MySQLRetry, timeout time.Duration, logger *zap.Logger) error {
+	interval := retry.InitialInterval
+	var err error
+
+	for attempt := 1; attempt <= retry.MaxAttempts; attempt++ {
+		pingCtx, cancel := ctx, func() {}
+		if timeout > 0 {
+			pingCtx, cancel = context.WithTimeout(ctx, timeout)
+		}
+
+		err = p.PingContext(pingCtx)
+		cancel()
+
+		if err == nil {
+			return nil
+		}
+
+		if attempt == retry.MaxAttempts {
+			break
+		}
+
+		logger.Warn("MySQL isn't reachable yet, retrying",
+			zap.Int("attempt", attempt),
+			zap.Duration("retry_in", interval),
+			zap.Error(err),
+		)
+
+		select {
+		case <-after(interval):
+		case <-ctx.Done():
+			return fmt.Errorf("gave up waiting for MySQL: %w", ctx.Err())
+		}
+
+		if interval *= 2; interval > retry.MaxInterval {
+			interval = retry.MaxInterval
+		}
+	}
+
+	return fmt.Errorf("MySQL unreachable after %d attempts: %w", retry.MaxAttempts, err)
+}
+
 // DBSet is the wire.ProviderSet that represents this package
-var DBSet = wire.NewSet(ProvideDB)
+var DBSet = wire.NewSet(
+	ProvideDB,
+	ProvideTxManager,
+	wire.Bind(new(Transactor), new(*TxManager)),
+)
@@ -0,0 +1,117 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LucasFrezarini/go-contacts/config"
+	"go.uber.org/zap"
+)
+
+type fakePinger struct {
+	failures int
+	calls    int
+}
+
+func (p *fakePinger) PingContext(ctx context.Context) error {
+	p.calls++
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if p.calls <= p.failures {
+		return errors.New("connection refused")
+	}
+
+	return nil
+}
+
+func TestWaitForDB(t *testing.T) {
+	var waits []time.Duration
+	after = func(d time.Duration) <-chan time.Time {
+		waits = append(waits, d)
+		ch := make(chan time.Time, 1)
+		ch <- time.Now()
+		return ch
+	}
+	defer func() { after = time.After }()
+
+	retry := config.MySQLRetry{MaxAttempts: 5, InitialInterval: time.Second, MaxInterval: 3 * time.Second}
+
+	var testCases = []struct {
+		testName      string
+		failures      int
+		expectedCalls int
+		expectedWaits []time.Duration
+		expectErr     bool
+	}{
+		{"reachable", 0, 1, nil, false},
+		{"reachable_after_retries", 3, 4, []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}, false},
+		{"unreachable", 10, 5, []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			waits = nil
+			p := &fakePinger{failures: tc.failures}
+
+			err := WaitForDB(context.Background(), p, retry, time.Second, zap.NewNop())
+
+			if tc.expectErr && (err == nil || !strings.Contains(err.Error(), "5 attempts")) {
+				t.Errorf("WaitForDB() error == %v, want an error mentioning the 5 attempts", err)
+			}
+
+			if !tc.expectErr && err != nil {
+				t.Errorf("WaitForDB() returned an error: %v", err)
+			}
+
+			if p.calls != tc.expectedCalls {
+				t.Errorf("WaitForDB() pinged %d times, want %d", p.calls, tc.expectedCalls)
+			}
+
+			if expected, got := len(tc.expectedWaits), len(waits); expected != got {
+				t.Fatalf("WaitForDB() waited %d times (%v), want %d", got, waits, expected)
+			}
+
+			for i, w := range tc.expectedWaits {
+				if waits[i] != w {
+					t.Errorf("WaitForDB() wait[%d] == %v, want %v", i, waits[i], w)
+				}
+			}
+		})
+	}
+}
+
+func TestWaitForDBWithoutTimeout(t *testing.T) {
+	retry := config.MySQLRetry{MaxAttempts: 1}
+	p := &fakePinger{}
+
+	// a zero timeout leaves the dial timeout of the driver alone, instead of expiring every ping right away
+	if err := WaitForDB(context.Background(), p, retry, 0, zap.NewNop()); err != nil {
+		t.Errorf("WaitForDB() without timeout returned an error: %v", err)
+	}
+}
+
+func TestDSN(t *testing.T) {
+	dsn := DSN(config.MySQL{
+		Host:         "db",
+		Port:         "3306",
+		User:         "root",
+		Password:     "secret",
+		Database:     "go_contacts",
+		ParseTime:    true,
+		Timeout:      5 * time.Second,
+		ReadTimeout:  30 * time.Second,
+		WriteTimeout: 30 * time.Second,
+		TLS:          "skip-verify",
+	})
+
+	for _, expected := range []string{"root:secret@tcp(db:3306)/go_contacts", "parseTime=true", "timeout=5s", "readTimeout=30s", "tls=skip-verify"} {
+		if !strings.Contains(dsn, expected) {
+			t.Errorf("DSN() == %q, doesn't contain %q", dsn, expected)
+		}
+	}
+}
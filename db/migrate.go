@@ -0,0 +1,180 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// A Migration is a SQL script changing the schema, applied once and in the order of its version
+type Migration struct {
+	Version int
+	Name    string
+	Script  string
+}
+
+// migrationName matches the names of the migration files, like 0001_create_contacts.sql
+var migrationName = regexp.MustCompile(`^(\d+)_\w+\.sql$`)
+
+// The servers starting at the same time take turns through a named lock, so each migration is applied once
+const (
+	migrationsLock        = "go_contacts_schema_migrations"
+	migrationsLockTimeout = 60
+)
+
+// LoadMigrations reads the migrations in the directory, sorted by version. The other files are ignored
+func LoadMigrations(dir string) ([]Migration, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("LoadMigrations(%s): error while listing the migrations: %w", dir, err)
+	}
+
+	migrations := make([]Migration, 0, len(files))
+
+	for _, f := range files {
+		match := migrationName.FindStringSubmatch(f.Name())
+		if f.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("LoadMigrations(%s): invalid version of %s: %w", dir, f.Name(), err)
+		}
+
+		script, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("LoadMigrations(%s): error while reading %s: %w", dir, f.Name(), err)
+		}
+
+		migrations = append(migrations, Migration{Version: version, Name: f.Name(), Script: string(script)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("LoadMigrations(%s): %s and %s have the same version", dir, migrations[i-1].Name, migrations[i].Name)
+		}
+	}
+
+	return migrations, nil
+}
+
+// statements splits the script into its statements, which must end with a semicolon at the end of a line.
+// The comment lines are left out, since they may contain semicolons too
+func statements(script string) []string {
+	stmts := make([]string, 0)
+	var current []string
+
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+
+		current = append(current, line)
+
+		if strings.HasSuffix(trimmed, ";") {
+			stmts = append(stmts, strings.TrimSuffix(strings.TrimSpace(strings.Join(current, "\n")), ";"))
+			current = nil
+		}
+	}
+
+	if rest := strings.TrimSpace(strings.Join(current, "\n")); rest != "" {
+		stmts = append(stmts, rest)
+	}
+
+	return stmts
+}
+
+// Migrate applies the migrations that weren't applied yet, in order, recording each one in the
+// schema_migrations table. MySQL commits the schema changes right away, so a migration failing halfway
+// must be completed by hand and recorded before starting again
+func Migrate(ctx context.Context, db *sql.DB, migrations []Migration, logger *zap.Logger) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("Migrate: error while opening a connection: %w", err)
+	}
+
+	defer conn.Close()
+
+	var locked sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", migrationsLock, migrationsLockTimeout).Scan(&locked); err != nil {
+		return fmt.Errorf("Migrate: error while taking the migrations lock: %w", err)
+	}
+
+	if locked.Int64 != 1 {
+		return fmt.Errorf("Migrate: gave up waiting for the migrations lock after %ds", migrationsLockTimeout)
+	}
+
+	defer conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", migrationsLock)
+
+	raw := "CREATE TABLE IF NOT EXISTS `schema_migrations` (" +
+		"`version` INT NOT NULL, `name` VARCHAR(255) NOT NULL, `applied_at` DATETIME(6) NOT NULL, PRIMARY KEY (`version`))"
+
+	if _, err := conn.ExecContext(ctx, raw); err != nil {
+		return fmt.Errorf("Migrate: error while creating the schema_migrations table: %w", err)
+	}
+
+	applied, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if applied[m.Version] {
+			continue
+		}
+
+		logger.Info("applying migration", zap.String("migration", m.Name))
+
+		for _, stmt := range statements(m.Script) {
+			if _, err := conn.ExecContext(ctx, stmt); err != nil {
+				return fmt.Errorf("Migrate: error while applying %s: %w", m.Name, err)
+			}
+		}
+
+		raw := "INSERT INTO `schema_migrations` (`version`, `name`, `applied_at`) VALUES (?, ?, ?)"
+		if _, err := conn.ExecContext(ctx, raw, m.Version, m.Name, time.Now().UTC()); err != nil {
+			return fmt.Errorf("Migrate: error while recording %s: %w", m.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// appliedVersions returns the versions of the migrations recorded in the schema_migrations table
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int]bool, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT `version` FROM `schema_migrations`")
+	if err != nil {
+		return nil, fmt.Errorf("Migrate: error while fetching the applied migrations: %w", err)
+	}
+
+	defer rows.Close()
+	applied := make(map[int]bool)
+
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, fmt.Errorf("Migrate: error while scanning the applied migrations: %w", err)
+		}
+
+		applied[version] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Migrate: error while fetching the applied migrations: %w", err)
+	}
+
+	return applied, nil
+}
@@ -0,0 +1,143 @@
+package db
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"go.uber.org/zap"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := LoadMigrations("migrations")
+	if err != nil {
+		t.Fatalf("LoadMigrations() returned an error %v, want nil", err)
+	}
+
+	if len(migrations) == 0 {
+		t.Fatalf("LoadMigrations() returned no migrations")
+	}
+
+	// the versions follow each other, so a migration can't be skipped by mistake
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("LoadMigrations()[%d] is %s, want the version %d", i, m.Name, i+1)
+		}
+
+		if len(statements(m.Script)) == 0 {
+			t.Errorf("LoadMigrations()[%d] %s has no statements", i, m.Name)
+		}
+	}
+}
+
+func TestLoadMigrationsDuplicateVersion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrations")
+	if err != nil {
+		t.Fatalf("unexpected error while creating a temporary directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"0002_add_owner.sql":      "ALTER TABLE `contact` ADD COLUMN `owner` VARCHAR(255);",
+		"0001_create_contact.sql": "CREATE TABLE `contact` (`id` INT);",
+		"README.md":               "not a migration",
+	}
+
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatalf("unexpected error while writing %s: %v", name, err)
+		}
+	}
+
+	migrations, err := LoadMigrations(dir)
+	if err != nil {
+		t.Fatalf("LoadMigrations() returned an error %v, want nil", err)
+	}
+
+	if len(migrations) != 2 || migrations[0].Name != "0001_create_contact.sql" || migrations[1].Name != "0002_add_owner.sql" {
+		t.Errorf("LoadMigrations() = %+v, want 0001_create_contact.sql then 0002_add_owner.sql", migrations)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "0002_add_email.sql"), []byte("SELECT 1;"), 0600); err != nil {
+		t.Fatalf("unexpected error while writing 0002_add_email.sql: %v", err)
+	}
+
+	if _, err := LoadMigrations(dir); err == nil || !strings.Contains(err.Error(), "same version") {
+		t.Errorf("LoadMigrations() with two migrations 0002 returned %v, want an error", err)
+	}
+}
+
+func TestStatements(t *testing.T) {
+	script := "-- Contacts have an owner; the existing ones have none.\n" +
+		"ALTER TABLE `contact`\n  ADD COLUMN `owner` VARCHAR(255) NOT NULL DEFAULT '';\n\n" +
+		"UPDATE `contact` SET `owner` = 'tanjiro';\n" +
+		"SELECT 1"
+
+	expected := []string{
+		"ALTER TABLE `contact`\n  ADD COLUMN `owner` VARCHAR(255) NOT NULL DEFAULT ''",
+		"UPDATE `contact` SET `owner` = 'tanjiro'",
+		"SELECT 1",
+	}
+
+	if got := statements(script); !reflect.DeepEqual(got, expected) {
+		t.Errorf("statements() = %q, want %q", got, expected)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer conn.Close()
+
+	migrations := []Migration{
+		{Version: 1, Name: "0001_create_contact.sql", Script: "CREATE TABLE `contact` (`id` INT);"},
+		{Version: 2, Name: "0002_create_email.sql", Script: "CREATE TABLE `email` (`id` INT);\nCREATE INDEX `idx` ON `email` (`id`);"},
+	}
+
+	mock.ExpectQuery("SELECT GET_LOCK").WithArgs(migrationsLock, migrationsLockTimeout).
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `schema_migrations`").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT `version` FROM `schema_migrations`").WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	mock.ExpectExec("CREATE TABLE `email`").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE INDEX `idx`").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO `schema_migrations`").WithArgs(2, "0002_create_email.sql", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("SELECT RELEASE_LOCK").WithArgs(migrationsLock).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err := Migrate(context.Background(), conn, migrations, zap.NewNop()); err != nil {
+		t.Fatalf("Migrate() returned an error %v, want nil", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled mock expectations: %v", err)
+	}
+}
+
+func TestMigrateLockTimeout(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer conn.Close()
+
+	mock.ExpectQuery("SELECT GET_LOCK").WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(0))
+
+	migrations := []Migration{{Version: 1, Name: "0001_create_contact.sql", Script: "CREATE TABLE `contact` (`id` INT);"}}
+	if err := Migrate(context.Background(), conn, migrations, zap.NewNop()); err == nil {
+		t.Errorf("Migrate() without the lock returned nil, want an error")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled mock expectations: %v", err)
+	}
+}
@@ -0,0 +1,6 @@
+-- Every contact belongs to the subject of the principal that created it.
+-- Contacts created before this migration get an empty owner and aren't visible to anyone
+-- until they are assigned, e.g. UPDATE `contact` SET `owner` = '<subject>' WHERE `owner` = '';
+ALTER TABLE `contact`
+  ADD COLUMN `owner` VARCHAR(255) NOT NULL DEFAULT '' AFTER `id`,
+  ADD INDEX `idx_contact_owner` (`owner`);
@@ -0,0 +1,25 @@
+-- Address books let several subjects share contacts. Every member has a role:
+-- viewers can only read, editors can also create and delete contacts, and owners manage the sharing.
+-- Contacts without an address book remain private to their owner.
+CREATE TABLE `address_book` (
+  `id` INT NOT NULL AUTO_INCREMENT,
+  `name` VARCHAR(100) NOT NULL,
+  PRIMARY KEY (`id`)
+);
+
+CREATE TABLE `address_book_member` (
+  `address_book_id` INT NOT NULL,
+  `subject` VARCHAR(255) NOT NULL,
+  `role` ENUM('viewer', 'editor', 'owner') NOT NULL,
+  PRIMARY KEY (`address_book_id`, `subject`),
+  INDEX `idx_address_book_member_subject` (`subject`),
+  CONSTRAINT `fk_address_book_member_book` FOREIGN KEY (`address_book_id`)
+    REFERENCES `address_book`(`id`)
+    ON DELETE CASCADE
+);
+
+ALTER TABLE `contact`
+  ADD COLUMN `address_book_id` INT NULL AFTER `owner`,
+  ADD CONSTRAINT `fk_contact_address_book` FOREIGN KEY (`address_book_id`)
+    REFERENCES `address_book`(`id`)
+    ON DELETE CASCADE;
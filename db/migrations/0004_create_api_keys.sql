@@ -0,0 +1,16 @@
+-- API keys authenticate machine clients on behalf of the subject that created them.
+-- Only the SHA-256 of each key is stored, along with its visible prefix used to find it.
+CREATE TABLE `api_key` (
+  `id` INT NOT NULL AUTO_INCREMENT,
+  `subject` VARCHAR(255) NOT NULL,
+  `name` VARCHAR(100) NOT NULL,
+  `prefix` CHAR(8) NOT NULL,
+  `hash` CHAR(64) NOT NULL,
+  `scope` ENUM('read', 'read-write') NOT NULL,
+  `created_at` DATETIME NOT NULL,
+  `last_used_at` DATETIME NULL,
+  `revoked_at` DATETIME NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_api_key_prefix` (`prefix`),
+  INDEX `idx_api_key_subject` (`subject`)
+);
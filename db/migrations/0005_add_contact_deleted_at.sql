@@ -0,0 +1,5 @@
+-- Deleted contacts are moved to the trash instead of being removed, keeping their emails and phones
+-- until they're restored or purged once the trash retention period is over.
+ALTER TABLE `contact`
+  ADD COLUMN `deleted_at` DATETIME NULL,
+  ADD INDEX `idx_contact_deleted_at` (`deleted_at`);
@@ -0,0 +1,22 @@
+-- The audit log records every change made to the contacts, their emails and phones.
+-- It has no foreign key to the contact, so the history outlives purged contacts.
+CREATE TABLE `audit_entry` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT,
+  `actor` VARCHAR(255) NOT NULL,
+  `request_id` VARCHAR(64) NOT NULL DEFAULT '',
+  `action` ENUM('create', 'update', 'delete', 'restore') NOT NULL,
+  `entity` ENUM('contact', 'email', 'phone') NOT NULL,
+  `entity_id` INT NOT NULL,
+  `contact_id` INT NOT NULL,
+  `address_book_id` INT NULL,
+  `owner` VARCHAR(255) NOT NULL DEFAULT '',
+  `before_state` JSON NULL,
+  `after_state` JSON NULL,
+  `changes` JSON NOT NULL,
+  `created_at` DATETIME(6) NOT NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_audit_entry_contact` (`contact_id`),
+  INDEX `idx_audit_entry_owner` (`owner`),
+  INDEX `idx_audit_entry_address_book` (`address_book_id`),
+  INDEX `idx_audit_entry_created_at` (`created_at`)
+);
@@ -0,0 +1,4 @@
+-- Every change made to a contact increments its version, which is exposed as its ETag
+-- so concurrent changes can be detected with If-Match.
+ALTER TABLE `contact`
+  ADD COLUMN `version` INT NOT NULL DEFAULT 1;
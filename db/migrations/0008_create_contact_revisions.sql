@@ -0,0 +1,13 @@
+-- Each change made to a contact stores its full state, including its emails and phones,
+-- as the revision numbered after the contact version, so it can be looked up and reverted.
+CREATE TABLE `contact_revision` (
+  `contact_id` INT NOT NULL,
+  `number` INT NOT NULL,
+  `actor` VARCHAR(255) NOT NULL DEFAULT '',
+  `state` JSON NOT NULL,
+  `created_at` DATETIME(6) NOT NULL,
+  PRIMARY KEY (`contact_id`, `number`),
+  CONSTRAINT `fk_contact_revision_contact` FOREIGN KEY (`contact_id`)
+    REFERENCES `contact`(`id`)
+    ON DELETE CASCADE
+);
@@ -0,0 +1,17 @@
+-- Each merge of contacts stores the state of its target before the merge, along with the
+-- emails and phones it moved from the sources, so it can be undone.
+CREATE TABLE `contact_merge` (
+  `id` INT NOT NULL AUTO_INCREMENT,
+  `target_id` INT NOT NULL,
+  `source_ids` JSON NOT NULL,
+  `target_state` JSON NOT NULL,
+  `moved` JSON NOT NULL,
+  `actor` VARCHAR(255) NOT NULL DEFAULT '',
+  `created_at` DATETIME(6) NOT NULL,
+  `undone_at` DATETIME(6) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_contact_merge_target` (`target_id`),
+  CONSTRAINT `fk_contact_merge_contact` FOREIGN KEY (`target_id`)
+    REFERENCES `contact`(`id`)
+    ON DELETE CASCADE
+);
@@ -0,0 +1,7 @@
+-- The normalized form of each email address, lowercased with its domain in punycode, is what
+-- addresses are compared by. The existing addresses are backfilled lowercased and trimmed.
+ALTER TABLE `email`
+  ADD COLUMN `normalized` VARCHAR(320) NOT NULL DEFAULT '',
+  ADD INDEX `idx_email_normalized` (`normalized`);
+
+UPDATE `email` SET `normalized` = LOWER(TRIM(`address`));
@@ -0,0 +1,5 @@
+-- The canonical E.164 form of each phone number is stored alongside the number as it was provided.
+-- It's left empty for the existing numbers, which were never parsed.
+ALTER TABLE `phone`
+  ADD COLUMN `e164` VARCHAR(16) NOT NULL DEFAULT '',
+  ADD INDEX `idx_phone_e164` (`e164`);
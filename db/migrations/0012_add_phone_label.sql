@@ -0,0 +1,4 @@
+-- Phones may have a label given by the user, like "office reception", stored apart from their type
+-- so the type stays one of the known ones.
+ALTER TABLE `phone`
+  ADD COLUMN `label` VARCHAR(50) NOT NULL DEFAULT '';
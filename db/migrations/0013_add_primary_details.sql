@@ -0,0 +1,16 @@
+-- Each contact has at most one primary email and one primary phone, the ones mailers and dialers use.
+-- The invariant is kept by the repositories, within the transactions of the service. The first email
+-- and the first phone of the existing contacts are backfilled as their primary ones.
+ALTER TABLE `email`
+  ADD COLUMN `is_primary` BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE `phone`
+  ADD COLUMN `is_primary` BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE `email` e
+  JOIN (SELECT MIN(`id`) AS `id` FROM `email` GROUP BY `contact_id`) f ON e.`id` = f.`id`
+  SET e.`is_primary` = TRUE;
+
+UPDATE `phone` p
+  JOIN (SELECT MIN(`id`) AS `id` FROM `phone` GROUP BY `contact_id`) f ON p.`id` = f.`id`
+  SET p.`is_primary` = TRUE;
@@ -0,0 +1,5 @@
+-- Emails have a label, one of home, work and other or a custom one given by the user.
+-- The existing emails are labeled as other.
+ALTER TABLE `email`
+  ADD COLUMN `label` VARCHAR(50) NOT NULL DEFAULT 'other',
+  ADD INDEX `idx_email_label` (`label`);
@@ -0,0 +1,16 @@
+-- The postal addresses of the contacts, in structured fields. The country is an ISO 3166-1 alpha-2 code,
+-- empty when it's unknown, and the label one of home, work and other or a custom one given by the user.
+CREATE TABLE `address` (
+  `id` INT NOT NULL AUTO_INCREMENT,
+  `contact_id` INT NOT NULL,
+  `street` VARCHAR(255) NOT NULL DEFAULT '',
+  `locality` VARCHAR(100) NOT NULL DEFAULT '',
+  `region` VARCHAR(100) NOT NULL DEFAULT '',
+  `postal_code` VARCHAR(20) NOT NULL DEFAULT '',
+  `country` CHAR(2) NOT NULL DEFAULT '',
+  `label` VARCHAR(50) NOT NULL DEFAULT 'other',
+  PRIMARY KEY(`id`),
+  CONSTRAINT `fk_address_contact` FOREIGN KEY (`contact_id`)
+    REFERENCES `contact`(`id`)
+    ON DELETE CASCADE
+);
@@ -0,0 +1,7 @@
+-- Contacts may have the organization they work for, their department and their job title, empty when unknown.
+-- The organization is indexed since the contacts can be filtered, sorted and grouped by it.
+ALTER TABLE `contact`
+  ADD COLUMN `organization` VARCHAR(100) NOT NULL DEFAULT '',
+  ADD COLUMN `department` VARCHAR(100) NOT NULL DEFAULT '',
+  ADD COLUMN `title` VARCHAR(100) NOT NULL DEFAULT '',
+  ADD INDEX `idx_contact_organization` (`organization`);
@@ -0,0 +1,3 @@
+-- The changes made to the postal addresses of the contacts are recorded in the audit log too.
+ALTER TABLE `audit_entry`
+  MODIFY COLUMN `entity` ENUM('contact', 'email', 'phone', 'address') NOT NULL;
@@ -0,0 +1,4 @@
+-- Each merge stores the version of its target right after the merge, so it's only undone while the target
+-- wasn't changed since. The merges recorded before don't have it, so they can no longer be undone.
+ALTER TABLE `contact_merge`
+  ADD COLUMN `target_version` INT NOT NULL DEFAULT 0;
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// An Executor runs statements, either directly on the database or inside a transaction.
+// Both *sql.DB and *sql.Tx are Executors
+type Executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type txKey struct{}
+
+// WithTx returns a copy of ctx carrying the transaction, which will be used by every repository
+// receiving the context
+func WithTx(ctx context.Context, tx *sql.Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// Conn returns the transaction carried by ctx, if any, or the database otherwise.
+// Repositories use it so their statements take part in the transaction of the caller
+func Conn(ctx context.Context, db *sql.DB) Executor {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+
+	return db
+}
+
+// A Transactor runs functions inside a database transaction
+type Transactor interface {
+	// InTx runs fn with a context carrying a transaction, which is committed if fn succeeds and
+	// rolled back otherwise. When ctx already carries a transaction, fn joins it
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// A TxManager is the Transactor of a *sql.DB
+type TxManager struct {
+	DB *sql.DB
+}
+
+// ProvideTxManager creates a new TxManager of the database.
+// Created especially for the use of Wire, which will inject the dependencies via DI
+func ProvideTxManager(db *sql.DB) *TxManager {
+	return &TxManager{db}
+}
+
+// InTx implements Transactor
+func (m *TxManager) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("InTx: error while beginning the transaction: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(WithTx(ctx, tx)); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("InTx: error while committing the transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestInTx(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE contact").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE email").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	m := ProvideTxManager(db)
+
+	err = m.InTx(context.Background(), func(ctx context.Context) error {
+		if Conn(ctx, db) == Executor(db) {
+			t.Errorf("Conn() inside InTx returned the database, want the transaction")
+		}
+
+		if _, err := Conn(ctx, db).ExecContext(ctx, "UPDATE contact SET version = 2"); err != nil {
+			return err
+		}
+
+		// a nested call joins the transaction instead of beginning a new one
+		return m.InTx(ctx, func(ctx context.Context) error {
+			_, err := Conn(ctx, db).ExecContext(ctx, "UPDATE email SET address = ''")
+			return err
+		})
+	})
+
+	if err != nil {
+		t.Errorf("InTx() returned an error %v, want nil", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled mock expectations: %v", err)
+	}
+}
+
+func TestInTxRollback(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	failure := errors.New("version mismatch")
+
+	err = ProvideTxManager(db).InTx(context.Background(), func(ctx context.Context) error {
+		return failure
+	})
+
+	if !errors.Is(err, failure) {
+		t.Errorf("InTx() returned error %v, want %v", err, failure)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled mock expectations: %v", err)
+	}
+}
+
+func TestConnWithoutTx(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error while opening a stub database connection: %v", err)
+	}
+
+	defer db.Close()
+
+	if Conn(context.Background(), db) != Executor(db) {
+		t.Errorf("Conn() without a transaction didn't return the database")
+	}
+}
@@ -0,0 +1,158 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// HTTPSinkOptions defines how an HTTPSink ships the log entries
+type HTTPSinkOptions struct {
+	// URL is the intake endpoint that will receive the batches
+	URL string
+	// APIKey is sent in the DD-API-KEY header, when present
+	APIKey string
+	// BatchSize is the number of entries that triggers a flush
+	BatchSize int
+	// FlushInterval is the maximum time an entry waits in the buffer before being shipped
+	FlushInterval time.Duration
+	// Client is the HTTP client used to ship the batches. Defaults to a client with a 10 seconds timeout
+	Client *http.Client
+}
+
+// An HTTPSink is a zapcore.WriteSyncer that buffers JSON encoded entries and ships them as a JSON array,
+// following the Datadog HTTP logs intake format
+type HTTPSink struct {
+	opts    HTTPSinkOptions
+	mu      sync.Mutex
+	batch   [][]byte
+	flushCh chan struct{}
+	done    chan struct{}
+}
+
+// NewHTTPSink creates a new HTTPSink and starts its background flushing loop
+func NewHTTPSink(opts HTTPSinkOptions) *HTTPSink {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = 5 * time.Second
+	}
+
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	s := &HTTPSink{
+		opts:    opts,
+		flushCh: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+
+	go s.loop()
+	return s
+}
+
+// Core returns a zapcore.Core that encodes entries using the attribute names expected by Datadog
+// and writes them to this sink
+func (s *HTTPSink) Core(level zapcore.LevelEnabler) zapcore.Core {
+	cfg := zap.NewProductionEncoderConfig()
+	cfg.MessageKey = "message"
+	cfg.LevelKey = "status"
+	cfg.TimeKey = "timestamp"
+	cfg.EncodeTime = zapcore.ISO8601TimeEncoder
+
+	return zapcore.NewCore(zapcore.NewJSONEncoder(cfg), s, level)
+}
+
+// Write buffers a single encoded entry. It never blocks on the network: when the batch is full,
+// the background loop is signaled to ship it
+func (s *HTTPSink) Write(p []byte) (int, error) {
+	entry := make([]byte, len(bytes.TrimRight(p, "\n")))
+	copy(entry, p)
+
+	s.mu.Lock()
+	s.batch = append(s.batch, entry)
+	full := len(s.batch) >= s.opts.BatchSize
+	s.mu.Unlock()
+
+	if full {
+		select {
+		case s.flushCh <- struct{}{}:
+		default:
+		}
+	}
+
+	return len(p), nil
+}
+
+// Sync ships all the buffered entries synchronously
+func (s *HTTPSink) Sync() error {
+	return s.flush()
+}
+
+// Close stops the background loop and ships the remaining entries
+func (s *HTTPSink) Close() error {
+	close(s.done)
+	return s.flush()
+}
+
+func (s *HTTPSink) loop() {
+	ticker := time.NewTicker(s.opts.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.flushCh:
+		case <-s.done:
+			return
+		}
+
+		// there is nowhere left to log a failure of the logger itself, so the batch is dropped
+		_ = s.flush()
+	}
+}
+
+func (s *HTTPSink) flush() error {
+	s.mu.Lock()
+	batch := s.batch
+	s.batch = nil
+	s.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	body := append([]byte("["), bytes.Join(batch, []byte(","))...)
+	body = append(body, ']')
+
+	req, err := http.NewRequest(http.MethodPost, s.opts.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("HTTPSink: error while building request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if s.opts.APIKey != "" {
+		req.Header.Set("DD-API-KEY", s.opts.APIKey)
+	}
+
+	res, err := s.opts.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("HTTPSink: error while shipping %d entries: %w", len(batch), err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusBadRequest {
+		return errors.New("HTTPSink: intake responded with status " + res.Status)
+	}
+
+	return nil
+}
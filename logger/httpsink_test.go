@@ -0,0 +1,89 @@
+package logger
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestHTTPSinkShipsBatches(t *testing.T) {
+	batches := make(chan []map[string]interface{}, 10)
+	apiKeys := make(chan string, 10)
+
+	intake := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+
+		var batch []map[string]interface{}
+		if err := json.Unmarshal(b, &batch); err != nil {
+			t.Errorf("HTTPSink sent an invalid JSON array: %v\n%s", err, b)
+		}
+
+		apiKeys <- r.Header.Get("DD-API-KEY")
+		batches <- batch
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer intake.Close()
+
+	sink := NewHTTPSink(HTTPSinkOptions{URL: intake.URL, APIKey: "dd-key", BatchSize: 2, FlushInterval: time.Hour})
+	defer sink.Close()
+
+	logger := zap.New(sink.Core(zap.NewAtomicLevelAt(zap.InfoLevel)))
+	logger.Debug("below the level")
+	logger.Info("first")
+	logger.Warn("second")
+
+	select {
+	case batch := <-batches:
+		if expected, got := 2, len(batch); expected != got {
+			t.Fatalf("HTTPSink shipped %d entries, want %d", got, expected)
+		}
+
+		for i, msg := range []string{"first", "second"} {
+			if got := batch[i]["message"]; got != msg {
+				t.Errorf("HTTPSink batch[%d].message == %v, want %q", i, got, msg)
+			}
+		}
+
+		if expected, got := "warn", batch[1]["status"]; expected != got {
+			t.Errorf("HTTPSink batch[1].status == %v, want %q", got, expected)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("HTTPSink didn't ship a full batch")
+	}
+
+	if expected, got := "dd-key", <-apiKeys; expected != got {
+		t.Errorf("HTTPSink DD-API-KEY header == %q, want %q", got, expected)
+	}
+
+	logger.Info("third")
+	if err := logger.Sync(); err != nil {
+		t.Fatalf("Sync() returned an error: %v", err)
+	}
+
+	if expected, got := 1, len(<-batches); expected != got {
+		t.Errorf("Sync() shipped %d entries, want %d", got, expected)
+	}
+}
+
+func TestHTTPSinkSyncReportsIntakeErrors(t *testing.T) {
+	intake := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer intake.Close()
+
+	sink := NewHTTPSink(HTTPSinkOptions{URL: intake.URL, FlushInterval: time.Hour})
+	defer sink.Close()
+
+	if _, err := sink.Write([]byte(`{"message":"hello"}` + "\n")); err != nil {
+		t.Fatalf("Write() returned an error: %v", err)
+	}
+
+	if err := sink.Sync(); err == nil {
+		t.Error("Sync() error == nil, want non-nil")
+	}
+}
@@ -2,23 +2,100 @@ package logger
 
 import (
 	"fmt"
+	"os"
 
+	"github.com/LucasFrezarini/go-contacts/config"
 	"github.com/google/wire"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
-type Logger struct {
-	mode string
+// Available logger modes
+const (
+	ModeProduction  = "production"
+	ModeDevelopment = "development"
+)
+
+// ProvideLevel provides the level shared by all the application loggers. Since it is an atomic level,
+// it can be changed at runtime, e.g. through the admin endpoints
+func ProvideLevel(cfg *config.Config) (zap.AtomicLevel, error) {
+	level := zap.NewAtomicLevel()
+
+	if err := level.UnmarshalText([]byte(cfg.Log.Level)); err != nil {
+		return level, fmt.Errorf("ProvideLevel: invalid log level: %w", err)
+	}
+
+	return level, nil
 }
 
-func ProvideLogger() (*zap.Logger, error) {
-	logger, err := zap.NewProduction()
+// ProvideLogger builds the application logger based on the configuration: its mode, encoding and outputs.
+// When an HTTP sink URL is configured, every entry is also shipped to it in batches. The returned cleanup
+// flushes the buffered entries, and must be called before the application exits
+func ProvideLogger(cfg *config.Config, level zap.AtomicLevel) (*zap.Logger, func(), error) {
+	c := cfg.Log
 
+	var zc zap.Config
+	switch c.Mode {
+	case ModeProduction:
+		zc = zap.NewProductionConfig()
+	case ModeDevelopment:
+		zc = zap.NewDevelopmentConfig()
+	default:
+		return nil, nil, fmt.Errorf("ProvideLogger: unknown log mode %q", c.Mode)
+	}
+
+	zc.Level = level
+
+	if c.Encoding != "" {
+		zc.Encoding = c.Encoding
+	}
+
+	if len(c.Outputs) != 0 {
+		zc.OutputPaths = c.Outputs
+	}
+
+	var opts []zap.Option
+	var sink *HTTPSink
+
+	if c.HTTPSink.URL != "" {
+		sink = NewHTTPSink(HTTPSinkOptions{
+			URL:           c.HTTPSink.URL,
+			APIKey:        c.HTTPSink.APIKey,
+			BatchSize:     c.HTTPSink.BatchSize,
+			FlushInterval: c.HTTPSink.FlushInterval,
+		})
+
+		hostname, _ := os.Hostname()
+		sinkCore := sink.Core(level).With([]zap.Field{
+			zap.String("ddsource", "go"),
+			zap.String("service", c.HTTPSink.Service),
+			zap.String("hostname", hostname),
+		})
+
+		opts = append(opts, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return zapcore.NewTee(core, sinkCore)
+		}))
+	}
+
+	logger, err := zc.Build(opts...)
 	if err != nil {
-		return nil, fmt.Errorf("NewLogger: error while starting zap logger: %w", err)
+		if sink != nil {
+			sink.Close()
+		}
+
+		return nil, nil, fmt.Errorf("ProvideLogger: error while starting zap logger: %w", err)
+	}
+
+	cleanup := func() {
+		logger.Sync()
+
+		if sink != nil {
+			sink.Close()
+		}
 	}
 
-	return logger, nil
+	return logger, cleanup, nil
 }
 
-var LoggerSet = wire.NewSet(ProvideLogger)
+// LoggerSet is the wire.ProviderSet of the logger package
+var LoggerSet = wire.NewSet(ProvideLevel, ProvideLogger)
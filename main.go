@@ -1,21 +1,51 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/LucasFrezarini/go-contacts/container"
 	_ "github.com/joho/godotenv/autoload"
 )
 
+// shutdownTimeout is how long the requests in progress are waited for when the application is stopped
+const shutdownTimeout = 10 * time.Second
+
 func main() {
-	app, err := container.InitializeServer()
+	app, cleanup, err := container.InitializeServer(os.Args[1:])
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	defer app.Logger.Sync()
+	stopped := make(chan error, 1)
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
 
-	log.Fatal(app.Start())
+		stopped <- app.Shutdown(ctx)
+	}()
+
+	err = app.Start()
+	if errors.Is(err, http.ErrServerClosed) {
+		err = <-stopped
+	}
 
 	app.Logger.Info("Closing application...")
+
+	// the buffered log entries are flushed before exiting, as log.Fatal skips the deferred calls
+	cleanup()
+
+	if err != nil {
+		log.Fatal(err)
+	}
 }
@@ -0,0 +1,50 @@
+package ratelimit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Limit allows a number of requests per period. It's enforced by a token bucket holding up to
+// Requests tokens and refilled continuously, so clients can burst up to the whole limit at once
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// ParseLimit parses a limit written as "requests/period", e.g. "100/1m". The period may omit
+// its amount, so "10/s" is the same as "10/1s"
+func ParseLimit(s string) (Limit, error) {
+	parts := strings.SplitN(strings.TrimSpace(s), "/", 2)
+	if len(parts) != 2 {
+		return Limit{}, fmt.Errorf("invalid limit %q, want requests/period", s)
+	}
+
+	requests, err := strconv.Atoi(parts[0])
+	if err != nil || requests < 1 {
+		return Limit{}, fmt.Errorf("invalid limit %q: requests must be a positive integer", s)
+	}
+
+	period := parts[1]
+	if period != "" && (period[0] < '0' || period[0] > '9') {
+		period = "1" + period
+	}
+
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("invalid limit %q: period must be a positive duration", s)
+	}
+
+	return Limit{Requests: requests, Period: d}, nil
+}
+
+// rate returns how many tokens are refilled per second
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+func (l Limit) String() string {
+	return fmt.Sprintf("%d/%s", l.Requests, l.Period)
+}
@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/LucasFrezarini/go-contacts/config"
+	"github.com/google/wire"
+)
+
+// A Limiter applies the configured limits to the requests of each client
+type Limiter struct {
+	Enabled bool
+	store   Store
+	def     Limit
+	routes  map[string]Limit
+	perIP   *Limit
+}
+
+// ProvideLimiter creates a Limiter with the limits from the configuration, keeping the buckets in the
+// provided store. Designed especially for the use of wire, to provide the dependencies via DI
+func ProvideLimiter(cfg *config.Config, store Store) (*Limiter, error) {
+	def, err := ParseLimit(cfg.RateLimit.Default)
+	if err != nil {
+		return nil, fmt.Errorf("ProvideLimiter: rate_limit.default: %w", err)
+	}
+
+	routes := make(map[string]Limit, len(cfg.RateLimit.Routes))
+	for route, raw := range cfg.RateLimit.Routes {
+		l, err := ParseLimit(raw)
+		if err != nil {
+			return nil, fmt.Errorf("ProvideLimiter: rate_limit.routes[%q]: %w", route, err)
+		}
+
+		routes[route] = l
+	}
+
+	limiter := &Limiter{Enabled: cfg.RateLimit.Enabled, store: store, def: def, routes: routes}
+
+	if cfg.RateLimit.PerIP != "" {
+		l, err := ParseLimit(cfg.RateLimit.PerIP)
+		if err != nil {
+			return nil, fmt.Errorf("ProvideLimiter: rate_limit.per_ip: %w", err)
+		}
+
+		limiter.perIP = &l
+	}
+
+	return limiter, nil
+}
+
+// Allow takes a token from the client's bucket for the route, in the "METHOD /path" format.
+// Routes with their own limit have their own buckets, while all the others share the client's default one
+func (l *Limiter) Allow(ctx context.Context, client string, route string) (Result, error) {
+	if limit, ok := l.routes[route]; ok {
+		return l.store.Take(ctx, route+" "+client, limit)
+	}
+
+	return l.store.Take(ctx, client, l.def)
+}
+
+// AllowIP takes a token from the bucket of the IP address, which is checked for every request before authentication.
+// It always allows the request when there is no per IP limit
+func (l *Limiter) AllowIP(ctx context.Context, ip string) (Result, error) {
+	if l.perIP == nil {
+		return Result{Allowed: true}, nil
+	}
+
+	return l.store.Take(ctx, "unauthenticated ip:"+ip, *l.perIP)
+}
+
+// Set is a wire set that contains all the providers of this package, with the in-memory store
+var Set = wire.NewSet(
+	ProvideLimiter,
+	ProvideMemoryStore,
+	wire.Bind(new(Store), new(*MemoryStore)),
+)
@@ -0,0 +1,164 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/LucasFrezarini/go-contacts/config"
+)
+
+func TestParseLimit(t *testing.T) {
+	var testCases = []struct {
+		raw   string
+		limit Limit
+		valid bool
+	}{
+		{"100/1m", Limit{100, time.Minute}, true},
+		{"10/s", Limit{10, time.Second}, true},
+		{" 5/30s ", Limit{5, 30 * time.Second}, true},
+		{"10", Limit{}, false},
+		{"0/s", Limit{}, false},
+		{"ten/s", Limit{}, false},
+		{"10/week", Limit{}, false},
+		{"10/-1s", Limit{}, false},
+	}
+
+	for _, tc := range testCases {
+		limit, err := ParseLimit(tc.raw)
+
+		if tc.valid && (err != nil || limit != tc.limit) {
+			t.Errorf("ParseLimit(%q) = (%v, %v), want (%v, nil)", tc.raw, limit, err, tc.limit)
+		}
+
+		if !tc.valid && err == nil {
+			t.Errorf("ParseLimit(%q) returned a nil error, want an invalid limit error", tc.raw)
+		}
+	}
+}
+
+func TestMemoryStoreTake(t *testing.T) {
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	store := ProvideMemoryStore()
+	store.now = func() time.Time { return now }
+
+	limit := Limit{Requests: 2, Period: 10 * time.Second}
+	ctx := context.Background()
+
+	for i, remaining := range []int{1, 0} {
+		result, _ := store.Take(ctx, "ip:1.2.3.4", limit)
+		if !result.Allowed || result.Remaining != remaining {
+			t.Fatalf("Take() #%d = %+v, want allowed with %d remaining", i+1, result, remaining)
+		}
+	}
+
+	result, _ := store.Take(ctx, "ip:1.2.3.4", limit)
+	if result.Allowed {
+		t.Fatalf("Take() over the limit = %+v, want not allowed", result)
+	}
+
+	if expected := 5 * time.Second; result.RetryAfter != expected {
+		t.Errorf("Take() over the limit RetryAfter == %v, want %v", result.RetryAfter, expected)
+	}
+
+	if result, _ := store.Take(ctx, "ip:5.6.7.8", limit); !result.Allowed {
+		t.Errorf("Take() of another client = %+v, want allowed", result)
+	}
+
+	// a token is refilled every 5 seconds
+	now = now.Add(5 * time.Second)
+	if result, _ := store.Take(ctx, "ip:1.2.3.4", limit); !result.Allowed || result.Remaining != 0 {
+		t.Errorf("Take() after a refill = %+v, want allowed with 0 remaining", result)
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	store := ProvideMemoryStore()
+	store.now = func() time.Time { return now }
+	store.lastSweep = now
+
+	store.Take(context.Background(), "ip:1.2.3.4", Limit{Requests: 2, Period: time.Second})
+
+	now = now.Add(sweepInterval)
+	store.Take(context.Background(), "ip:5.6.7.8", Limit{Requests: 2, Period: time.Second})
+
+	if _, ok := store.buckets["ip:1.2.3.4"]; ok {
+		t.Error("sweep() kept a bucket that is full again")
+	}
+}
+
+func TestMemoryStoreMaxBuckets(t *testing.T) {
+	store := ProvideMemoryStore()
+	store.max = 3
+
+	for i := 0; i < 10; i++ {
+		store.Take(context.Background(), fmt.Sprintf("ip:10.0.0.%d", i), Limit{Requests: 2, Period: time.Minute})
+	}
+
+	if got := len(store.buckets); got != store.max {
+		t.Errorf("Take() of 10 clients kept %d buckets, want at most %d", got, store.max)
+	}
+
+	if _, ok := store.buckets["ip:10.0.0.9"]; !ok {
+		t.Error("Take() of a new client over the maximum didn't keep its bucket")
+	}
+}
+
+func TestLimiterRoutes(t *testing.T) {
+	cfg := &config.Config{RateLimit: config.RateLimit{
+		Enabled: true,
+		Default: "100/1m",
+		Routes:  map[string]string{"POST /contacts/": "1/1m"},
+	}}
+
+	limiter, err := ProvideLimiter(cfg, ProvideMemoryStore())
+	if err != nil {
+		t.Fatalf("ProvideLimiter() returned an error %v, want nil", err)
+	}
+
+	ctx := context.Background()
+	limiter.Allow(ctx, "user:tanjiro", "POST /contacts/")
+
+	if result, _ := limiter.Allow(ctx, "user:tanjiro", "POST /contacts/"); result.Allowed {
+		t.Error("Allow() over the route limit returned an allowed result")
+	}
+
+	// the route bucket doesn't consume the default one
+	if result, _ := limiter.Allow(ctx, "user:tanjiro", "GET /contacts/"); !result.Allowed || result.Remaining != 99 {
+		t.Errorf("Allow() on a route without limit = %+v, want allowed with 99 remaining", result)
+	}
+
+	if result, _ := limiter.AllowIP(ctx, "1.2.3.4"); !result.Allowed {
+		t.Error("AllowIP() without a per IP limit returned a limited result")
+	}
+
+	cfg.RateLimit.PerIP = "1/1m"
+	if limiter, err = ProvideLimiter(cfg, ProvideMemoryStore()); err != nil {
+		t.Fatalf("ProvideLimiter() returned an error %v, want nil", err)
+	}
+
+	limiter.AllowIP(ctx, "1.2.3.4")
+	if result, _ := limiter.AllowIP(ctx, "1.2.3.4"); result.Allowed {
+		t.Error("AllowIP() over the per IP limit returned an allowed result")
+	}
+
+	// the per IP bucket doesn't consume the default one of the client
+	if result, _ := limiter.Allow(ctx, "ip:1.2.3.4", "GET /contacts/"); !result.Allowed {
+		t.Error("Allow() after the per IP limit was reached returned a limited result")
+	}
+
+	cfg.RateLimit.PerIP = "often"
+	if _, err := ProvideLimiter(cfg, ProvideMemoryStore()); err == nil {
+		t.Error("ProvideLimiter() with an invalid per IP limit returned a nil error")
+	}
+
+	cfg.RateLimit.PerIP = ""
+	cfg.RateLimit.Routes["GET /contacts/"] = "fast"
+	if _, err := ProvideLimiter(cfg, ProvideMemoryStore()); err == nil {
+		t.Error("ProvideLimiter() with an invalid route limit returned a nil error")
+	}
+}
@@ -0,0 +1,123 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// A Result describes the state of a client's bucket after it tried to make a request
+type Result struct {
+	// Allowed tells if the request can proceed
+	Allowed bool
+	// Limit is the capacity of the bucket
+	Limit int
+	// Remaining is how many requests can still be made right away
+	Remaining int
+	// Reset is how long until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is how long until the next request is allowed, when this one wasn't
+	RetryAfter time.Duration
+}
+
+// A Store keeps the token buckets of the clients. The in-memory store only limits the requests
+// reaching a single instance; a shared store is needed to enforce the limits across instances
+type Store interface {
+	// Take takes a token from the bucket identified by key, created full with the provided limit
+	// if it doesn't exist yet
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// sweepInterval is how often the memory store forgets the buckets that are full again
+const sweepInterval = time.Minute
+
+// maxBuckets bounds the memory used by the memory store, however many clients there are
+const maxBuckets = 100000
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	full   time.Time
+}
+
+// A MemoryStore keeps the buckets in the memory of the current process
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	max       int
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// ProvideMemoryStore creates an empty MemoryStore.
+// Designed especially for the use of wire, to provide the dependencies via DI
+func ProvideMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket), max: maxBuckets, lastSweep: time.Now(), now: time.Now}
+}
+
+// Take implements Store
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	capacity, rate := float64(limit.Requests), limit.rate()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		if len(s.buckets) >= s.max {
+			s.evict()
+		}
+
+		b = &bucket{tokens: capacity, last: now}
+		s.buckets[key] = b
+	}
+
+	b.tokens = math.Min(capacity, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+
+	result := Result{Limit: limit.Requests}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - b.tokens) / rate)
+	}
+
+	result.Remaining = int(b.tokens)
+	result.Reset = seconds((capacity - b.tokens) / rate)
+	b.full = now.Add(result.Reset)
+
+	return result, nil
+}
+
+// sweep forgets the buckets that are full again, as they're the same as new ones
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+
+	s.lastSweep = now
+}
+
+// evict forgets a random bucket to make room for a new one. At worst, its client gets a full bucket again
+func (s *MemoryStore) evict() {
+	for key := range s.buckets {
+		delete(s.buckets, key)
+		return
+	}
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
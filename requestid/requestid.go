@@ -0,0 +1,42 @@
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+)
+
+// Header is the HTTP header used to receive and propagate the request ID
+const Header = "X-Request-ID"
+
+// pattern is the format of the request IDs accepted from the clients, which end up in the logs and the audit log
+var pattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+type contextKey struct{}
+
+// New generates a new random request ID
+func New() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// Valid reports whether id can be used as a request ID: up to 64 letters, digits, dots, underscores and hyphens
+func Valid(id string) bool {
+	return pattern.MatchString(id)
+}
+
+// WithID returns a copy of ctx carrying the provided request ID
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID stored in ctx, or an empty string if there is none
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
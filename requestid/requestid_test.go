@@ -0,0 +1,44 @@
+package requestid
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestValid(t *testing.T) {
+	var testCases = []struct {
+		testName string
+		id       string
+		valid    bool
+	}{
+		{"generated", New(), true},
+		{"uuid", "0b9e3a4c-5f1d-4c2e-9a8b-7d6e5f4a3b2c", true},
+		{"dots_and_underscores", "web_1.request-42", true},
+		{"max_length", strings.Repeat("a", 64), true},
+		{"empty", "", false},
+		{"too_long", strings.Repeat("a", 65), false},
+		{"spaces", "request 1", false},
+		{"newline", "request-1\nlevel=error", false},
+		{"quotes", `request-"1"`, false},
+		{"non_ascii", "requête-1", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			if got := Valid(tc.id); got != tc.valid {
+				t.Errorf("Valid(%q) == %v, want %v", tc.id, got, tc.valid)
+			}
+		})
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if got := FromContext(context.Background()); got != "" {
+		t.Errorf("FromContext() without an ID == %q, want an empty string", got)
+	}
+
+	if expected, got := "request-1", FromContext(WithID(context.Background(), "request-1")); expected != got {
+		t.Errorf("FromContext() == %q, want %q", got, expected)
+	}
+}
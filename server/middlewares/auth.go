@@ -0,0 +1,112 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/LucasFrezarini/go-contacts/auth"
+	"github.com/LucasFrezarini/go-contacts/server/problem"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// PrincipalKey is the echo.Context key under which the authenticated principal is stored
+const PrincipalKey = "principal"
+
+// Authenticate requires a valid bearer token or API key on every route that isn't configured as public.
+// The principal identified by the credentials is stored in the request context, so it can be retrieved
+// with auth.FromContext, and in the echo context under PrincipalKey. When authentication is disabled,
+// every request is made by the same anonymous principal, who is an admin as nothing is protected anyway.
+// Read-only API keys can't change any data
+func (ct *Container) Authenticate(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if !ct.auth.Enabled {
+			return next(withPrincipal(c, &auth.Principal{Subject: auth.AnonymousSubject, Method: auth.MethodNone, Admin: true}))
+		}
+
+		if ct.isPublic(c.Request().URL.Path) {
+			return next(c)
+		}
+
+		header := c.Request().Header.Get(echo.HeaderAuthorization)
+		scheme, credentials := splitAuthorization(header)
+
+		var principal *auth.Principal
+		var err error
+
+		switch {
+		case strings.EqualFold(scheme, "Bearer") && credentials != "":
+			principal, err = ct.authenticator.Authenticate(credentials)
+			if err != nil {
+				ct.logger.Debug("rejected bearer token", zap.Error(err))
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer realm="go-contacts", error="invalid_token"`)
+				return problem.Write(c, http.StatusUnauthorized, "invalid bearer token")
+			}
+		case strings.EqualFold(scheme, "ApiKey") && credentials != "":
+			principal, err = ct.keys.AuthenticateKey(c.Request().Context(), credentials)
+			if errors.Is(err, auth.ErrInvalidAPIKey) {
+				ct.logger.Debug("rejected API key", zap.Error(err))
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, `ApiKey realm="go-contacts"`)
+				return problem.Write(c, http.StatusUnauthorized, "invalid API key")
+			}
+
+			if err != nil {
+				ct.logger.Error("error while authenticating API key", zap.Error(err))
+				return problem.Write(c, http.StatusInternalServerError, "error while authenticating API key")
+			}
+		default:
+			c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer realm="go-contacts"`)
+			c.Response().Header().Add(echo.HeaderWWWAuthenticate, `ApiKey realm="go-contacts"`)
+			return problem.Write(c, http.StatusUnauthorized, "missing bearer token or API key")
+		}
+
+		if !principal.CanWrite() && !isSafeMethod(c.Request().Method) {
+			return problem.Write(c, http.StatusForbidden, "the API key is read-only")
+		}
+
+		return next(withPrincipal(c, principal))
+	}
+}
+
+// isSafeMethod checks if the HTTP method only reads data
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+func withPrincipal(c echo.Context, principal *auth.Principal) echo.Context {
+	req := c.Request()
+	c.SetRequest(req.WithContext(auth.WithPrincipal(req.Context(), principal)))
+	c.Set(PrincipalKey, principal)
+
+	return c
+}
+
+// isPublic checks the path against the configured public routes. A route ending with * matches
+// any path starting with it
+func (ct *Container) isPublic(path string) bool {
+	for _, route := range ct.auth.PublicRoutes {
+		if prefix := strings.TrimSuffix(route, "*"); prefix != route {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+
+			continue
+		}
+
+		if path == route {
+			return true
+		}
+	}
+
+	return false
+}
+
+func splitAuthorization(header string) (scheme, credentials string) {
+	parts := strings.SplitN(strings.TrimSpace(header), " ", 2)
+	if len(parts) != 2 {
+		return parts[0], ""
+	}
+
+	return parts[0], strings.TrimSpace(parts[1])
+}
@@ -0,0 +1,172 @@
+package middlewares
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LucasFrezarini/go-contacts/auth"
+	"github.com/LucasFrezarini/go-contacts/config"
+	"github.com/LucasFrezarini/go-contacts/server/problem"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// fakeKeys authenticates the keys it knows, with the provided scope
+type fakeKeys map[string]string
+
+func (f fakeKeys) AuthenticateKey(ctx context.Context, key string) (*auth.Principal, error) {
+	scope, ok := f[key]
+	if !ok {
+		return nil, auth.ErrInvalidAPIKey
+	}
+
+	return &auth.Principal{Subject: "sync-job", Method: auth.MethodAPIKey, Scope: scope}, nil
+}
+
+func newAuthEcho(t *testing.T) *echo.Echo {
+	cfg := &config.Config{Auth: config.Auth{Enabled: true, HS256Secret: "testing", PublicRoutes: []string{"/health", "/public/*"}}}
+
+	authenticator, err := auth.ProvideJWTAuthenticator(cfg)
+	if err != nil {
+		t.Fatalf("ProvideJWTAuthenticator() returned an error: %v", err)
+	}
+
+	keys := fakeKeys{"gck_read": auth.ScopeRead, "gck_write": auth.ScopeReadWrite}
+	ct := ProvideMiddlewaresContainer(cfg, zap.NewNop(), authenticator, keys, nil)
+
+	e := echo.New()
+	e.Use(ct.Authenticate)
+
+	handler := func(c echo.Context) error {
+		subject := ""
+		if p, ok := auth.FromContext(c.Request().Context()); ok {
+			subject = p.Subject
+		}
+
+		return c.String(http.StatusOK, subject)
+	}
+
+	e.GET("/health", handler)
+	e.GET("/public/docs", handler)
+	e.GET("/contacts/", handler)
+	e.POST("/contacts/", handler)
+
+	return e
+}
+
+func TestAuthenticate(t *testing.T) {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.StandardClaims{
+		Subject:   "nezuko",
+		ExpiresAt: time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte("testing"))
+	if err != nil {
+		t.Fatalf("error while signing token: %v", err)
+	}
+
+	var testCases = []struct {
+		testName      string
+		path          string
+		authorization string
+		status        int
+		body          string
+	}{
+		{"public_route", "/health", "", http.StatusOK, ""},
+		{"public_prefix", "/public/docs", "", http.StatusOK, ""},
+		{"missing_token", "/contacts/", "", http.StatusUnauthorized, ""},
+		{"wrong_scheme", "/contacts/", "Basic dXNlcjpwYXNz", http.StatusUnauthorized, ""},
+		{"invalid_token", "/contacts/", "Bearer invalid", http.StatusUnauthorized, ""},
+		{"valid_token", "/contacts/", "Bearer " + token, http.StatusOK, "nezuko"},
+	}
+
+	e := newAuthEcho(t)
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.authorization != "" {
+				req.Header.Set(echo.HeaderAuthorization, tc.authorization)
+			}
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != tc.status {
+				t.Fatalf("GET %s wrote status %d, want %d", tc.path, rec.Code, tc.status)
+			}
+
+			if tc.status != http.StatusUnauthorized {
+				if got := rec.Body.String(); got != tc.body {
+					t.Errorf("GET %s handler saw subject %q, want %q", tc.path, got, tc.body)
+				}
+
+				return
+			}
+
+			if contentType := rec.Header().Get(echo.HeaderContentType); !strings.HasPrefix(contentType, problem.ContentType) {
+				t.Errorf("GET %s Content-Type == %q, want %q", tc.path, contentType, problem.ContentType)
+			}
+
+			if rec.Header().Get(echo.HeaderWWWAuthenticate) == "" {
+				t.Errorf("GET %s didn't write the WWW-Authenticate header", tc.path)
+			}
+
+			var body problem.Problem
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("error while unmarshaling problem response: %v", err)
+			}
+
+			if body.Status != http.StatusUnauthorized {
+				t.Errorf("GET %s problem status == %d, want %d", tc.path, body.Status, http.StatusUnauthorized)
+			}
+		})
+	}
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	var testCases = []struct {
+		testName      string
+		method        string
+		authorization string
+		status        int
+	}{
+		{"invalid_key", http.MethodGet, "ApiKey gck_unknown", http.StatusUnauthorized},
+		{"empty_key", http.MethodGet, "ApiKey ", http.StatusUnauthorized},
+		{"read_only_get", http.MethodGet, "ApiKey gck_read", http.StatusOK},
+		{"read_only_post", http.MethodPost, "ApiKey gck_read", http.StatusForbidden},
+		{"read_write_post", http.MethodPost, "ApiKey gck_write", http.StatusOK},
+	}
+
+	e := newAuthEcho(t)
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/contacts/", nil)
+			req.Header.Set(echo.HeaderAuthorization, tc.authorization)
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != tc.status {
+				t.Fatalf("%s /contacts/ wrote status %d, want %d", tc.method, rec.Code, tc.status)
+			}
+
+			if tc.status == http.StatusOK {
+				if got, expected := rec.Body.String(), "sync-job"; got != expected {
+					t.Errorf("%s /contacts/ handler saw subject %q, want %q", tc.method, got, expected)
+				}
+
+				return
+			}
+
+			if contentType := rec.Header().Get(echo.HeaderContentType); !strings.HasPrefix(contentType, problem.ContentType) {
+				t.Errorf("%s /contacts/ Content-Type == %q, want %q", tc.method, contentType, problem.ContentType)
+			}
+		})
+	}
+}
@@ -1,32 +1,140 @@
 package middlewares
 
 import (
-	"fmt"
-	"net/http/httputil"
+	"bytes"
+	"io"
+	"math/rand"
+	"net/http"
+	"time"
 
+	"github.com/LucasFrezarini/go-contacts/auth"
+	"github.com/LucasFrezarini/go-contacts/config"
+	"github.com/LucasFrezarini/go-contacts/ratelimit"
+	"github.com/LucasFrezarini/go-contacts/requestid"
 	"github.com/google/wire"
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
 )
 
+// A Container holds the dependencies shared by all the HTTP middlewares of the application
 type Container struct {
-	logger *zap.Logger
+	logger        *zap.Logger
+	httpLog       config.HTTPLog
+	sample        func() float64
+	auth          config.Auth
+	authenticator *auth.JWTAuthenticator
+	keys          auth.KeyAuthenticator
+	limiter       *ratelimit.Limiter
 }
 
-func ProvideMiddlewaresContainer(logger *zap.Logger) *Container {
-	return &Container{logger: logger.Named("HTTPLogger")}
+// ProvideMiddlewaresContainer creates a new Container with the provided configuration.
+// Designed especially for the use of wire, to provide the dependencies via DI
+func ProvideMiddlewaresContainer(cfg *config.Config, logger *zap.Logger, authenticator *auth.JWTAuthenticator,
+	keys auth.KeyAuthenticator, limiter *ratelimit.Limiter) *Container {
+	return &Container{
+		logger:        logger.Named("HTTPLogger"),
+		httpLog:       cfg.HTTPLog,
+		sample:        rand.Float64,
+		auth:          cfg.Auth,
+		authenticator: authenticator,
+		keys:          keys,
+		limiter:       limiter,
+	}
 }
 
+// RequestID makes sure every request has an ID, reusing the one sent by the client in the
+// X-Request-ID header when it's valid, otherwise a new one is generated. The ID is written back in the response
+// and stored in the request context, so it can be retrieved with requestid.FromContext
+func (ct *Container) RequestID(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+
+		id := req.Header.Get(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+
+		c.Response().Header().Set(requestid.Header, id)
+		c.SetRequest(req.WithContext(requestid.WithID(req.Context(), id)))
+
+		return next(c)
+	}
+}
+
+// ZapHTTPLogger writes a structured access log entry for every request. Bodies and headers are only
+// logged when enabled in the configuration, always with PII and credentials redacted. Successful requests
+// may be sampled per route; failed ones are always logged.
+// The logged body is copied while the handler reads it, instead of being read upfront, so it's bounded by the
+// body limits applied after this middleware
 func (ct *Container) ZapHTTPLogger(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		dump, err := httputil.DumpRequest(c.Request(), true)
+		req := c.Request()
+		start := time.Now()
+
+		body := new(bytes.Buffer)
+		if ct.httpLog.LogBody && req.Body != nil {
+			req.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.TeeReader(req.Body, body), req.Body}
+		}
+
+		err := next(c)
 		if err != nil {
-			ct.logger.Error(fmt.Sprintf("error while dumping request: %v", err))
+			c.Error(err)
 		}
 
-		ct.logger.Info(fmt.Sprintf("received incoming request: %s", dump))
-		return next(c)
+		res := c.Response()
+		route := c.Path()
+		if route == "" {
+			route = req.URL.Path
+		}
+
+		if res.Status < http.StatusBadRequest && !ct.sampled(req.Method+" "+route) {
+			return nil
+		}
+
+		fields := []zap.Field{
+			zap.String("method", req.Method),
+			zap.String("route", route),
+			zap.Int("status", res.Status),
+			zap.Duration("latency", time.Since(start)),
+			zap.Int64("bytes_in", req.ContentLength),
+			zap.Int64("bytes_out", res.Size),
+			zap.String("request_id", requestid.FromContext(req.Context())),
+		}
+
+		if ct.httpLog.LogHeaders {
+			fields = append(fields, zap.Any("headers", redactHeaders(req.Header)))
+		}
+
+		if ct.httpLog.LogBody && body.Len() > 0 {
+			fields = append(fields, zap.ByteString("body", redactBody(body.Bytes(), ct.httpLog.MaxBodySize)))
+		}
+
+		switch {
+		case res.Status >= http.StatusInternalServerError:
+			ct.logger.Error("request handled", fields...)
+		case res.Status >= http.StatusBadRequest:
+			ct.logger.Warn("request handled", fields...)
+		default:
+			ct.logger.Info("request handled", fields...)
+		}
+
+		return nil
 	}
 }
 
+// sampled decides if a request to the provided route should be logged, based on its sample rate.
+// Routes without a configured rate are always logged
+func (ct *Container) sampled(route string) bool {
+	rate, ok := ct.httpLog.SampleRates[route]
+	if !ok || rate >= 1 {
+		return true
+	}
+
+	return ct.sample() < rate
+}
+
+// MiddlewaresSet is the wire.ProviderSet of the middlewares package
 var MiddlewaresSet = wire.NewSet(ProvideMiddlewaresContainer)
@@ -0,0 +1,160 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/LucasFrezarini/go-contacts/config"
+	"github.com/LucasFrezarini/go-contacts/requestid"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newObservedContainer(httpLog config.HTTPLog, sample float64) (*Container, *observer.ObservedLogs) {
+	core, logs := observer.New(zapcore.DebugLevel)
+
+	return &Container{
+		logger:  zap.New(core),
+		httpLog: httpLog,
+		sample:  func() float64 { return sample },
+	}, logs
+}
+
+func TestZapHTTPLoggerRedactsPII(t *testing.T) {
+	ct, logs := newObservedContainer(config.HTTPLog{LogBody: true, LogHeaders: true, MaxBodySize: 4096}, 0)
+
+	e := echo.New()
+	e.Use(ct.RequestID, ct.ZapHTTPLogger)
+	e.POST("/contacts/", func(c echo.Context) error {
+		return c.NoContent(http.StatusCreated)
+	})
+
+	body := `{"first_name":"Zenitsu","last_name":"Agatsuma","emails":["zenitsu@gmail.com"],"phones":[{"type":"home","number":"551122223333"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/contacts/", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer secret-token")
+	req.Header.Set(requestid.Header, "request-1")
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if expected, got := 1, logs.Len(); expected != got {
+		t.Fatalf("ZapHTTPLogger wrote %d log entries, want %d", got, expected)
+	}
+
+	entry := logs.All()[0]
+	fields := entry.ContextMap()
+
+	if expected, got := "/contacts/", fields["route"]; expected != got {
+		t.Errorf("ZapHTTPLogger route == %v, want %v", got, expected)
+	}
+
+	if expected, got := int64(http.StatusCreated), fields["status"]; expected != got {
+		t.Errorf("ZapHTTPLogger status == %v, want %v", got, expected)
+	}
+
+	if expected, got := "request-1", fields["request_id"]; expected != got {
+		t.Errorf("ZapHTTPLogger request_id == %v, want %v", got, expected)
+	}
+
+	if expected, got := "request-1", rec.Header().Get(requestid.Header); expected != got {
+		t.Errorf("RequestID response header == %q, want %q", got, expected)
+	}
+
+	loggedBody, _ := fields["body"].(string)
+	for _, pii := range []string{"Zenitsu", "Agatsuma", "zenitsu@gmail.com", "551122223333"} {
+		if strings.Contains(loggedBody, pii) {
+			t.Errorf("ZapHTTPLogger logged body contains PII %q: %s", pii, loggedBody)
+		}
+	}
+
+	headers, _ := fields["headers"].(map[string]string)
+	if expected, got := redacted, headers["Authorization"]; expected != got {
+		t.Errorf("ZapHTTPLogger logged Authorization header == %q, want %q", got, expected)
+	}
+}
+
+func TestZapHTTPLoggerSampling(t *testing.T) {
+	var testCases = []struct {
+		testName string
+		status   int
+		sample   float64
+		expected int
+	}{
+		{"sampled_out", http.StatusOK, 0.5, 0},
+		{"sampled_in", http.StatusOK, 0.05, 1},
+		{"errors_always_logged", http.StatusInternalServerError, 0.5, 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			httpLog := config.HTTPLog{SampleRates: map[string]float64{"GET /contacts/": 0.1}}
+			ct, logs := newObservedContainer(httpLog, tc.sample)
+
+			e := echo.New()
+			e.Use(ct.ZapHTTPLogger)
+			e.GET("/contacts/", func(c echo.Context) error {
+				return c.NoContent(tc.status)
+			})
+
+			e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/contacts/", nil))
+
+			if got := logs.Len(); tc.expected != got {
+				t.Errorf("ZapHTTPLogger wrote %d log entries, want %d", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	var testCases = []struct {
+		testName string
+		header   string
+		reused   bool
+	}{
+		{"valid", "request-1", true},
+		{"missing", "", false},
+		{"too_long", strings.Repeat("a", 65), false},
+		{"forged_log_line", "request-1\" level=error msg=\"forged", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			ct, _ := newObservedContainer(config.HTTPLog{}, 0)
+
+			var stored string
+
+			e := echo.New()
+			e.Use(ct.RequestID)
+			e.GET("/", func(c echo.Context) error {
+				stored = requestid.FromContext(c.Request().Context())
+				return c.NoContent(http.StatusNoContent)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.header != "" {
+				req.Header.Set(requestid.Header, tc.header)
+			}
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			written := rec.Header().Get(requestid.Header)
+			if written != stored {
+				t.Errorf("RequestID wrote the ID %q, want the one in the context %q", written, stored)
+			}
+
+			if reused := written == tc.header; reused != tc.reused {
+				t.Errorf("RequestID wrote the ID %q for the header %q, want it reused: %v", written, tc.header, tc.reused)
+			}
+
+			if !requestid.Valid(written) {
+				t.Errorf("RequestID wrote the invalid ID %q", written)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package middlewares
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/LucasFrezarini/go-contacts/auth"
+	"github.com/LucasFrezarini/go-contacts/ratelimit"
+	"github.com/LucasFrezarini/go-contacts/server/problem"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// Headers describing the client's rate limit, as in the IETF RateLimit header fields draft,
+// and when a limited client can retry
+const (
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
+	HeaderRetryAfter         = "Retry-After"
+)
+
+// RateLimit limits the rate of requests of each client, identified by its API key, its user or
+// its IP address, in this order. It must run after Authenticate, so the principal is known.
+// Requests over the limit get a 429 response, and every response describes the remaining quota
+func (ct *Container) RateLimit(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if ct.limiter == nil || !ct.limiter.Enabled {
+			return next(c)
+		}
+
+		route := c.Path()
+		if route == "" {
+			route = c.Request().URL.Path
+		}
+
+		result, err := ct.limiter.Allow(c.Request().Context(), clientKey(c), c.Request().Method+" "+route)
+		if err != nil {
+			// an unavailable store must not take the whole API down with it
+			ct.logger.Error("error while checking the rate limit", zap.Error(err))
+			return next(c)
+		}
+
+		setRateLimitHeaders(c, result)
+		if !result.Allowed {
+			return tooManyRequests(c, result)
+		}
+
+		return next(c)
+	}
+}
+
+// RateLimitIP limits the rate of requests of each IP address before authentication, so the clients
+// sending bad credentials are limited too. Only the requests over the limit describe it
+func (ct *Container) RateLimitIP(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if ct.limiter == nil || !ct.limiter.Enabled {
+			return next(c)
+		}
+
+		result, err := ct.limiter.AllowIP(c.Request().Context(), c.RealIP())
+		if err != nil {
+			ct.logger.Error("error while checking the IP rate limit", zap.Error(err))
+			return next(c)
+		}
+
+		if !result.Allowed {
+			setRateLimitHeaders(c, result)
+			return tooManyRequests(c, result)
+		}
+
+		return next(c)
+	}
+}
+
+func setRateLimitHeaders(c echo.Context, result ratelimit.Result) {
+	header := c.Response().Header()
+	header.Set(HeaderRateLimitLimit, strconv.Itoa(result.Limit))
+	header.Set(HeaderRateLimitRemaining, strconv.Itoa(result.Remaining))
+	header.Set(HeaderRateLimitReset, ceilSeconds(result.Reset))
+}
+
+func tooManyRequests(c echo.Context, result ratelimit.Result) error {
+	c.Response().Header().Set(HeaderRetryAfter, ceilSeconds(result.RetryAfter))
+	return problem.Write(c, http.StatusTooManyRequests, "rate limit exceeded, retry after "+ceilSeconds(result.RetryAfter)+" seconds")
+}
+
+// clientKey identifies the client making the request
+func clientKey(c echo.Context) string {
+	p, ok := auth.FromContext(c.Request().Context())
+
+	switch {
+	case ok && p.Method == auth.MethodAPIKey:
+		return "key:" + strconv.Itoa(p.KeyID)
+	case ok && p.Method != auth.MethodNone:
+		return "user:" + p.Subject
+	}
+
+	return "ip:" + c.RealIP()
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
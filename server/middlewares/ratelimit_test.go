@@ -0,0 +1,140 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/LucasFrezarini/go-contacts/auth"
+	"github.com/LucasFrezarini/go-contacts/config"
+	"github.com/LucasFrezarini/go-contacts/ratelimit"
+	"github.com/LucasFrezarini/go-contacts/server/problem"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+func TestRateLimit(t *testing.T) {
+	cfg := &config.Config{RateLimit: config.RateLimit{
+		Enabled: true,
+		Default: "100/1m",
+		Routes:  map[string]string{"POST /contacts/": "2/1m"},
+	}}
+
+	limiter, err := ratelimit.ProvideLimiter(cfg, ratelimit.ProvideMemoryStore())
+	if err != nil {
+		t.Fatalf("ProvideLimiter() returned an error: %v", err)
+	}
+
+	ct := ProvideMiddlewaresContainer(cfg, zap.NewNop(), nil, nil, limiter)
+
+	e := echo.New()
+	e.Use(ct.RateLimit)
+	e.POST("/contacts/", func(c echo.Context) error {
+		return c.NoContent(http.StatusCreated)
+	})
+
+	post := func(ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/contacts/", nil)
+		req.RemoteAddr = ip + ":4321"
+
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	for i, remaining := range []string{"1", "0"} {
+		rec := post("10.0.0.1")
+
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("request #%d wrote status %d, want %d", i+1, rec.Code, http.StatusCreated)
+		}
+
+		if got := rec.Header().Get(HeaderRateLimitLimit); got != "2" {
+			t.Errorf("request #%d %s == %q, want %q", i+1, HeaderRateLimitLimit, got, "2")
+		}
+
+		if got := rec.Header().Get(HeaderRateLimitRemaining); got != remaining {
+			t.Errorf("request #%d %s == %q, want %q", i+1, HeaderRateLimitRemaining, got, remaining)
+		}
+	}
+
+	rec := post("10.0.0.1")
+
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("request over the limit wrote status %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+
+	if contentType := rec.Header().Get(echo.HeaderContentType); !strings.HasPrefix(contentType, problem.ContentType) {
+		t.Errorf("request over the limit Content-Type == %q, want %q", contentType, problem.ContentType)
+	}
+
+	// a token is refilled every 30 seconds
+	if got := rec.Header().Get(HeaderRetryAfter); got != "30" {
+		t.Errorf("request over the limit %s == %q, want %q", HeaderRetryAfter, got, "30")
+	}
+
+	if rec := post("10.0.0.2"); rec.Code != http.StatusCreated {
+		t.Errorf("request of another client wrote status %d, want %d", rec.Code, http.StatusCreated)
+	}
+}
+
+func TestRateLimitIPBeforeAuthentication(t *testing.T) {
+	cfg := &config.Config{
+		Auth:      config.Auth{Enabled: true, HS256Secret: "testing"},
+		RateLimit: config.RateLimit{Enabled: true, Default: "100/1m", PerIP: "3/1m"},
+	}
+
+	authenticator, err := auth.ProvideJWTAuthenticator(cfg)
+	if err != nil {
+		t.Fatalf("ProvideJWTAuthenticator() returned an error: %v", err)
+	}
+
+	limiter, err := ratelimit.ProvideLimiter(cfg, ratelimit.ProvideMemoryStore())
+	if err != nil {
+		t.Fatalf("ProvideLimiter() returned an error: %v", err)
+	}
+
+	ct := ProvideMiddlewaresContainer(cfg, zap.NewNop(), authenticator, fakeKeys{}, limiter)
+
+	e := echo.New()
+	e.Use(ct.RateLimitIP)
+	e.Use(ct.Authenticate)
+	e.Use(ct.RateLimit)
+	e.GET("/contacts/", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	get := func(ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/contacts/", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer not-a-token")
+		req.RemoteAddr = ip + ":4321"
+
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	for i := 0; i < 3; i++ {
+		if rec := get("10.0.0.1"); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("request #%d with bad credentials wrote status %d, want %d", i+1, rec.Code, http.StatusUnauthorized)
+		}
+	}
+
+	rec := get("10.0.0.1")
+
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("request with bad credentials over the limit wrote status %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+
+	// a token is refilled every 20 seconds
+	if got := rec.Header().Get(HeaderRetryAfter); got != "20" {
+		t.Errorf("request over the limit %s == %q, want %q", HeaderRetryAfter, got, "20")
+	}
+
+	if rec := get("10.0.0.2"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("request of another IP address wrote status %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
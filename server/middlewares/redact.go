@@ -0,0 +1,95 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveHeaders are the request headers whose values must never be logged
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+	"X-Api-Key":           true,
+	"Proxy-Authorization": true,
+}
+
+// piiFields are the JSON keys whose values are considered personal data or secrets.
+// When found anywhere in a request body, the whole value is replaced
+var piiFields = map[string]bool{
+	"first_name":  true,
+	"last_name":   true,
+	"emails":      true,
+	"email":       true,
+	"address":     true,
+	"phones":      true,
+	"phone":       true,
+	"number":      true,
+	"addresses":   true,
+	"street":      true,
+	"locality":    true,
+	"region":      true,
+	"postal_code": true,
+	"country":     true,
+	"password":    true,
+	"token":       true,
+}
+
+func redactHeaders(h http.Header) map[string]string {
+	headers := make(map[string]string, len(h))
+
+	for k, v := range h {
+		if sensitiveHeaders[http.CanonicalHeaderKey(k)] {
+			headers[k] = redacted
+			continue
+		}
+
+		headers[k] = strings.Join(v, ", ")
+	}
+
+	return headers
+}
+
+// redactBody returns a loggable version of a request body, with all the PII fields redacted and
+// truncated to maxSize bytes. Bodies that aren't valid JSON are omitted entirely, since there
+// is no way to know what they contain
+func redactBody(body []byte, maxSize int) []byte {
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return []byte("[non-JSON body omitted]")
+	}
+
+	b, err := json.Marshal(redactValue(data))
+	if err != nil {
+		return []byte("[body omitted]")
+	}
+
+	if maxSize > 0 && len(b) > maxSize {
+		b = append(b[:maxSize], "...[truncated]"...)
+	}
+
+	return b
+}
+
+func redactValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, field := range value {
+			if piiFields[strings.ToLower(k)] {
+				value[k] = redacted
+				continue
+			}
+
+			value[k] = redactValue(field)
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = redactValue(item)
+		}
+	}
+
+	return v
+}
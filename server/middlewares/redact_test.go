@@ -0,0 +1,46 @@
+package middlewares
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedactBody(t *testing.T) {
+	var testCases = []struct {
+		testName string
+		body     string
+		pii      []string
+		kept     []string
+	}{
+		{
+			"contact_addresses",
+			`{"first_name":"Zenitsu","addresses":[{"street":"Av. Paulista, 1000","locality":"São Paulo","postal_code":"01310-100","country":"BR"}]}`,
+			[]string{"Zenitsu", "Paulista", "São Paulo", "01310-100", "BR"},
+			[]string{"addresses"},
+		},
+		{
+			"address_sub_resource",
+			`{"street":"1-1 Chiyoda","locality":"Tokyo","region":"Tokyo","postal_code":"100-8111","country":"JP","label":"work"}`,
+			[]string{"Chiyoda", "Tokyo", "100-8111", "JP"},
+			[]string{`"label":"work"`},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			redactedBody := string(redactBody([]byte(tc.body), 0))
+
+			for _, pii := range tc.pii {
+				if strings.Contains(redactedBody, pii) {
+					t.Errorf("redactBody() = %s, contains the PII %q", redactedBody, pii)
+				}
+			}
+
+			for _, kept := range tc.kept {
+				if !strings.Contains(redactedBody, kept) {
+					t.Errorf("redactBody() = %s, want it to keep %q", redactedBody, kept)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package problem
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ContentType is the media type of the problem details responses
+const ContentType = "application/problem+json"
+
+// A Problem is an RFC 7807 problem details object, used as the body of every error response
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// New creates a Problem for the provided status, titled after the HTTP status text
+func New(status int, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// Write writes a problem response with the provided status and detail to the echo context
+func Write(c echo.Context, status int, detail string) error {
+	p := New(status, detail)
+	p.Instance = c.Request().URL.Path
+
+	c.Response().Header().Set(echo.HeaderContentType, ContentType)
+	return c.JSON(status, p)
+}
@@ -1,6 +1,10 @@
 package routes
 
 import (
+	"github.com/LucasFrezarini/go-contacts/addressbook"
+	"github.com/LucasFrezarini/go-contacts/admin"
+	"github.com/LucasFrezarini/go-contacts/apikey"
+	"github.com/LucasFrezarini/go-contacts/audit"
 	"github.com/LucasFrezarini/go-contacts/contacts"
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
@@ -9,6 +13,10 @@ import (
 // A Router provides functions to build the server routing, based on Go *http.ServeMux
 type Router struct {
 	contactsController *contacts.Controller
+	adminController    *admin.Controller
+	bookController     *addressbook.Controller
+	keyController      *apikey.Controller
+	auditController    *audit.Controller
 	logger             *zap.Logger
 	echo               *echo.Echo
 }
@@ -16,10 +24,16 @@ type Router struct {
 // BuildRouter initialize all routing groups of the server
 func (r *Router) BuildRouter() {
 	r.contactsController.EchoGroup()
+	r.adminController.EchoGroup()
+	r.bookController.EchoGroup()
+	r.keyController.EchoGroup()
+	r.auditController.EchoGroup()
 }
 
 // ProvideRouter is responsible by building the Router object. Designed especially for the use of
 // wire, to provide the dependencies via DI
-func ProvideRouter(cc *contacts.Controller, logger *zap.Logger, echo *echo.Echo) *Router {
-	return &Router{contactsController: cc, logger: logger, echo: echo}
+func ProvideRouter(cc *contacts.Controller, ac *admin.Controller, bc *addressbook.Controller, kc *apikey.Controller,
+	adc *audit.Controller, logger *zap.Logger, echo *echo.Echo) *Router {
+	return &Router{contactsController: cc, adminController: ac, bookController: bc, keyController: kc,
+		auditController: adc, logger: logger, echo: echo}
 }
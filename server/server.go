@@ -1,20 +1,34 @@
 package server
 
 import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/LucasFrezarini/go-contacts/addressbook"
+	"github.com/LucasFrezarini/go-contacts/admin"
+	"github.com/LucasFrezarini/go-contacts/apikey"
+	"github.com/LucasFrezarini/go-contacts/audit"
+	"github.com/LucasFrezarini/go-contacts/auth"
+	"github.com/LucasFrezarini/go-contacts/config"
 	"github.com/LucasFrezarini/go-contacts/contacts"
 	"github.com/LucasFrezarini/go-contacts/db"
 	"github.com/LucasFrezarini/go-contacts/logger"
+	"github.com/LucasFrezarini/go-contacts/ratelimit"
 	"github.com/LucasFrezarini/go-contacts/server/middlewares"
 	"github.com/LucasFrezarini/go-contacts/server/routes"
 	"github.com/LucasFrezarini/go-contacts/server/validator"
 	"github.com/google/wire"
 	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
 	"go.uber.org/zap"
 )
 
 // A Server contains all the artifacts necessary to start a fresh app's server instance
 type Server struct {
+	config *config.Config
 	router *routes.Router
+	purge  *contacts.PurgeJob
 	Logger *zap.Logger
 	echo   *echo.Echo
 }
@@ -23,25 +37,90 @@ type Server struct {
 // so it will ever return an error when the server goes down.
 func (s *Server) Start() error {
 	s.router.BuildRouter()
-	s.Logger.Info("starting HTTP server on port 8080...")
-	return s.echo.Start(":8080")
+	s.Logger.Debug("loaded configuration:\n" + s.config.String())
+	go s.purge.Run(context.Background())
+	s.Logger.Info("starting HTTP server on " + s.config.Server.Address + "...")
+	return s.echo.Start(s.config.Server.Address)
+}
+
+// Shutdown stops the HTTP server gracefully, waiting for the requests in progress until ctx is done
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.Logger.Info("shutting down HTTP server...")
+	return s.echo.Shutdown(ctx)
 }
 
 // ProvideServer provides a Server object, built for the use of wire
-func ProvideServer(r *routes.Router, logger *zap.Logger, echo *echo.Echo) *Server {
-	return &Server{router: r, Logger: logger.Named("Server"), echo: echo}
+func ProvideServer(cfg *config.Config, r *routes.Router, purge *contacts.PurgeJob, logger *zap.Logger, echo *echo.Echo) *Server {
+	return &Server{config: cfg, router: r, purge: purge, Logger: logger.Named("Server"), echo: echo}
 }
 
-// ProvideEcho provides a brand new echo instance
-func ProvideEcho(middlewares *middlewares.Container) *echo.Echo {
+// ProvideEcho provides a brand new echo instance. CORS preflight requests are answered before
+// authentication, as browsers never send credentials with them, and each IP address is rate limited
+// before authentication too, so bad credentials can't be tried without limit
+func ProvideEcho(cfg *config.Config, middlewares *middlewares.Container) *echo.Echo {
 	e := echo.New()
 	e.Validator = validator.NewCustomValidator()
+	e.IPExtractor = ipExtractor(cfg.Server.TrustedProxies)
 
+	e.Use(middlewares.RequestID)
 	e.Use(middlewares.ZapHTTPLogger)
 
+	if cors := cfg.Server.CORS; len(cors.AllowOrigins) != 0 {
+		e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
+			AllowOrigins:     cors.AllowOrigins,
+			AllowMethods:     cors.AllowMethods,
+			AllowHeaders:     cors.AllowHeaders,
+			ExposeHeaders:    cors.ExposeHeaders,
+			AllowCredentials: cors.AllowCredentials,
+			MaxAge:           cors.MaxAge,
+		}))
+	}
+
+	e.Use(middleware.SecureWithConfig(middleware.SecureConfig{
+		XSSProtection:         "1; mode=block",
+		ContentTypeNosniff:    "nosniff",
+		XFrameOptions:         "DENY",
+		HSTSMaxAge:            cfg.Server.Security.HSTSMaxAge,
+		ContentSecurityPolicy: cfg.Server.Security.ContentSecurityPolicy,
+		ReferrerPolicy:        cfg.Server.Security.ReferrerPolicy,
+	}))
+
+	e.Use(middleware.BodyLimitWithConfig(middleware.BodyLimitConfig{Limit: cfg.Server.MaxBodySize, Skipper: isImport}))
+	e.Use(middleware.BodyLimitWithConfig(middleware.BodyLimitConfig{
+		Limit:   cfg.Server.MaxImportBodySize,
+		Skipper: func(c echo.Context) bool { return !isImport(c) },
+	}))
+
+	e.Use(middlewares.RateLimitIP)
+	e.Use(middlewares.Authenticate)
+	e.Use(middlewares.RateLimit)
+
 	return e
 }
 
+// ipExtractor identifies the clients by the address of the peer, so they can't pick another one for each request
+// to escape the rate limits. Behind the trusted proxies, the client is the nearest untrusted X-Forwarded-For address
+func ipExtractor(proxies []string) echo.IPExtractor {
+	if len(proxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, cidr := range proxies {
+		// the configuration was already validated
+		if _, ipRange, err := net.ParseCIDR(cidr); err == nil {
+			options = append(options, echo.TrustIPRange(ipRange))
+		}
+	}
+
+	return echo.ExtractIPFromXFFHeader(options...)
+}
+
+// isImport checks if the request is made to an import endpoint, which accepts bigger bodies
+func isImport(c echo.Context) bool {
+	return strings.HasSuffix(strings.TrimSuffix(c.Request().URL.Path, "/"), "/import")
+}
+
 // ServerSet is the wire.ProviderSet of the server package
 var ServerSet = wire.NewSet(
 	ProvideEcho,
@@ -49,6 +128,13 @@ var ServerSet = wire.NewSet(
 	middlewares.ProvideMiddlewaresContainer,
 	routes.ProvideRouter,
 	contacts.Set,
+	admin.Set,
+	addressbook.Set,
+	apikey.Set,
+	audit.Set,
+	auth.Set,
+	ratelimit.Set,
 	logger.LoggerSet,
 	db.DBSet,
+	config.Set,
 )
@@ -0,0 +1,228 @@
+package server
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/LucasFrezarini/go-contacts/config"
+	"github.com/LucasFrezarini/go-contacts/ratelimit"
+	"github.com/LucasFrezarini/go-contacts/server/middlewares"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+func newTestEcho(t *testing.T, cors config.CORS) *echo.Echo {
+	cfg := &config.Config{
+		Server: config.Server{
+			MaxBodySize:       "16B",
+			MaxImportBodySize: "64B",
+			CORS:              cors,
+			Security: config.Security{
+				HSTSMaxAge:            31536000,
+				ContentSecurityPolicy: "default-src 'none'",
+				ReferrerPolicy:        "no-referrer",
+			},
+		},
+		HTTPLog: config.HTTPLog{LogBody: true},
+		Auth:    config.Auth{Enabled: false},
+	}
+
+	e := ProvideEcho(cfg, middlewares.ProvideMiddlewaresContainer(cfg, zap.NewNop(), nil, nil, nil))
+
+	handler := func(c echo.Context) error {
+		var body map[string]interface{}
+		if err := c.Bind(&body); err != nil {
+			return err
+		}
+
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	e.POST("/contacts/", handler)
+	e.POST("/contacts/import", handler)
+
+	return e
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	e := newTestEcho(t, config.CORS{})
+
+	req := httptest.NewRequest(http.MethodPost, "/contacts/", strings.NewReader("{}"))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(echo.HeaderXForwardedProto, "https")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	expected := map[string]string{
+		echo.HeaderXContentTypeOptions:      "nosniff",
+		echo.HeaderXFrameOptions:            "DENY",
+		echo.HeaderContentSecurityPolicy:    "default-src 'none'",
+		echo.HeaderStrictTransportSecurity:  "max-age=31536000; includeSubdomains",
+		echo.HeaderReferrerPolicy:           "no-referrer",
+		echo.HeaderAccessControlAllowOrigin: "",
+	}
+
+	for header, value := range expected {
+		if got := rec.Header().Get(header); got != value {
+			t.Errorf("POST /contacts/ %s == %q, want %q", header, got, value)
+		}
+	}
+}
+
+func TestCORS(t *testing.T) {
+	e := newTestEcho(t, config.CORS{
+		AllowOrigins:  []string{"https://app.example.com"},
+		AllowMethods:  []string{http.MethodGet, http.MethodPost},
+		AllowHeaders:  []string{echo.HeaderAuthorization, echo.HeaderContentType},
+		ExposeHeaders: []string{"ETag"},
+		MaxAge:        600,
+	})
+
+	req := httptest.NewRequest(http.MethodOptions, "/contacts/", nil)
+	req.Header.Set(echo.HeaderOrigin, "https://app.example.com")
+	req.Header.Set(echo.HeaderAccessControlRequestMethod, http.MethodPost)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("preflight request wrote status %d, want %d", rec.Code, http.StatusNoContent)
+	}
+
+	expected := map[string]string{
+		echo.HeaderAccessControlAllowOrigin:  "https://app.example.com",
+		echo.HeaderAccessControlAllowMethods: "GET,POST",
+		echo.HeaderAccessControlAllowHeaders: "Authorization,Content-Type",
+		echo.HeaderAccessControlMaxAge:       "600",
+	}
+
+	for header, value := range expected {
+		if got := rec.Header().Get(header); got != value {
+			t.Errorf("preflight request %s == %q, want %q", header, got, value)
+		}
+	}
+
+	req = httptest.NewRequest(http.MethodOptions, "/contacts/", nil)
+	req.Header.Set(echo.HeaderOrigin, "https://evil.example.com")
+	req.Header.Set(echo.HeaderAccessControlRequestMethod, http.MethodPost)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get(echo.HeaderAccessControlAllowOrigin); got != "" {
+		t.Errorf("preflight request from an unknown origin %s == %q, want it empty", echo.HeaderAccessControlAllowOrigin, got)
+	}
+}
+
+func TestBodyLimit(t *testing.T) {
+	var testCases = []struct {
+		path   string
+		body   string
+		status int
+	}{
+		{"/contacts/", `{"first_name": "Tanjiro"}`, http.StatusRequestEntityTooLarge},
+		{"/contacts/", `{"a": "b"}`, http.StatusNoContent},
+		{"/contacts/import", `{"first_name": "Tanjiro"}`, http.StatusNoContent},
+		{"/contacts/import", `{"first_name": "` + strings.Repeat("a", 64) + `"}`, http.StatusRequestEntityTooLarge},
+	}
+
+	e := newTestEcho(t, config.CORS{})
+
+	for _, tc := range testCases {
+		req := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		if rec.Code != tc.status {
+			t.Errorf("POST %s with a %d bytes body wrote status %d, want %d", tc.path, len(tc.body), rec.Code, tc.status)
+		}
+	}
+}
+
+// countingReader counts the bytes read from it
+type countingReader struct {
+	io.Reader
+	read int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.read += n
+	return n, err
+}
+
+func TestBodyLimitBeforeBodyLogging(t *testing.T) {
+	e := newTestEcho(t, config.CORS{})
+	e.POST("/contacts/raw", func(c echo.Context) error {
+		if _, err := ioutil.ReadAll(c.Request().Body); err != nil {
+			return err
+		}
+
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	body := &countingReader{Reader: strings.NewReader(strings.Repeat("a", 1<<20))}
+	req := httptest.NewRequest(http.MethodPost, "/contacts/raw", body)
+	// without a Content-Length, the limit can only be enforced while the body is read
+	req.ContentLength = -1
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("POST /contacts/raw with a 1MB chunked body wrote status %d, want %d", rec.Code, http.StatusRequestEntityTooLarge)
+	}
+
+	if body.read > 4096 {
+		t.Errorf("POST /contacts/raw with a 1MB chunked body read %d bytes of it, want it to stop at the body limit", body.read)
+	}
+}
+
+func TestRateLimitClientIP(t *testing.T) {
+	var testCases = []struct {
+		testName string
+		proxies  []string
+		status   int
+	}{
+		// the peer can't escape its limit by forging the address of another client
+		{"forged_forwarded_for", nil, http.StatusTooManyRequests},
+		{"trusted_proxy", []string{"10.0.0.0/8"}, http.StatusNoContent},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			cfg := &config.Config{
+				Server:    config.Server{MaxBodySize: "1M", MaxImportBodySize: "1M", TrustedProxies: tc.proxies},
+				Auth:      config.Auth{Enabled: false},
+				RateLimit: config.RateLimit{Enabled: true, Default: "100/1m", PerIP: "1/1m"},
+			}
+
+			limiter, err := ratelimit.ProvideLimiter(cfg, ratelimit.ProvideMemoryStore())
+			if err != nil {
+				t.Fatalf("ProvideLimiter() returned an error: %v", err)
+			}
+
+			e := ProvideEcho(cfg, middlewares.ProvideMiddlewaresContainer(cfg, zap.NewNop(), nil, nil, limiter))
+			e.GET("/contacts/", func(c echo.Context) error {
+				return c.NoContent(http.StatusNoContent)
+			})
+
+			var rec *httptest.ResponseRecorder
+			for _, client := range []string{"203.0.113.1", "203.0.113.2"} {
+				req := httptest.NewRequest(http.MethodGet, "/contacts/", nil)
+				req.RemoteAddr = "10.0.0.1:4321"
+				req.Header.Set(echo.HeaderXForwardedFor, client)
+
+				rec = httptest.NewRecorder()
+				e.ServeHTTP(rec, req)
+			}
+
+			if rec.Code != tc.status {
+				t.Errorf("GET /contacts/ forwarded for another client wrote status %d, want %d", rec.Code, tc.status)
+			}
+		})
+	}
+}
@@ -1,6 +1,11 @@
 package validator
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/LucasFrezarini/go-contacts/contacts/phone"
 	v "github.com/go-playground/validator/v10"
 )
 
@@ -12,10 +17,42 @@ type CustomValidator struct {
 
 // Validate uses the go-playground/validator to validate the request body passed as parameter
 func (cv *CustomValidator) Validate(i interface{}) error {
-	return cv.Validator.Struct(i)
+	err := cv.Validator.Struct(i)
+
+	var fieldErrors v.ValidationErrors
+	if !errors.As(err, &fieldErrors) {
+		return err
+	}
+
+	messages := make([]string, 0, len(fieldErrors))
+	for _, fe := range fieldErrors {
+		messages = append(messages, message(fe))
+	}
+
+	return errors.New(strings.Join(messages, "\n"))
 }
 
-// NewCustomValidator returns a ready to use CustomValidator to integrate with Echo
+// message describes the field error, telling the valid values of the custom tags
+func message(fe v.FieldError) string {
+	switch fe.Tag() {
+	case "phone_type":
+		return fmt.Sprintf("Key: '%s' Error:Field validation for '%s' failed: %q must be one of %s",
+			fe.Namespace(), fe.Field(), fe.Value(), strings.Join(phone.Types, ", "))
+	default:
+		return fmt.Sprintf("Key: '%s' Error:Field validation for '%s' failed on the '%s' tag", fe.Namespace(), fe.Field(), fe.Tag())
+	}
+}
+
+// NewCustomValidator returns a ready to use CustomValidator to integrate with Echo,
+// along with the custom tags:
+//
+// phone_type: the field is one of the phone types
 func NewCustomValidator() *CustomValidator {
-	return &CustomValidator{Validator: v.New()}
+	validate := v.New()
+
+	validate.RegisterValidation("phone_type", func(fl v.FieldLevel) bool {
+		return phone.IsValidType(fl.Field().String())
+	})
+
+	return &CustomValidator{Validator: validate}
 }
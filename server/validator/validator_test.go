@@ -0,0 +1,32 @@
+package validator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidatePhoneType(t *testing.T) {
+	type body struct {
+		Type string `validate:"omitempty,phone_type"`
+		Name string `validate:"required"`
+	}
+
+	cv := NewCustomValidator()
+
+	for _, valid := range []string{"", "mobile", "home", "work", "fax"} {
+		if err := cv.Validate(body{Type: valid, Name: "Tanjiro"}); err != nil {
+			t.Errorf("Validate() of the type %q returned %v, want nil", valid, err)
+		}
+	}
+
+	err := cv.Validate(body{Type: "mobil"})
+	if err == nil {
+		t.Fatal("Validate() of an unknown type returned nil, want an error")
+	}
+
+	for _, s := range []string{`"mobil" must be one of mobile, home, work, fax`, "'Name' failed on the 'required' tag"} {
+		if !strings.Contains(err.Error(), s) {
+			t.Errorf("Validate() error %q doesn't mention %q", err, s)
+		}
+	}
+}
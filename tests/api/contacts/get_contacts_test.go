@@ -23,11 +23,13 @@ func TestMain(m *testing.M) {
 }
 
 func TestGetAllContacts(t *testing.T) {
-	app, err := container.InitializeServer()
+	app, cleanup, err := container.InitializeServer(nil)
 	if err != nil {
 		t.Fatalf("GET /contacts/: error while initializing server from container: %v", err)
 	}
 
+	defer cleanup()
+
 	srv := httptest.NewServer(app.Router.BuildMux())
 	defer srv.Close()
 
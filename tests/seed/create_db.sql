@@ -1,10 +1,36 @@
 USE go_contacts_test;
 
+CREATE TABLE `address_book` (
+  `id` INT NOT NULL AUTO_INCREMENT,
+  `name` VARCHAR(100) NOT NULL,
+  PRIMARY KEY (`id`)
+);
+
+CREATE TABLE `address_book_member` (
+  `address_book_id` INT NOT NULL,
+  `subject` VARCHAR(255) NOT NULL,
+  `role` ENUM('viewer', 'editor', 'owner') NOT NULL,
+  PRIMARY KEY (`address_book_id`, `subject`),
+  INDEX `idx_address_book_member_subject` (`subject`),
+  CONSTRAINT `fk_address_book_member_book` FOREIGN KEY (`address_book_id`)
+    REFERENCES `address_book`(`id`)
+    ON DELETE CASCADE
+);
+
 CREATE TABLE `contact` (
   `id` INT NOT NULL AUTO_INCREMENT,
+  `owner` VARCHAR(255) NOT NULL DEFAULT '',
+  `address_book_id` INT NULL,
   `first_name` varchar(50) NOT NULL,
   `last_name` varchar(50) DEFAULT NULL,
-  PRIMARY KEY (`id`)
+  `deleted_at` DATETIME NULL,
+  `version` INT NOT NULL DEFAULT 1,
+  PRIMARY KEY (`id`),
+  INDEX `idx_contact_owner` (`owner`),
+  INDEX `idx_contact_deleted_at` (`deleted_at`),
+  CONSTRAINT `fk_contact_address_book` FOREIGN KEY (`address_book_id`)
+    REFERENCES `address_book`(`id`)
+    ON DELETE CASCADE
 );
 
 CREATE TABLE `email` (
@@ -26,4 +52,146 @@ CREATE TABLE `phone` (
   CONSTRAINT `fk_phone_contact` FOREIGN KEY (`contact_id`)
     REFERENCES `contact`(`id`)
     ON DELETE CASCADE
-);
\ No newline at end of file
+);
+
+CREATE TABLE `api_key` (
+  `id` INT NOT NULL AUTO_INCREMENT,
+  `subject` VARCHAR(255) NOT NULL,
+  `name` VARCHAR(100) NOT NULL,
+  `prefix` CHAR(8) NOT NULL,
+  `hash` CHAR(64) NOT NULL,
+  `scope` ENUM('read', 'read-write') NOT NULL,
+  `created_at` DATETIME NOT NULL,
+  `last_used_at` DATETIME NULL,
+  `revoked_at` DATETIME NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_api_key_prefix` (`prefix`),
+  INDEX `idx_api_key_subject` (`subject`)
+);
+
+CREATE TABLE `audit_entry` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT,
+  `actor` VARCHAR(255) NOT NULL,
+  `request_id` VARCHAR(64) NOT NULL DEFAULT '',
+  `action` ENUM('create', 'update', 'delete', 'restore') NOT NULL,
+  `entity` ENUM('contact', 'email', 'phone') NOT NULL,
+  `entity_id` INT NOT NULL,
+  `contact_id` INT NOT NULL,
+  `address_book_id` INT NULL,
+  `owner` VARCHAR(255) NOT NULL DEFAULT '',
+  `before_state` JSON NULL,
+  `after_state` JSON NULL,
+  `changes` JSON NOT NULL,
+  `created_at` DATETIME(6) NOT NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_audit_entry_contact` (`contact_id`),
+  INDEX `idx_audit_entry_owner` (`owner`),
+  INDEX `idx_audit_entry_address_book` (`address_book_id`),
+  INDEX `idx_audit_entry_created_at` (`created_at`)
+);
+
+CREATE TABLE `contact_revision` (
+  `contact_id` INT NOT NULL,
+  `number` INT NOT NULL,
+  `actor` VARCHAR(255) NOT NULL DEFAULT '',
+  `state` JSON NOT NULL,
+  `created_at` DATETIME(6) NOT NULL,
+  PRIMARY KEY (`contact_id`, `number`),
+  CONSTRAINT `fk_contact_revision_contact` FOREIGN KEY (`contact_id`)
+    REFERENCES `contact`(`id`)
+    ON DELETE CASCADE
+);
+
+-- Each merge of contacts stores the state of its target before the merge, along with the
+-- emails and phones it moved from the sources, so it can be undone.
+CREATE TABLE `contact_merge` (
+  `id` INT NOT NULL AUTO_INCREMENT,
+  `target_id` INT NOT NULL,
+  `source_ids` JSON NOT NULL,
+  `target_state` JSON NOT NULL,
+  `moved` JSON NOT NULL,
+  `actor` VARCHAR(255) NOT NULL DEFAULT '',
+  `created_at` DATETIME(6) NOT NULL,
+  `undone_at` DATETIME(6) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_contact_merge_target` (`target_id`),
+  CONSTRAINT `fk_contact_merge_contact` FOREIGN KEY (`target_id`)
+    REFERENCES `contact`(`id`)
+    ON DELETE CASCADE
+);
+
+-- The normalized form of each email address, lowercased with its domain in punycode, is what
+-- addresses are compared by. The existing addresses are backfilled lowercased and trimmed.
+ALTER TABLE `email`
+  ADD COLUMN `normalized` VARCHAR(320) NOT NULL DEFAULT '',
+  ADD INDEX `idx_email_normalized` (`normalized`);
+
+UPDATE `email` SET `normalized` = LOWER(TRIM(`address`));
+
+-- The canonical E.164 form of each phone number is stored alongside the number as it was provided.
+-- It's left empty for the existing numbers, which were never parsed.
+ALTER TABLE `phone`
+  ADD COLUMN `e164` VARCHAR(16) NOT NULL DEFAULT '',
+  ADD INDEX `idx_phone_e164` (`e164`);
+
+-- Phones may have a label given by the user, like "office reception", stored apart from their type
+-- so the type stays one of the known ones.
+ALTER TABLE `phone`
+  ADD COLUMN `label` VARCHAR(50) NOT NULL DEFAULT '';
+
+-- Each contact has at most one primary email and one primary phone, the ones mailers and dialers use.
+-- The invariant is kept by the repositories, within the transactions of the service. The first email
+-- and the first phone of the existing contacts are backfilled as their primary ones.
+ALTER TABLE `email`
+  ADD COLUMN `is_primary` BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE `phone`
+  ADD COLUMN `is_primary` BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE `email` e
+  JOIN (SELECT MIN(`id`) AS `id` FROM `email` GROUP BY `contact_id`) f ON e.`id` = f.`id`
+  SET e.`is_primary` = TRUE;
+
+UPDATE `phone` p
+  JOIN (SELECT MIN(`id`) AS `id` FROM `phone` GROUP BY `contact_id`) f ON p.`id` = f.`id`
+  SET p.`is_primary` = TRUE;
+
+-- Emails have a label, one of home, work and other or a custom one given by the user.
+-- The existing emails are labeled as other.
+ALTER TABLE `email`
+  ADD COLUMN `label` VARCHAR(50) NOT NULL DEFAULT 'other',
+  ADD INDEX `idx_email_label` (`label`);
+
+-- The postal addresses of the contacts, in structured fields. The country is an ISO 3166-1 alpha-2 code,
+-- empty when it's unknown, and the label one of home, work and other or a custom one given by the user.
+CREATE TABLE `address` (
+  `id` INT NOT NULL AUTO_INCREMENT,
+  `contact_id` INT NOT NULL,
+  `street` VARCHAR(255) NOT NULL DEFAULT '',
+  `locality` VARCHAR(100) NOT NULL DEFAULT '',
+  `region` VARCHAR(100) NOT NULL DEFAULT '',
+  `postal_code` VARCHAR(20) NOT NULL DEFAULT '',
+  `country` CHAR(2) NOT NULL DEFAULT '',
+  `label` VARCHAR(50) NOT NULL DEFAULT 'other',
+  PRIMARY KEY(`id`),
+  CONSTRAINT `fk_address_contact` FOREIGN KEY (`contact_id`)
+    REFERENCES `contact`(`id`)
+    ON DELETE CASCADE
+);
+
+-- Contacts may have the organization they work for, their department and their job title, empty when unknown.
+-- The organization is indexed since the contacts can be filtered, sorted and grouped by it.
+ALTER TABLE `contact`
+  ADD COLUMN `organization` VARCHAR(100) NOT NULL DEFAULT '',
+  ADD COLUMN `department` VARCHAR(100) NOT NULL DEFAULT '',
+  ADD COLUMN `title` VARCHAR(100) NOT NULL DEFAULT '',
+  ADD INDEX `idx_contact_organization` (`organization`);
+
+-- The changes made to the postal addresses of the contacts are recorded in the audit log too.
+ALTER TABLE `audit_entry`
+  MODIFY COLUMN `entity` ENUM('contact', 'email', 'phone', 'address') NOT NULL;
+
+-- Each merge stores the version of its target right after the merge, so it's only undone while the target
+-- wasn't changed since. The merges recorded before don't have it, so they can no longer be undone.
+ALTER TABLE `contact_merge`
+  ADD COLUMN `target_version` INT NOT NULL DEFAULT 0;
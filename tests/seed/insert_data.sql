@@ -1,7 +1,7 @@
 USE go_contacts_test;
 
 INSERT INTO 
-    `contact` (first_name, last_name) 
+    `contact` (owner, first_name, last_name) 
 VALUES 
-    ("Inosuke", "Hashibira"),
-    ("Gonpachiro", "Kamaboko");
\ No newline at end of file
+    ("anonymous", "Inosuke", "Hashibira"),
+    ("anonymous", "Gonpachiro", "Kamaboko");
\ No newline at end of file